@@ -0,0 +1,42 @@
+package entity
+
+import "time"
+
+// Cart is a user's persistent shopping cart. Each user has at most one cart.
+type Cart struct {
+	ID        int        `json:"id"`
+	UserID    int        `json:"user_id" gorm:"uniqueIndex"`
+	Items     []CartItem `json:"items" gorm:"foreignKey:CartID"`
+	Total     Money      `json:"total" gorm:"-"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// CartItem is a single product line in a cart.
+type CartItem struct {
+	ID        int   `json:"id"`
+	CartID    int   `json:"cart_id" gorm:"uniqueIndex:idx_cart_items_cart_product"`
+	ProductID int   `json:"product_id" gorm:"uniqueIndex:idx_cart_items_cart_product"`
+	Quantity  int   `json:"quantity"`
+	UnitPrice Money `json:"unit_price" gorm:"embedded;embeddedPrefix:unit_price_"`
+	Subtotal  Money `json:"subtotal" gorm:"-"`
+}
+
+// Recalculate updates the line subtotals and the cart total from the unit
+// prices. All items must be priced in the same currency.
+func (c *Cart) Recalculate() error {
+	c.Total = Money{}
+	for i := range c.Items {
+		subtotal, err := c.Items[i].UnitPrice.Mul(c.Items[i].Quantity)
+		if err != nil {
+			return err
+		}
+		c.Items[i].Subtotal = subtotal
+		total, err := c.Total.Add(subtotal)
+		if err != nil {
+			return err
+		}
+		c.Total = total
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package entity
+
+import "time"
+
+// ReservationStatus is the state of an inventory reservation.
+type ReservationStatus string
+
+const (
+	// ReservationHeld is a reservation whose stock is set aside for an
+	// order awaiting payment until the reservation expires.
+	ReservationHeld ReservationStatus = "held"
+	// ReservationCommitted is a reservation whose order was paid, so its
+	// stock is gone for good.
+	ReservationCommitted ReservationStatus = "committed"
+	// ReservationReleased is a reservation whose stock was put back because
+	// it expired or its order was cancelled or deleted.
+	ReservationReleased ReservationStatus = "released"
+)
+
+// InventoryReservation holds the stock of one line of an order while the
+// order awaits payment. The stock is taken out of the product when the
+// reservation is made; committing the reservation keeps it out, releasing
+// it puts it back.
+type InventoryReservation struct {
+	ID        int               `json:"id"`
+	OrderID   int               `json:"order_id" gorm:"index"`
+	ProductID int               `json:"product_id"`
+	Quantity  int               `json:"quantity"`
+	Status    ReservationStatus `json:"status" gorm:"size:20;default:held;index:idx_inventory_reservations_status_expires_at"`
+	ExpiresAt time.Time         `json:"expires_at" gorm:"index:idx_inventory_reservations_status_expires_at"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+// IsExpired reports whether the reservation is still held past its expiry at the given time.
+func (r InventoryReservation) IsExpired(now time.Time) bool {
+	return r.Status == ReservationHeld && !now.Before(r.ExpiresAt)
+}
@@ -0,0 +1,206 @@
+package entity
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/witchakornb/basic-ecommerce/domain/errs"
+)
+
+var (
+	// ErrCurrencyMismatch is returned when combining amounts in different currencies.
+	ErrCurrencyMismatch = errs.New(errs.ErrConflict, "currency_mismatch", "currency mismatch")
+	// ErrInvalidCurrency is returned for a currency that is not a three letter ISO 4217 code.
+	ErrInvalidCurrency = errs.New(errs.ErrValidation, "invalid_currency", "invalid currency code")
+	// ErrInvalidAmount is returned for an amount that cannot be represented exactly in its currency.
+	ErrInvalidAmount = errs.New(errs.ErrValidation, "invalid_amount", "invalid amount")
+)
+
+// minorUnitExponents lists the ISO 4217 currencies whose minor unit is not
+// a hundredth of the major unit. Every other currency uses two decimals.
+var minorUnitExponents = map[string]int{
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0, "KRW": 0,
+	"PYG": 0, "RWF": 0, "UGX": 0, "UYI": 0, "VND": 0, "VUV": 0, "XAF": 0, "XOF": 0, "XPF": 0,
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+}
+
+// Money is an exact monetary amount: an integer number of minor units
+// (e.g. cents) of an ISO 4217 currency. It is stored as two columns and
+// encoded in JSON as a decimal string so no precision is ever lost:
+//
+//	{"amount": "19.99", "currency": "USD"}
+type Money struct {
+	Amount   int64  `gorm:"column:amount"`
+	Currency string `gorm:"column:currency;size:3"`
+}
+
+// NewMoney returns an amount of minor units in the currency.
+func NewMoney(amount int64, currency string) (Money, error) {
+	currency = strings.ToUpper(currency)
+	if !isCurrencyCode(currency) {
+		return Money{}, ErrInvalidCurrency
+	}
+	return Money{Amount: amount, Currency: currency}, nil
+}
+
+// ParseMoney parses a decimal amount such as "19.99" in the currency.
+// Amounts with more decimals than the currency's minor unit are rejected
+// rather than rounded.
+func ParseMoney(amount, currency string) (Money, error) {
+	currency = strings.ToUpper(currency)
+	if !isCurrencyCode(currency) {
+		return Money{}, ErrInvalidCurrency
+	}
+	exponent := currencyExponent(currency)
+
+	negative := strings.HasPrefix(amount, "-")
+	digits := strings.TrimPrefix(amount, "-")
+	whole, fraction, _ := strings.Cut(digits, ".")
+	if whole == "" || len(fraction) > exponent || strings.HasPrefix(fraction, "-") {
+		return Money{}, ErrInvalidAmount
+	}
+	fraction += strings.Repeat("0", exponent-len(fraction))
+
+	minor, err := strconv.ParseInt(whole+fraction, 10, 64)
+	if err != nil || minor < 0 {
+		return Money{}, ErrInvalidAmount
+	}
+	if negative {
+		minor = -minor
+	}
+	return Money{Amount: minor, Currency: currency}, nil
+}
+
+// Zero returns a zero amount in the currency.
+func Zero(currency string) Money {
+	return Money{Currency: currency}
+}
+
+// IsZero reports whether the amount is zero.
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+// IsNegative reports whether the amount is below zero.
+func (m Money) IsNegative() bool {
+	return m.Amount < 0
+}
+
+// Add returns m + other. Both amounts must be in the same currency; a zero
+// Money without a currency takes the currency of the other operand.
+func (m Money) Add(other Money) (Money, error) {
+	if m.Currency == "" && m.Amount == 0 {
+		return other, nil
+	}
+	if m.Currency != other.Currency {
+		return Money{}, ErrCurrencyMismatch
+	}
+	if (other.Amount > 0 && m.Amount > math.MaxInt64-other.Amount) ||
+		(other.Amount < 0 && m.Amount < math.MinInt64-other.Amount) {
+		return Money{}, ErrInvalidAmount
+	}
+	return Money{Amount: m.Amount + other.Amount, Currency: m.Currency}, nil
+}
+
+// Mul returns m multiplied by a quantity, or ErrInvalidAmount if the
+// result does not fit in an amount.
+func (m Money) Mul(quantity int) (Money, error) {
+	q := int64(quantity)
+	product := m.Amount * q
+	if q != 0 && (product/q != m.Amount || (q == -1 && m.Amount == math.MinInt64) || (m.Amount == -1 && q == math.MinInt64)) {
+		return Money{}, ErrInvalidAmount
+	}
+	return Money{Amount: product, Currency: m.Currency}, nil
+}
+
+// Decimal returns the amount as a decimal string in major units, e.g. "19.99".
+func (m Money) Decimal() string {
+	exponent := currencyExponent(m.Currency)
+	sign := ""
+	amount := m.Amount
+	if amount < 0 {
+		sign = "-"
+	}
+	digits := strconv.FormatUint(absInt64(amount), 10)
+	if exponent == 0 {
+		return sign + digits
+	}
+	if len(digits) <= exponent {
+		digits = strings.Repeat("0", exponent-len(digits)+1) + digits
+	}
+	split := len(digits) - exponent
+	return sign + digits[:split] + "." + digits[split:]
+}
+
+// String returns the amount followed by its currency, e.g. "19.99 USD".
+func (m Money) String() string {
+	return m.Decimal() + " " + m.Currency
+}
+
+type moneyJSON struct {
+	Amount   json.RawMessage `json:"amount"`
+	Currency string          `json:"currency"`
+}
+
+// MarshalJSON encodes the amount as a decimal string so it round-trips exactly.
+func (m Money) MarshalJSON() ([]byte, error) {
+	amount, err := json.Marshal(m.Decimal())
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(moneyJSON{Amount: amount, Currency: m.Currency})
+}
+
+// UnmarshalJSON decodes an amount given either as a decimal string or as a
+// JSON number. Numbers are parsed from their literal text, never through a
+// float, so "19.99" and 19.99 both decode to exactly 1999 cents.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	var raw moneyJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	amount := string(bytes.TrimSpace(raw.Amount))
+	if strings.HasPrefix(amount, `"`) {
+		if err := json.Unmarshal(raw.Amount, &amount); err != nil {
+			return err
+		}
+	}
+	parsed, err := ParseMoney(amount, raw.Currency)
+	if err != nil {
+		return fmt.Errorf("money %s: %w", data, err)
+	}
+	*m = parsed
+	return nil
+}
+
+// currencyExponent returns the number of decimals of the currency's minor unit.
+func currencyExponent(currency string) int {
+	if exponent, ok := minorUnitExponents[currency]; ok {
+		return exponent
+	}
+	return 2
+}
+
+func isCurrencyCode(currency string) bool {
+	if len(currency) != 3 {
+		return false
+	}
+	for _, r := range currency {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+func absInt64(n int64) uint64 {
+	if n < 0 {
+		return uint64(-(n + 1)) + 1
+	}
+	return uint64(n)
+}
@@ -0,0 +1,37 @@
+package entity
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestMoneyMul(t *testing.T) {
+	tests := []struct {
+		amount   int64
+		quantity int
+		want     int64
+		wantErr  error
+	}{
+		{amount: 1999, quantity: 3, want: 5997},
+		{amount: 1999, quantity: 0, want: 0},
+		{amount: -250, quantity: 4, want: -1000},
+		{amount: math.MaxInt64, quantity: 1, want: math.MaxInt64},
+		{amount: math.MaxInt64, quantity: 2, wantErr: ErrInvalidAmount},
+		{amount: 1 << 62, quantity: -4, wantErr: ErrInvalidAmount},
+		{amount: math.MinInt64, quantity: -1, wantErr: ErrInvalidAmount},
+		{amount: 1000, quantity: math.MaxInt, wantErr: ErrInvalidAmount},
+	}
+	for _, test := range tests {
+		got, err := Money{Amount: test.amount, Currency: "USD"}.Mul(test.quantity)
+		if test.wantErr != nil {
+			if !errors.Is(err, test.wantErr) {
+				t.Errorf("%d * %d returned %v, %v; want %v", test.amount, test.quantity, got, err, test.wantErr)
+			}
+			continue
+		}
+		if err != nil || got.Amount != test.want || got.Currency != "USD" {
+			t.Errorf("%d * %d = %v, %v; want %d USD", test.amount, test.quantity, got, err, test.want)
+		}
+	}
+}
@@ -1,12 +1,66 @@
 package entity
 
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// MaxQuantity is the largest quantity of a product a cart or order line
+// may hold. It keeps quantities, and the prices computed from them, far
+// from overflowing.
+const MaxQuantity = 10000
+
+// Order is an order header; the purchased products are its Items.
 type Order struct {
-	ID        int  `json:"id"`
-	CustomerID int  `json:"customer_id"`
-	ProductID  int  `json:"product_id"`
-	Quantity   int     `json:"quantity"`
-	TotalPrice float64 `json:"total_price"`
-	CreatedAt  string  `json:"created_at"`
-	UpdatedAt  string  `json:"updated_at"`
-	DeletedAt  string  `json:"deleted_at"`
-}
\ No newline at end of file
+	ID         int            `json:"id"`
+	CustomerID int            `json:"customer_id" gorm:"index"`
+	Status     OrderStatus    `json:"status" gorm:"default:pending;index"`
+	Items      []OrderItem    `json:"items" gorm:"foreignKey:OrderID"`
+	TotalPrice Money          `json:"total_price" gorm:"embedded;embeddedPrefix:total_price_"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+	DeletedAt  gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+}
+
+// TransitionTo moves the order to the next status, or returns an
+// *InvalidTransitionError if the state machine does not allow it.
+func (o *Order) TransitionTo(next OrderStatus) error {
+	if !o.Status.CanTransitionTo(next) {
+		return &InvalidTransitionError{From: o.Status, To: next}
+	}
+	o.Status = next
+	return nil
+}
+
+// OrderItem is a single product line of an order. The product name and unit
+// price are a snapshot taken when the order was placed, so later catalog
+// changes do not alter past orders.
+type OrderItem struct {
+	ID          int    `json:"id"`
+	OrderID     int    `json:"order_id" gorm:"index"`
+	ProductID   int    `json:"product_id"`
+	ProductName string `json:"product_name"`
+	UnitPrice   Money  `json:"unit_price" gorm:"embedded;embeddedPrefix:unit_price_"`
+	Quantity    int    `json:"quantity"`
+	Subtotal    Money  `json:"subtotal" gorm:"embedded;embeddedPrefix:subtotal_"`
+}
+
+// Recalculate updates the line subtotals and the order total from the unit
+// prices. All items must be priced in the same currency.
+func (o *Order) Recalculate() error {
+	o.TotalPrice = Money{}
+	for i := range o.Items {
+		subtotal, err := o.Items[i].UnitPrice.Mul(o.Items[i].Quantity)
+		if err != nil {
+			return err
+		}
+		o.Items[i].Subtotal = subtotal
+		total, err := o.TotalPrice.Add(subtotal)
+		if err != nil {
+			return err
+		}
+		o.TotalPrice = total
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package entity
+
+import (
+	"fmt"
+
+	"github.com/witchakornb/basic-ecommerce/domain/errs"
+)
+
+// OrderStatus is the lifecycle state of an order.
+type OrderStatus string
+
+const (
+	OrderStatusPending   OrderStatus = "pending"
+	OrderStatusPaid      OrderStatus = "paid"
+	OrderStatusFulfilled OrderStatus = "fulfilled"
+	OrderStatusShipped   OrderStatus = "shipped"
+	OrderStatusDelivered OrderStatus = "delivered"
+	OrderStatusCancelled OrderStatus = "cancelled"
+	OrderStatusRefunded  OrderStatus = "refunded"
+)
+
+// orderTransitions lists the statuses each status may move to.
+// Cancelled and refunded orders are final.
+var orderTransitions = map[OrderStatus][]OrderStatus{
+	OrderStatusPending:   {OrderStatusPaid, OrderStatusCancelled},
+	OrderStatusPaid:      {OrderStatusFulfilled, OrderStatusRefunded},
+	OrderStatusFulfilled: {OrderStatusShipped, OrderStatusRefunded},
+	OrderStatusShipped:   {OrderStatusDelivered},
+	OrderStatusDelivered: {OrderStatusRefunded},
+}
+
+// IsValid reports whether s is one of the known statuses.
+func (s OrderStatus) IsValid() bool {
+	switch s {
+	case OrderStatusPending, OrderStatusPaid, OrderStatusFulfilled, OrderStatusShipped,
+		OrderStatusDelivered, OrderStatusCancelled, OrderStatusRefunded:
+		return true
+	}
+	return false
+}
+
+// CanTransitionTo reports whether an order may move from s to next.
+func (s OrderStatus) CanTransitionTo(next OrderStatus) bool {
+	for _, allowed := range orderTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// HoldsStock reports whether an order in this status still holds the stock
+// of its items, i.e. the goods have not left the warehouse and the order
+// has not been cancelled or refunded.
+func (s OrderStatus) HoldsStock() bool {
+	return s == OrderStatusPending || s == OrderStatusPaid || s == OrderStatusFulfilled
+}
+
+// InvalidTransitionError is returned when an order is moved to a status
+// that cannot be reached from its current one.
+type InvalidTransitionError struct {
+	From OrderStatus
+	To   OrderStatus
+}
+
+func (e *InvalidTransitionError) Error() string {
+	return fmt.Sprintf("cannot move order from %s to %s", e.From, e.To)
+}
+
+// Unwrap makes an invalid transition a conflict with the order's current status.
+func (e *InvalidTransitionError) Unwrap() error {
+	return errs.ErrConflict
+}
+
+// ErrorCode returns the stable code of the error.
+func (e *InvalidTransitionError) ErrorCode() string {
+	return "invalid_transition"
+}
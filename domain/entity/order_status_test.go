@@ -0,0 +1,74 @@
+package entity
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/witchakornb/basic-ecommerce/domain/errs"
+)
+
+var allOrderStatuses = []OrderStatus{
+	OrderStatusPending, OrderStatusPaid, OrderStatusFulfilled, OrderStatusShipped,
+	OrderStatusDelivered, OrderStatusCancelled, OrderStatusRefunded,
+}
+
+func TestOrderTransitionTo(t *testing.T) {
+	allowed := map[[2]OrderStatus]bool{
+		{OrderStatusPending, OrderStatusPaid}:       true,
+		{OrderStatusPending, OrderStatusCancelled}:  true,
+		{OrderStatusPaid, OrderStatusFulfilled}:     true,
+		{OrderStatusPaid, OrderStatusRefunded}:      true,
+		{OrderStatusFulfilled, OrderStatusShipped}:  true,
+		{OrderStatusFulfilled, OrderStatusRefunded}: true,
+		{OrderStatusShipped, OrderStatusDelivered}:  true,
+		{OrderStatusDelivered, OrderStatusRefunded}: true,
+	}
+	for _, from := range allOrderStatuses {
+		for _, to := range allOrderStatuses {
+			order := Order{Status: from}
+			err := order.TransitionTo(to)
+			if allowed[[2]OrderStatus{from, to}] {
+				if err != nil || order.Status != to {
+					t.Errorf("%s -> %s: got status %s and error %v, want the move allowed", from, to, order.Status, err)
+				}
+				continue
+			}
+			var invalid *InvalidTransitionError
+			if !errors.As(err, &invalid) || invalid.From != from || invalid.To != to {
+				t.Errorf("%s -> %s: got error %v, want an InvalidTransitionError", from, to, err)
+			}
+			if !errors.Is(err, errs.ErrConflict) {
+				t.Errorf("%s -> %s: error %v is not a conflict", from, to, err)
+			}
+			if order.Status != from {
+				t.Errorf("%s -> %s: a rejected move changed the status to %s", from, to, order.Status)
+			}
+		}
+	}
+}
+
+func TestOrderStatusHoldsStock(t *testing.T) {
+	tests := []struct {
+		status OrderStatus
+		want   bool
+	}{
+		{OrderStatusPending, true},
+		{OrderStatusPaid, true},
+		{OrderStatusFulfilled, true},
+		{OrderStatusShipped, false},
+		{OrderStatusDelivered, false},
+		{OrderStatusCancelled, false},
+		{OrderStatusRefunded, false},
+	}
+	for _, test := range tests {
+		if got := test.status.HoldsStock(); got != test.want {
+			t.Errorf("%s.HoldsStock() = %v, want %v", test.status, got, test.want)
+		}
+		if !test.status.IsValid() {
+			t.Errorf("%s is not valid", test.status)
+		}
+	}
+	if OrderStatus("lost").IsValid() {
+		t.Error("an unknown status is valid")
+	}
+}
@@ -0,0 +1,7 @@
+package entity
+
+// Principal identifies the authenticated caller of an operation.
+type Principal struct {
+	UserID int  `json:"user_id"`
+	Role   Role `json:"role"`
+}
@@ -1,13 +1,37 @@
 package entity
 
+import (
+	"strings"
+	"time"
+
+	"github.com/witchakornb/basic-ecommerce/domain/errs"
+	"gorm.io/gorm"
+)
+
 type Product struct {
-	ID          int  `json:"id"`
-	Name        string  `json:"name"`
-	Description string  `json:"description"`
-	Price       float64 `json:"price"`
-	Stock       int     `json:"stock"`
-	CreatedAt   string  `json:"created_at"`
-	UpdatedAt   string  `json:"updated_at"`
-	DeletedAt   string  `json:"deleted_at"`
+	ID          int            `json:"id"`
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	Price       Money          `json:"price" gorm:"embedded;embeddedPrefix:price_"`
+	Stock       int            `json:"stock"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `json:"deleted_at" gorm:"index"`
 }
 
+// Validate checks the invariants every stored product must satisfy.
+func (p Product) Validate() error {
+	var fields []errs.FieldError
+	if strings.TrimSpace(p.Name) == "" {
+		fields = append(fields, errs.FieldError{Field: "name", Reason: "is required"})
+	}
+	if !isCurrencyCode(p.Price.Currency) {
+		fields = append(fields, errs.FieldError{Field: "price", Reason: "must have a valid currency"})
+	} else if p.Price.IsNegative() {
+		fields = append(fields, errs.FieldError{Field: "price", Reason: "must not be negative"})
+	}
+	if p.Stock < 0 {
+		fields = append(fields, errs.FieldError{Field: "stock", Reason: "must not be negative"})
+	}
+	return errs.Invalid(fields...)
+}
@@ -0,0 +1,19 @@
+package entity
+
+import "time"
+
+// RefreshToken is a long-lived token that can be exchanged for a new access token.
+// Only a hash of the token is stored; the token itself is handed to the client once.
+type RefreshToken struct {
+	ID        int        `json:"id"`
+	UserID    int        `json:"user_id" gorm:"index"`
+	TokenHash string     `json:"-" gorm:"uniqueIndex"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	RevokedAt *time.Time `json:"revoked_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// IsActive reports whether the token is neither revoked nor expired at the given time.
+func (t RefreshToken) IsActive(now time.Time) bool {
+	return t.RevokedAt == nil && now.Before(t.ExpiresAt)
+}
@@ -1,11 +1,72 @@
 package entity
 
+import (
+	"log/slog"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/witchakornb/basic-ecommerce/domain/errs"
+	"gorm.io/gorm"
+)
+
+// Role determines what a user is allowed to do.
+type Role string
+
+const (
+	RoleCustomer Role = "customer"
+	RoleStaff    Role = "staff"
+	RoleAdmin    Role = "admin"
+)
+
+// IsValid reports whether r is one of the known roles.
+func (r Role) IsValid() bool {
+	switch r {
+	case RoleCustomer, RoleStaff, RoleAdmin:
+		return true
+	}
+	return false
+}
+
 type User struct {
-	ID       int `json:"id"`
-	Username string `json:"username"`
-	Email    string `json:"email"`
-	Password string `json:"password"`
-	CreatedAt string `json:"created_at"`
-	UpdatedAt string `json:"updated_at"`
-	DeletedAt string `json:"deleted_at"`
-}
\ No newline at end of file
+	ID        int            `json:"id"`
+	Username  string         `json:"username"`
+	Email     string         `json:"email" gorm:"uniqueIndex"`
+	Password  string         `json:"password"`
+	Role      Role           `json:"role" gorm:"default:customer"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+}
+
+// Validate checks the invariants every stored user must satisfy. The
+// password is not checked here since a stored user only holds its hash.
+func (u User) Validate() error {
+	var fields []errs.FieldError
+	if strings.TrimSpace(u.Username) == "" {
+		fields = append(fields, errs.FieldError{Field: "username", Reason: "is required"})
+	}
+	if !IsValidEmail(u.Email) {
+		fields = append(fields, errs.FieldError{Field: "email", Reason: "must be a valid email address"})
+	}
+	if !u.Role.IsValid() {
+		fields = append(fields, errs.FieldError{Field: "role", Reason: "must be one of customer, staff, admin"})
+	}
+	return errs.Invalid(fields...)
+}
+
+// LogValue keeps the password hash out of logs.
+func (u User) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.Int("id", u.ID),
+		slog.String("username", u.Username),
+		slog.String("role", string(u.Role)),
+	)
+}
+
+// IsValidEmail reports whether email is a bare address such as
+// "jane@example.com", without a display name or angle brackets.
+func IsValidEmail(email string) bool {
+	address, err := mail.ParseAddress(email)
+	return err == nil && address.Address == email
+}
@@ -0,0 +1,134 @@
+// Package errs is the taxonomy of errors the domain reports to its callers.
+//
+// Every error a use case returns on purpose matches one of the kinds below
+// with errors.Is, and carries a stable, machine-readable code that clients
+// can rely on. Errors that match no kind are unexpected failures.
+package errs
+
+import (
+	"errors"
+	"strings"
+)
+
+// Kinds of domain errors.
+var (
+	// ErrNotFound means the requested resource does not exist.
+	ErrNotFound = errors.New("not found")
+	// ErrConflict means the request conflicts with the current state of a resource.
+	ErrConflict = errors.New("conflict")
+	// ErrInsufficientStock means a product does not have enough stock left.
+	ErrInsufficientStock = errors.New("not enough stock")
+	// ErrValidation means the input breaks a rule of the domain.
+	ErrValidation = errors.New("validation failed")
+	// ErrForbidden means the caller is not allowed to perform the action.
+	ErrForbidden = errors.New("forbidden")
+	// ErrUnauthorized means the caller could not be authenticated.
+	ErrUnauthorized = errors.New("unauthorized")
+	// ErrUnavailable means a capability is not available in this deployment.
+	ErrUnavailable = errors.New("unavailable")
+)
+
+// kindCodes are the codes of errors that are a bare kind.
+var kindCodes = map[error]string{
+	ErrNotFound:          "not_found",
+	ErrConflict:          "conflict",
+	ErrInsufficientStock: "insufficient_stock",
+	ErrValidation:        "validation_failed",
+	ErrForbidden:         "forbidden",
+	ErrUnauthorized:      "unauthorized",
+	ErrUnavailable:       "unavailable",
+}
+
+// Kinds lists every kind of domain error.
+var Kinds = []error{ErrNotFound, ErrConflict, ErrInsufficientStock, ErrValidation, ErrForbidden, ErrUnauthorized, ErrUnavailable}
+
+// Error is a domain error of a given kind with a specific code and message.
+type Error struct {
+	Kind    error
+	Code    string
+	Message string
+	// Fields lists the invalid input fields of a validation error.
+	Fields []FieldError
+}
+
+// FieldError explains why one input field is invalid. Field is the name
+// of the field as clients send it, e.g. "price" or "items[0].quantity".
+type FieldError struct {
+	Field  string `json:"field"`
+	Reason string `json:"reason"`
+}
+
+// New returns an error of the kind with a stable code, e.g.
+// New(ErrConflict, "email_taken", "email is already registered").
+func New(kind error, code, message string) *Error {
+	return &Error{Kind: kind, Code: code, Message: message}
+}
+
+// NotFound returns the error for a missing resource, e.g. NotFound("product")
+// has the code "product_not_found".
+func NotFound(resource string) *Error {
+	return New(ErrNotFound, resource+"_not_found", resource+" not found")
+}
+
+// Invalid returns a validation error listing the invalid fields, or nil if
+// there are none, so it can be returned directly by a Validate method.
+func Invalid(fields ...FieldError) error {
+	if len(fields) == 0 {
+		return nil
+	}
+	reasons := make([]string, len(fields))
+	for i, field := range fields {
+		reasons[i] = field.Field + " " + field.Reason
+	}
+	return &Error{
+		Kind:    ErrValidation,
+		Code:    "validation_failed",
+		Message: "invalid input: " + strings.Join(reasons, "; "),
+		Fields:  fields,
+	}
+}
+
+// Fields returns the invalid fields of a validation error, if it lists any.
+func Fields(err error) []FieldError {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Fields
+	}
+	return nil
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Unwrap returns the kind, so errors.Is(err, ErrNotFound) matches every
+// not-found error whatever its code.
+func (e *Error) Unwrap() error {
+	return e.Kind
+}
+
+// ErrorCode returns the stable code of the error.
+func (e *Error) ErrorCode() string {
+	return e.Code
+}
+
+// KindOf returns the kind of err, or nil if err is not a domain error.
+func KindOf(err error) error {
+	for _, kind := range Kinds {
+		if errors.Is(err, kind) {
+			return kind
+		}
+	}
+	return nil
+}
+
+// Code returns the stable code of err: the code of the first error in its
+// chain that has one, or else the code of its kind. It returns "" if err is
+// not a domain error.
+func Code(err error) string {
+	var coded interface{ ErrorCode() string }
+	if errors.As(err, &coded) {
+		return coded.ErrorCode()
+	}
+	return kindCodes[KindOf(err)]
+}
@@ -0,0 +1,62 @@
+package policy
+
+import (
+	"github.com/witchakornb/basic-ecommerce/domain/entity"
+	"github.com/witchakornb/basic-ecommerce/domain/errs"
+)
+
+// ErrForbidden is returned when the caller is authenticated but not allowed to perform an action.
+var ErrForbidden = errs.New(errs.ErrForbidden, "forbidden", "you are not allowed to perform this action")
+
+// Action is a permission that can be granted to a role.
+type Action string
+
+const (
+	// ManageCatalog allows creating, updating and deleting products.
+	ManageCatalog Action = "catalog:manage"
+	// ReadUsers allows reading any user account.
+	ReadUsers Action = "users:read"
+	// ManageUsers allows updating and deleting any user account and assigning roles.
+	ManageUsers Action = "users:manage"
+	// ReadOrders allows reading orders placed by any customer.
+	ReadOrders Action = "orders:read"
+	// ManageOrders allows administrative changes to any order.
+	ManageOrders Action = "orders:manage"
+	// ManageDeleted allows listing soft-deleted users, products and orders and restoring them.
+	ManageDeleted Action = "deleted:manage"
+)
+
+// grants lists the actions each role may perform. Customers are only
+// allowed to act on resources they own, which is checked separately.
+var grants = map[entity.Role][]Action{
+	entity.RoleCustomer: {},
+	entity.RoleStaff:    {ManageCatalog, ReadUsers, ReadOrders, ManageOrders},
+	entity.RoleAdmin:    {ManageCatalog, ReadUsers, ManageUsers, ReadOrders, ManageOrders, ManageDeleted},
+}
+
+// Can reports whether the principal's role grants the action.
+func Can(principal entity.Principal, action Action) bool {
+	for _, granted := range grants[principal.Role] {
+		if granted == action {
+			return true
+		}
+	}
+	return false
+}
+
+// Authorize returns ErrForbidden unless the principal's role grants the action.
+func Authorize(principal entity.Principal, action Action) error {
+	if !Can(principal, action) {
+		return ErrForbidden
+	}
+	return nil
+}
+
+// AuthorizeOwner returns ErrForbidden unless the principal owns the resource
+// or its role grants the action on every resource of that kind.
+func AuthorizeOwner(principal entity.Principal, ownerID int, action Action) error {
+	if principal.UserID == ownerID {
+		return nil
+	}
+	return Authorize(principal, action)
+}
@@ -0,0 +1,15 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/witchakornb/basic-ecommerce/domain/entity"
+)
+
+type CartRepository interface {
+	CreateCart(ctx context.Context, cart entity.Cart) (entity.Cart, error)
+	GetCartByUserID(ctx context.Context, userID int) (entity.Cart, error)
+	SaveCartItem(ctx context.Context, item entity.CartItem) (entity.CartItem, error)
+	DeleteCartItem(ctx context.Context, cartID, productID int) error
+	ClearCart(ctx context.Context, cartID int) error
+}
@@ -0,0 +1,18 @@
+package repository
+
+import "github.com/witchakornb/basic-ecommerce/domain/errs"
+
+// ErrInsufficientStock is returned when a stock adjustment would make a product's stock negative.
+var ErrInsufficientStock = errs.New(errs.ErrInsufficientStock, "insufficient_stock", "not enough stock")
+
+// ErrOrderStatusChanged is returned when moving an order out of a status
+// it no longer has, such as after a concurrent transition of the same order.
+var ErrOrderStatusChanged = errs.New(errs.ErrConflict, "order_status_changed", "order status was changed concurrently")
+
+// ErrRefreshTokenRevoked is returned when revoking a refresh token that
+// was already revoked, such as by a concurrent refresh with the same token.
+var ErrRefreshTokenRevoked = errs.New(errs.ErrConflict, "refresh_token_revoked", "refresh token is already revoked")
+
+// ErrReservationNotHeld is returned when committing or releasing an
+// inventory reservation that was already committed or released.
+var ErrReservationNotHeld = errs.New(errs.ErrConflict, "reservation_not_held", "reservation is no longer held")
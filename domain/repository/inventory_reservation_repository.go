@@ -0,0 +1,22 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/witchakornb/basic-ecommerce/domain/entity"
+)
+
+type InventoryReservationRepository interface {
+	CreateReservation(ctx context.Context, reservation entity.InventoryReservation) (entity.InventoryReservation, error)
+	// GetOrderReservations returns every reservation of the order, oldest first.
+	GetOrderReservations(ctx context.Context, orderID int) ([]entity.InventoryReservation, error)
+	// GetExpiredReservations returns up to limit reservations still held
+	// at now past their expiry, the earliest to expire first.
+	GetExpiredReservations(ctx context.Context, now time.Time, limit int) ([]entity.InventoryReservation, error)
+	// CommitReservation and ReleaseReservation settle a held reservation.
+	// They return ErrReservationNotHeld if it was already settled, so of
+	// two transactions settling the same reservation only one succeeds.
+	CommitReservation(ctx context.Context, id int) error
+	ReleaseReservation(ctx context.Context, id int) error
+}
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/witchakornb/basic-ecommerce/domain/entity"
+	"github.com/witchakornb/basic-ecommerce/domain/errs"
+)
+
+const (
+	// DefaultListLimit is the page size used when ListOptions.Limit is not set.
+	DefaultListLimit = 20
+	// MaxListLimit is the largest page size a caller may request.
+	MaxListLimit = 100
+)
+
+// ErrInvalidListOptions is returned for an unknown sort key, a malformed cursor or a bad page size.
+var ErrInvalidListOptions = errs.New(errs.ErrValidation, "invalid_list_options", "invalid list options")
+
+// SortKey orders a list by one field.
+type SortKey struct {
+	Field string
+	Desc  bool
+}
+
+// ListOptions selects one page of a list. A page is either addressed by
+// Offset or, when Cursor is set, by the NextCursor of the previous page.
+// Cursors stay stable while rows are inserted, offsets do not.
+type ListOptions struct {
+	Limit  int
+	Offset int
+	Cursor string
+	Sort   []SortKey
+}
+
+// Page is one page of a list.
+type Page[T any] struct {
+	Items []T
+	// Total is the number of items matching the filter across all pages.
+	Total int64
+	// NextCursor addresses the following page; it is empty on the last page.
+	NextCursor string
+}
+
+// ProductFilter narrows a product list. Zero values do not filter.
+type ProductFilter struct {
+	// MinPrice and MaxPrice bound the price inclusively. When set, only
+	// products priced in the same currency match.
+	MinPrice *entity.Money
+	MaxPrice *entity.Money
+	// InStock keeps only products with a stock above zero.
+	InStock bool
+	// IncludeDeleted also lists soft-deleted products.
+	IncludeDeleted bool
+}
+
+// OrderFilter narrows an order list. Zero values do not filter.
+type OrderFilter struct {
+	CustomerID int
+	Status     entity.OrderStatus
+	// CreatedFrom and CreatedTo bound the creation time inclusively.
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	// IncludeDeleted also lists soft-deleted orders.
+	IncludeDeleted bool
+}
+
+// UserFilter narrows a user list. Zero values do not filter.
+type UserFilter struct {
+	Role  entity.Role
+	Email string
+	// IncludeDeleted also lists soft-deleted users.
+	IncludeDeleted bool
+}
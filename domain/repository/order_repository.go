@@ -1,10 +1,24 @@
 package repository
 
-import "github.com/witchakornb/basic-ecommerce/domain/entity"
+import (
+	"context"
+
+	"github.com/witchakornb/basic-ecommerce/domain/entity"
+)
 
 type OrderRepository interface {
-	CreateOrder(order entity.Order) (entity.Order, error)
-	GetOrderByID(id int) (entity.Order, error)
-	GetAllOrders() ([]entity.Order, error)
-	DeleteOrder(id int) error
+	CreateOrder(ctx context.Context, order entity.Order) (entity.Order, error)
+	GetOrderByID(ctx context.Context, id int) (entity.Order, error)
+	GetAllOrders(ctx context.Context, filter OrderFilter, opts ListOptions) (Page[entity.Order], error)
+	// UpdateOrderStatus moves an order from one status to another. It
+	// returns ErrOrderStatusChanged if the order no longer has the status
+	// from, so of two transactions moving the same order only one succeeds.
+	UpdateOrderStatus(ctx context.Context, id int, from, to entity.OrderStatus) error
+	// DeleteOrder soft-deletes an order; its items are kept so that it can
+	// be restored. Like UpdateOrderStatus it returns ErrOrderStatusChanged
+	// if the order no longer has the status, so a delete and a concurrent
+	// transition of the same order cannot both succeed.
+	DeleteOrder(ctx context.Context, id int, status entity.OrderStatus) error
+	// RestoreOrder undeletes a soft-deleted order and returns it with its items.
+	RestoreOrder(ctx context.Context, id int) (entity.Order, error)
 }
@@ -1,11 +1,40 @@
 package repository
 
-import "github.com/witchakornb/basic-ecommerce/domain/entity"
+import (
+	"context"
+
+	"github.com/witchakornb/basic-ecommerce/domain/entity"
+)
+
+// ProductField is a field of a product that UpdateProduct writes.
+type ProductField string
+
+const (
+	ProductName        ProductField = "name"
+	ProductDescription ProductField = "description"
+	ProductPrice       ProductField = "price"
+	ProductStock       ProductField = "stock"
+)
+
+// AllProductFields are the fields a full replacement of a product writes.
+var AllProductFields = []ProductField{ProductName, ProductDescription, ProductPrice, ProductStock}
 
 type ProductRepository interface {
-	CreateProduct(product entity.Product) (entity.Product, error)
-	GetProductByID(id int) (entity.Product, error)
-	GetAllProducts() ([]entity.Product, error)
-	UpdateProduct(product entity.Product) (entity.Product, error)
-	DeleteProduct(id int) error
+	CreateProduct(ctx context.Context, product entity.Product) (entity.Product, error)
+	GetProductByID(ctx context.Context, id int) (entity.Product, error)
+	GetAllProducts(ctx context.Context, filter ProductFilter, opts ListOptions) (Page[entity.Product], error)
+	// UpdateProduct writes the fields of product to the stored product with
+	// its ID and returns the stored product. Other fields are left alone, so
+	// an update that omits ProductStock keeps concurrent stock adjustments.
+	UpdateProduct(ctx context.Context, product entity.Product, fields []ProductField) (entity.Product, error)
+	// AdjustStock atomically adds delta to the product's stock and returns
+	// ErrInsufficientStock instead of letting the stock drop below zero.
+	// Soft-deleted products are adjusted too, so stock put back while a
+	// product is deleted is there again when it is restored.
+	AdjustStock(ctx context.Context, id int, delta int) error
+	// DeleteProduct soft-deletes a product: it is hidden from every lookup,
+	// list and search until it is restored.
+	DeleteProduct(ctx context.Context, id int) error
+	// RestoreProduct undeletes a soft-deleted product and returns it.
+	RestoreProduct(ctx context.Context, id int) (entity.Product, error)
 }
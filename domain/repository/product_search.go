@@ -0,0 +1,30 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/witchakornb/basic-ecommerce/domain/entity"
+	"github.com/witchakornb/basic-ecommerce/domain/errs"
+)
+
+// ErrSearchUnavailable is returned when the backend has no full-text index.
+var ErrSearchUnavailable = errs.New(errs.ErrUnavailable, "search_unavailable", "product search is not available")
+
+// ProductSearchResult is a product matching a search query.
+type ProductSearchResult struct {
+	Product entity.Product
+	// Rank orders results by relevance; lower is more relevant.
+	Rank float64
+	// NameHighlight is the product name with the matched terms marked.
+	NameHighlight string
+	// DescriptionSnippet is the part of the description around the matched terms, with the terms marked.
+	DescriptionSnippet string
+}
+
+// ProductSearch is a full-text search over the product catalog.
+// It indexes the Name and Description of the products stored through ProductRepository.
+type ProductSearch interface {
+	// SearchProducts returns up to limit products matching every term of
+	// query, most relevant first. Terms match as prefixes of words.
+	SearchProducts(ctx context.Context, query string, limit int) ([]ProductSearchResult, error)
+}
@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/witchakornb/basic-ecommerce/domain/entity"
+)
+
+type RefreshTokenRepository interface {
+	CreateRefreshToken(ctx context.Context, token entity.RefreshToken) (entity.RefreshToken, error)
+	GetRefreshTokenByHash(ctx context.Context, tokenHash string) (entity.RefreshToken, error)
+	// RevokeRefreshToken revokes an active token. It returns
+	// ErrRefreshTokenRevoked if the token was already revoked, so of two
+	// transactions revoking the same token only one succeeds.
+	RevokeRefreshToken(ctx context.Context, id int) error
+	RevokeUserRefreshTokens(ctx context.Context, userID int) error
+}
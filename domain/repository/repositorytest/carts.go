@@ -0,0 +1,85 @@
+package repositorytest
+
+import (
+	"testing"
+
+	"github.com/witchakornb/basic-ecommerce/domain/entity"
+	"github.com/witchakornb/basic-ecommerce/domain/errs"
+)
+
+func testCarts(t *testing.T, newBackend Factory) {
+	t.Run("CreateAndGet", func(t *testing.T) {
+		b := newBackend(t)
+		created, err := b.Carts.CreateCart(t.Context(), entity.Cart{UserID: 7})
+		must(t, "CreateCart", err)
+		if created.ID == 0 {
+			t.Fatal("CreateCart did not assign an ID")
+		}
+
+		got, err := b.Carts.GetCartByUserID(t.Context(), 7)
+		must(t, "GetCartByUserID", err)
+		if got.ID != created.ID || len(got.Items) != 0 {
+			t.Fatalf("GetCartByUserID returned %+v", got)
+		}
+
+		_, err = b.Carts.CreateCart(t.Context(), entity.Cart{UserID: 7})
+		expectKind(t, errs.ErrConflict, "CreateCart with a second cart for the same user", err)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		b := newBackend(t)
+		_, err := b.Carts.GetCartByUserID(t.Context(), 42)
+		expectNotFound(t, "GetCartByUserID of a user without a cart", err)
+	})
+
+	t.Run("Items", func(t *testing.T) {
+		b := newBackend(t)
+		cart, err := b.Carts.CreateCart(t.Context(), entity.Cart{UserID: 7})
+		must(t, "CreateCart", err)
+
+		mug, err := b.Carts.SaveCartItem(t.Context(), entity.CartItem{CartID: cart.ID, ProductID: 1, Quantity: 1, UnitPrice: usd(1000)})
+		must(t, "SaveCartItem", err)
+		_, err = b.Carts.SaveCartItem(t.Context(), entity.CartItem{CartID: cart.ID, ProductID: 2, Quantity: 1, UnitPrice: usd(500)})
+		must(t, "SaveCartItem", err)
+
+		// Saving a new item for a product already in the cart replaces that line.
+		upserted, err := b.Carts.SaveCartItem(t.Context(), entity.CartItem{CartID: cart.ID, ProductID: 1, Quantity: 3, UnitPrice: usd(900)})
+		must(t, "SaveCartItem", err)
+		if upserted.ID != mug.ID {
+			t.Fatalf("SaveCartItem returned ID %d for the replaced line, want %d", upserted.ID, mug.ID)
+		}
+		got, err := b.Carts.GetCartByUserID(t.Context(), 7)
+		must(t, "GetCartByUserID", err)
+		if len(got.Items) != 2 {
+			t.Fatalf("cart has %d items, want 2", len(got.Items))
+		}
+		if item := got.Items[0]; item.ID != mug.ID || item.ProductID != 1 || item.Quantity != 3 || item.UnitPrice != usd(900) {
+			t.Fatalf("upserted item = %+v", item)
+		}
+
+		// Saving a stored item updates it in place.
+		item := got.Items[0]
+		item.Quantity = 5
+		_, err = b.Carts.SaveCartItem(t.Context(), item)
+		must(t, "SaveCartItem", err)
+		got, err = b.Carts.GetCartByUserID(t.Context(), 7)
+		must(t, "GetCartByUserID", err)
+		if len(got.Items) != 2 || got.Items[0].Quantity != 5 {
+			t.Fatalf("updated items = %+v", got.Items)
+		}
+
+		must(t, "DeleteCartItem", b.Carts.DeleteCartItem(t.Context(), cart.ID, 1))
+		got, err = b.Carts.GetCartByUserID(t.Context(), 7)
+		must(t, "GetCartByUserID", err)
+		if len(got.Items) != 1 || got.Items[0].ProductID != 2 {
+			t.Fatalf("items after DeleteCartItem = %+v", got.Items)
+		}
+
+		must(t, "ClearCart", b.Carts.ClearCart(t.Context(), cart.ID))
+		got, err = b.Carts.GetCartByUserID(t.Context(), 7)
+		must(t, "GetCartByUserID", err)
+		if len(got.Items) != 0 {
+			t.Fatalf("items after ClearCart = %+v", got.Items)
+		}
+	})
+}
@@ -0,0 +1,145 @@
+package repositorytest
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/witchakornb/basic-ecommerce/domain/entity"
+	"github.com/witchakornb/basic-ecommerce/domain/repository"
+)
+
+func newOrder(customerID int) entity.Order {
+	return entity.Order{
+		CustomerID: customerID,
+		Status:     entity.OrderStatusPending,
+		Items: []entity.OrderItem{
+			{ProductID: 1, ProductName: "Mug", UnitPrice: usd(1000), Quantity: 2, Subtotal: usd(2000)},
+			{ProductID: 2, ProductName: "Plate", UnitPrice: usd(500), Quantity: 1, Subtotal: usd(500)},
+		},
+		TotalPrice: usd(2500),
+	}
+}
+
+func testOrders(t *testing.T, newBackend Factory) {
+	t.Run("CreateAndGet", func(t *testing.T) {
+		b := newBackend(t)
+		created, err := b.Orders.CreateOrder(t.Context(), newOrder(7))
+		must(t, "CreateOrder", err)
+		if created.ID == 0 {
+			t.Fatal("CreateOrder did not assign an ID")
+		}
+		for _, item := range created.Items {
+			if item.ID == 0 || item.OrderID != created.ID {
+				t.Fatalf("CreateOrder did not link item %+v to order %d", item, created.ID)
+			}
+		}
+
+		got, err := b.Orders.GetOrderByID(t.Context(), created.ID)
+		must(t, "GetOrderByID", err)
+		if got.CustomerID != 7 || got.Status != entity.OrderStatusPending || got.TotalPrice != usd(2500) {
+			t.Fatalf("GetOrderByID returned %+v", got)
+		}
+		if len(got.Items) != 2 || got.Items[0].ProductName != "Mug" || got.Items[0].Subtotal != usd(2000) {
+			t.Fatalf("GetOrderByID returned items %+v", got.Items)
+		}
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		b := newBackend(t)
+		_, err := b.Orders.GetOrderByID(t.Context(), 42)
+		expectNotFound(t, "GetOrderByID of a missing order", err)
+
+		order, err := b.Orders.CreateOrder(t.Context(), newOrder(7))
+		must(t, "CreateOrder", err)
+		must(t, "DeleteOrder", b.Orders.DeleteOrder(t.Context(), order.ID, entity.OrderStatusPending))
+		_, err = b.Orders.GetOrderByID(t.Context(), order.ID)
+		expectNotFound(t, "GetOrderByID of a deleted order", err)
+		expectNotFound(t, "DeleteOrder of a deleted order", b.Orders.DeleteOrder(t.Context(), order.ID, entity.OrderStatusPending))
+		expectNotFound(t, "DeleteOrder of a missing order", b.Orders.DeleteOrder(t.Context(), 42, entity.OrderStatusPending))
+	})
+
+	t.Run("UpdateStatus", func(t *testing.T) {
+		b := newBackend(t)
+		order, err := b.Orders.CreateOrder(t.Context(), newOrder(7))
+		must(t, "CreateOrder", err)
+
+		must(t, "UpdateOrderStatus", b.Orders.UpdateOrderStatus(t.Context(), order.ID, entity.OrderStatusPending, entity.OrderStatusPaid))
+		got, err := b.Orders.GetOrderByID(t.Context(), order.ID)
+		must(t, "GetOrderByID", err)
+		if got.Status != entity.OrderStatusPaid || len(got.Items) != 2 {
+			t.Fatalf("order after UpdateOrderStatus = %+v, want it paid with its items", got)
+		}
+
+		err = b.Orders.UpdateOrderStatus(t.Context(), order.ID, entity.OrderStatusPending, entity.OrderStatusCancelled)
+		if !errors.Is(err, repository.ErrOrderStatusChanged) {
+			t.Fatalf("UpdateOrderStatus from a status the order no longer has returned %v, want ErrOrderStatusChanged", err)
+		}
+		expectNotFound(t, "UpdateOrderStatus of a missing order", b.Orders.UpdateOrderStatus(t.Context(), 999, entity.OrderStatusPending, entity.OrderStatusPaid))
+
+		err = b.Orders.DeleteOrder(t.Context(), order.ID, entity.OrderStatusPending)
+		if !errors.Is(err, repository.ErrOrderStatusChanged) {
+			t.Fatalf("DeleteOrder with a status the order no longer has returned %v, want ErrOrderStatusChanged", err)
+		}
+		if _, err := b.Orders.GetOrderByID(t.Context(), order.ID); err != nil {
+			t.Fatalf("DeleteOrder with a stale status deleted the order: %v", err)
+		}
+	})
+
+	t.Run("List", func(t *testing.T) {
+		b := newBackend(t)
+		var orders []entity.Order
+		for _, customerID := range []int{1, 2, 1, 1} {
+			order, err := b.Orders.CreateOrder(t.Context(), newOrder(customerID))
+			must(t, "CreateOrder", err)
+			orders = append(orders, order)
+		}
+		must(t, "UpdateOrderStatus", b.Orders.UpdateOrderStatus(t.Context(), orders[2].ID, entity.OrderStatusPending, entity.OrderStatusPaid))
+
+		page, err := b.Orders.GetAllOrders(t.Context(), repository.OrderFilter{CustomerID: 1}, repository.ListOptions{})
+		must(t, "GetAllOrders", err)
+		if page.Total != 3 {
+			t.Fatalf("customer filter matched %d orders, want 3", page.Total)
+		}
+		for _, order := range page.Items {
+			if len(order.Items) != 2 {
+				t.Fatalf("GetAllOrders did not load the items of order %d", order.ID)
+			}
+		}
+
+		page, err = b.Orders.GetAllOrders(t.Context(), repository.OrderFilter{CustomerID: 1, Status: entity.OrderStatusPending}, repository.ListOptions{})
+		must(t, "GetAllOrders", err)
+		if page.Total != 2 {
+			t.Fatalf("customer and status filter matched %d orders, want 2", page.Total)
+		}
+	})
+
+	t.Run("SoftDeleteAndRestore", func(t *testing.T) {
+		b := newBackend(t)
+		order, err := b.Orders.CreateOrder(t.Context(), newOrder(7))
+		must(t, "CreateOrder", err)
+		if order.CreatedAt.IsZero() || order.UpdatedAt.IsZero() {
+			t.Fatalf("CreateOrder did not set the timestamps: %+v", order)
+		}
+		must(t, "DeleteOrder", b.Orders.DeleteOrder(t.Context(), order.ID, entity.OrderStatusPending))
+
+		page, err := b.Orders.GetAllOrders(t.Context(), repository.OrderFilter{}, repository.ListOptions{})
+		must(t, "GetAllOrders", err)
+		if page.Total != 0 {
+			t.Fatalf("GetAllOrders listed %d deleted orders", page.Total)
+		}
+		page, err = b.Orders.GetAllOrders(t.Context(), repository.OrderFilter{IncludeDeleted: true}, repository.ListOptions{})
+		must(t, "GetAllOrders", err)
+		if page.Total != 1 || !page.Items[0].DeletedAt.Valid {
+			t.Fatalf("GetAllOrders with deleted orders returned %+v", page)
+		}
+
+		restored, err := b.Orders.RestoreOrder(t.Context(), order.ID)
+		must(t, "RestoreOrder", err)
+		if restored.DeletedAt.Valid || len(restored.Items) != 2 {
+			t.Fatalf("RestoreOrder returned %+v", restored)
+		}
+
+		_, err = b.Orders.RestoreOrder(t.Context(), 42)
+		expectNotFound(t, "RestoreOrder of a missing order", err)
+	})
+}
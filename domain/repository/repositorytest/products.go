@@ -0,0 +1,227 @@
+package repositorytest
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/witchakornb/basic-ecommerce/domain/entity"
+	"github.com/witchakornb/basic-ecommerce/domain/repository"
+)
+
+func usd(amount int64) entity.Money {
+	return entity.Money{Amount: amount, Currency: "USD"}
+}
+
+func testProducts(t *testing.T, newBackend Factory) {
+	t.Run("CreateAndGet", func(t *testing.T) {
+		b := newBackend(t)
+		created, err := b.Products.CreateProduct(t.Context(), entity.Product{Name: "Mug", Description: "A mug", Price: usd(1999), Stock: 3})
+		must(t, "CreateProduct", err)
+		if created.ID == 0 {
+			t.Fatal("CreateProduct did not assign an ID")
+		}
+
+		got, err := b.Products.GetProductByID(t.Context(), created.ID)
+		must(t, "GetProductByID", err)
+		if got.Name != "Mug" || got.Description != "A mug" || got.Price != usd(1999) || got.Stock != 3 {
+			t.Fatalf("GetProductByID returned %+v", got)
+		}
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		b := newBackend(t)
+		_, err := b.Products.GetProductByID(t.Context(), 42)
+		expectNotFound(t, "GetProductByID of a missing product", err)
+
+		product, err := b.Products.CreateProduct(t.Context(), entity.Product{Name: "Mug", Price: usd(1999)})
+		must(t, "CreateProduct", err)
+		must(t, "DeleteProduct", b.Products.DeleteProduct(t.Context(), product.ID))
+		_, err = b.Products.GetProductByID(t.Context(), product.ID)
+		expectNotFound(t, "GetProductByID of a deleted product", err)
+		must(t, "DeleteProduct of a deleted product", b.Products.DeleteProduct(t.Context(), product.ID))
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		b := newBackend(t)
+		product, err := b.Products.CreateProduct(t.Context(), entity.Product{Name: "Mug", Price: usd(1999), Stock: 3})
+		must(t, "CreateProduct", err)
+
+		product.Name = "Big mug"
+		product.Price = usd(2499)
+		_, err = b.Products.UpdateProduct(t.Context(), product, repository.AllProductFields)
+		must(t, "UpdateProduct", err)
+		got, err := b.Products.GetProductByID(t.Context(), product.ID)
+		must(t, "GetProductByID", err)
+		if got.Name != "Big mug" || got.Price != usd(2499) {
+			t.Fatalf("UpdateProduct was not persisted: %+v", got)
+		}
+		if !got.CreatedAt.Equal(product.CreatedAt) {
+			t.Fatalf("UpdateProduct changed the creation time from %v to %v", product.CreatedAt, got.CreatedAt)
+		}
+
+		product.ID = 999
+		_, err = b.Products.UpdateProduct(t.Context(), product, repository.AllProductFields)
+		expectNotFound(t, "UpdateProduct of a missing product", err)
+	})
+
+	t.Run("UpdateFields", func(t *testing.T) {
+		b := newBackend(t)
+		product, err := b.Products.CreateProduct(t.Context(), entity.Product{Name: "Mug", Description: "Blue", Price: usd(1999), Stock: 3})
+		must(t, "CreateProduct", err)
+
+		// An order takes stock after the product was read for the update
+		must(t, "AdjustStock", b.Products.AdjustStock(t.Context(), product.ID, -1))
+		product.Name = "Big mug"
+		product.Description = ""
+		product.Price = usd(2499)
+		updated, err := b.Products.UpdateProduct(t.Context(), product, []repository.ProductField{repository.ProductName, repository.ProductDescription})
+		must(t, "UpdateProduct", err)
+		if updated.Name != "Big mug" || updated.Description != "" || updated.Price != usd(1999) || updated.Stock != 2 {
+			t.Fatalf("UpdateProduct of the name and description returned %+v, want the rest unchanged", updated)
+		}
+		got, err := b.Products.GetProductByID(t.Context(), product.ID)
+		must(t, "GetProductByID", err)
+		if got != updated {
+			t.Fatalf("GetProductByID returned %+v, want %+v", got, updated)
+		}
+
+		product.Stock = 10
+		updated, err = b.Products.UpdateProduct(t.Context(), product, []repository.ProductField{repository.ProductStock})
+		must(t, "UpdateProduct", err)
+		if updated.Stock != 10 {
+			t.Fatalf("stock after updating it = %d, want 10", updated.Stock)
+		}
+	})
+
+	t.Run("AdjustStock", func(t *testing.T) {
+		b := newBackend(t)
+		product, err := b.Products.CreateProduct(t.Context(), entity.Product{Name: "Mug", Price: usd(1999), Stock: 3})
+		must(t, "CreateProduct", err)
+
+		must(t, "AdjustStock(-2)", b.Products.AdjustStock(t.Context(), product.ID, -2))
+		must(t, "AdjustStock(+4)", b.Products.AdjustStock(t.Context(), product.ID, 4))
+		if err := b.Products.AdjustStock(t.Context(), product.ID, -6); !errors.Is(err, repository.ErrInsufficientStock) {
+			t.Fatalf("AdjustStock below zero returned %v, want ErrInsufficientStock", err)
+		}
+		got, err := b.Products.GetProductByID(t.Context(), product.ID)
+		must(t, "GetProductByID", err)
+		if got.Stock != 5 {
+			t.Fatalf("stock = %d, want 5", got.Stock)
+		}
+
+		err = b.Products.AdjustStock(t.Context(), 42, 1)
+		expectNotFound(t, "AdjustStock of a missing product", err)
+	})
+
+	t.Run("ConcurrentAdjustStock", func(t *testing.T) {
+		b := newBackend(t)
+		const stock, buyers = 10, 25
+		product, err := b.Products.CreateProduct(t.Context(), entity.Product{Name: "Mug", Price: usd(1999), Stock: stock})
+		must(t, "CreateProduct", err)
+
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		sold, rejected := 0, 0
+		for range buyers {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				err := b.Products.AdjustStock(t.Context(), product.ID, -1)
+				mu.Lock()
+				defer mu.Unlock()
+				switch {
+				case err == nil:
+					sold++
+				case errors.Is(err, repository.ErrInsufficientStock):
+					rejected++
+				default:
+					t.Errorf("AdjustStock: %v", err)
+				}
+			}()
+		}
+		wg.Wait()
+
+		if sold != stock || rejected != buyers-stock {
+			t.Fatalf("sold %d and rejected %d, want %d and %d", sold, rejected, stock, buyers-stock)
+		}
+		got, err := b.Products.GetProductByID(t.Context(), product.ID)
+		must(t, "GetProductByID", err)
+		if got.Stock != 0 {
+			t.Fatalf("stock = %d, want 0", got.Stock)
+		}
+	})
+
+	t.Run("List", func(t *testing.T) {
+		b := newBackend(t)
+		for i := 1; i <= 6; i++ {
+			price := usd(int64(i) * 1000)
+			if i == 6 {
+				price = entity.Money{Amount: 1000, Currency: "EUR"}
+			}
+			_, err := b.Products.CreateProduct(t.Context(), entity.Product{Name: fmt.Sprintf("product%d", i), Price: price, Stock: i % 2})
+			must(t, "CreateProduct", err)
+		}
+
+		minPrice, maxPrice := usd(2000), usd(4000)
+		page, err := b.Products.GetAllProducts(t.Context(), repository.ProductFilter{MinPrice: &minPrice, MaxPrice: &maxPrice}, repository.ListOptions{})
+		must(t, "GetAllProducts", err)
+		if page.Total != 3 {
+			t.Fatalf("price filter matched %d products, want 3", page.Total)
+		}
+
+		page, err = b.Products.GetAllProducts(t.Context(), repository.ProductFilter{InStock: true}, repository.ListOptions{})
+		must(t, "GetAllProducts", err)
+		if page.Total != 3 {
+			t.Fatalf("in-stock filter matched %d products, want 3", page.Total)
+		}
+
+		page, err = b.Products.GetAllProducts(t.Context(), repository.ProductFilter{}, repository.ListOptions{
+			Limit:  2,
+			Offset: 1,
+			Sort:   []repository.SortKey{{Field: "price", Desc: true}},
+		})
+		must(t, "GetAllProducts", err)
+		if page.Total != 6 || len(page.Items) != 2 || page.Items[0].Name != "product4" || page.Items[1].Name != "product3" {
+			t.Fatalf("offset page returned %+v", page)
+		}
+	})
+
+	t.Run("SoftDeleteAndRestore", func(t *testing.T) {
+		b := newBackend(t)
+		product, err := b.Products.CreateProduct(t.Context(), entity.Product{Name: "Mug", Price: usd(1999), Stock: 3})
+		must(t, "CreateProduct", err)
+		if product.CreatedAt.IsZero() || product.UpdatedAt.IsZero() {
+			t.Fatalf("CreateProduct did not set the timestamps: %+v", product)
+		}
+		must(t, "DeleteProduct", b.Products.DeleteProduct(t.Context(), product.ID))
+
+		// Stock put back while the product is deleted is kept for its restore
+		must(t, "AdjustStock of a deleted product", b.Products.AdjustStock(t.Context(), product.ID, 2))
+		if err := b.Products.AdjustStock(t.Context(), product.ID, -6); !errors.Is(err, repository.ErrInsufficientStock) {
+			t.Fatalf("AdjustStock of a deleted product below zero returned %v, want ErrInsufficientStock", err)
+		}
+		page, err := b.Products.GetAllProducts(t.Context(), repository.ProductFilter{}, repository.ListOptions{})
+		must(t, "GetAllProducts", err)
+		if page.Total != 0 {
+			t.Fatalf("GetAllProducts listed %d deleted products", page.Total)
+		}
+		page, err = b.Products.GetAllProducts(t.Context(), repository.ProductFilter{IncludeDeleted: true}, repository.ListOptions{})
+		must(t, "GetAllProducts", err)
+		if page.Total != 1 || !page.Items[0].DeletedAt.Valid {
+			t.Fatalf("GetAllProducts with deleted products returned %+v", page)
+		}
+
+		restored, err := b.Products.RestoreProduct(t.Context(), product.ID)
+		must(t, "RestoreProduct", err)
+		if restored.DeletedAt.Valid || restored.Stock != 5 {
+			t.Fatalf("RestoreProduct returned %+v", restored)
+		}
+		_, err = b.Products.GetProductByID(t.Context(), product.ID)
+		must(t, "GetProductByID of a restored product", err)
+
+		_, err = b.Products.RestoreProduct(t.Context(), 42)
+		expectNotFound(t, "RestoreProduct of a missing product", err)
+	})
+}
@@ -0,0 +1,83 @@
+package repositorytest
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/witchakornb/basic-ecommerce/domain/entity"
+	"github.com/witchakornb/basic-ecommerce/domain/errs"
+	"github.com/witchakornb/basic-ecommerce/domain/repository"
+)
+
+func testRefreshTokens(t *testing.T, newBackend Factory) {
+	t.Run("CreateAndGet", func(t *testing.T) {
+		b := newBackend(t)
+		expiresAt := time.Now().Add(time.Hour)
+		created, err := b.RefreshTokens.CreateRefreshToken(t.Context(), entity.RefreshToken{UserID: 7, TokenHash: "hash-1", ExpiresAt: expiresAt})
+		must(t, "CreateRefreshToken", err)
+		if created.ID == 0 {
+			t.Fatal("CreateRefreshToken did not assign an ID")
+		}
+
+		got, err := b.RefreshTokens.GetRefreshTokenByHash(t.Context(), "hash-1")
+		must(t, "GetRefreshTokenByHash", err)
+		if got.ID != created.ID || got.UserID != 7 || !got.ExpiresAt.Equal(expiresAt) || got.RevokedAt != nil {
+			t.Fatalf("GetRefreshTokenByHash returned %+v", got)
+		}
+		if !got.IsActive(time.Now()) {
+			t.Fatal("a new refresh token is not active")
+		}
+
+		_, err = b.RefreshTokens.CreateRefreshToken(t.Context(), entity.RefreshToken{UserID: 8, TokenHash: "hash-1", ExpiresAt: expiresAt})
+		expectKind(t, errs.ErrConflict, "CreateRefreshToken with a duplicate hash", err)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		b := newBackend(t)
+		_, err := b.RefreshTokens.GetRefreshTokenByHash(t.Context(), "missing")
+		expectNotFound(t, "GetRefreshTokenByHash of a missing token", err)
+	})
+
+	t.Run("Revoke", func(t *testing.T) {
+		b := newBackend(t)
+		expiresAt := time.Now().Add(time.Hour)
+		for _, token := range []entity.RefreshToken{
+			{UserID: 7, TokenHash: "hash-1", ExpiresAt: expiresAt},
+			{UserID: 7, TokenHash: "hash-2", ExpiresAt: expiresAt},
+			{UserID: 8, TokenHash: "hash-3", ExpiresAt: expiresAt},
+		} {
+			_, err := b.RefreshTokens.CreateRefreshToken(t.Context(), token)
+			must(t, "CreateRefreshToken", err)
+		}
+
+		first, err := b.RefreshTokens.GetRefreshTokenByHash(t.Context(), "hash-1")
+		must(t, "GetRefreshTokenByHash", err)
+		must(t, "RevokeRefreshToken", b.RefreshTokens.RevokeRefreshToken(t.Context(), first.ID))
+		first, err = b.RefreshTokens.GetRefreshTokenByHash(t.Context(), "hash-1")
+		must(t, "GetRefreshTokenByHash", err)
+		if first.RevokedAt == nil {
+			t.Fatal("RevokeRefreshToken did not revoke the token")
+		}
+		revokedAt := *first.RevokedAt
+		err = b.RefreshTokens.RevokeRefreshToken(t.Context(), first.ID)
+		if !errors.Is(err, repository.ErrRefreshTokenRevoked) {
+			t.Fatalf("RevokeRefreshToken of a revoked token returned %v, want ErrRefreshTokenRevoked", err)
+		}
+		expectNotFound(t, "RevokeRefreshToken of a missing token", b.RefreshTokens.RevokeRefreshToken(t.Context(), 999))
+
+		must(t, "RevokeUserRefreshTokens", b.RefreshTokens.RevokeUserRefreshTokens(t.Context(), 7))
+		for hash, revoked := range map[string]bool{"hash-1": true, "hash-2": true, "hash-3": false} {
+			token, err := b.RefreshTokens.GetRefreshTokenByHash(t.Context(), hash)
+			must(t, "GetRefreshTokenByHash", err)
+			if (token.RevokedAt != nil) != revoked {
+				t.Fatalf("token %s revoked = %v, want %v", hash, token.RevokedAt != nil, revoked)
+			}
+		}
+		first, err = b.RefreshTokens.GetRefreshTokenByHash(t.Context(), "hash-1")
+		must(t, "GetRefreshTokenByHash", err)
+		if !first.RevokedAt.Equal(revokedAt) {
+			t.Fatal("revoking an already revoked token changed its revocation time")
+		}
+	})
+}
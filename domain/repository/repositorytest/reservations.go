@@ -0,0 +1,98 @@
+package repositorytest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/witchakornb/basic-ecommerce/domain/entity"
+	"github.com/witchakornb/basic-ecommerce/domain/errs"
+)
+
+func testReservations(t *testing.T, newBackend Factory) {
+	t.Run("CreateAndGet", func(t *testing.T) {
+		b := newBackend(t)
+		expiresAt := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+		created, err := b.Reservations.CreateReservation(t.Context(), entity.InventoryReservation{OrderID: 7, ProductID: 1, Quantity: 2, ExpiresAt: expiresAt})
+		must(t, "CreateReservation", err)
+		if created.ID == 0 || created.Status != entity.ReservationHeld {
+			t.Fatalf("CreateReservation returned %+v, want an ID and the held status", created)
+		}
+		_, err = b.Reservations.CreateReservation(t.Context(), entity.InventoryReservation{OrderID: 7, ProductID: 2, Quantity: 1, ExpiresAt: expiresAt})
+		must(t, "CreateReservation", err)
+		_, err = b.Reservations.CreateReservation(t.Context(), entity.InventoryReservation{OrderID: 8, ProductID: 1, Quantity: 1, ExpiresAt: expiresAt})
+		must(t, "CreateReservation", err)
+
+		got, err := b.Reservations.GetOrderReservations(t.Context(), 7)
+		must(t, "GetOrderReservations", err)
+		if len(got) != 2 || got[0].ID != created.ID || got[1].ProductID != 2 {
+			t.Fatalf("GetOrderReservations returned %+v", got)
+		}
+		if got[0].Quantity != 2 || !got[0].ExpiresAt.Equal(expiresAt) || got[0].Status != entity.ReservationHeld {
+			t.Fatalf("GetOrderReservations returned %+v", got[0])
+		}
+
+		none, err := b.Reservations.GetOrderReservations(t.Context(), 9)
+		must(t, "GetOrderReservations of an order without reservations", err)
+		if none == nil || len(none) != 0 {
+			t.Fatalf("GetOrderReservations of an order without reservations returned %#v, want an empty slice", none)
+		}
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		b := newBackend(t)
+		now := time.Now().UTC().Truncate(time.Second)
+		var ids []int
+		for _, reservation := range []entity.InventoryReservation{
+			{OrderID: 1, ProductID: 1, Quantity: 1, ExpiresAt: now.Add(-time.Minute)},
+			{OrderID: 2, ProductID: 1, Quantity: 1, ExpiresAt: now.Add(-time.Hour)},
+			{OrderID: 3, ProductID: 1, Quantity: 1, ExpiresAt: now},
+			{OrderID: 4, ProductID: 1, Quantity: 1, ExpiresAt: now.Add(time.Minute)},
+			{OrderID: 5, ProductID: 1, Quantity: 1, ExpiresAt: now.Add(-time.Hour)},
+		} {
+			created, err := b.Reservations.CreateReservation(t.Context(), reservation)
+			must(t, "CreateReservation", err)
+			ids = append(ids, created.ID)
+		}
+		// Settled reservations never expire
+		must(t, "CommitReservation", b.Reservations.CommitReservation(t.Context(), ids[4]))
+
+		expired, err := b.Reservations.GetExpiredReservations(t.Context(), now, 10)
+		must(t, "GetExpiredReservations", err)
+		var orders []int
+		for _, reservation := range expired {
+			orders = append(orders, reservation.OrderID)
+		}
+		if len(orders) != 3 || orders[0] != 2 || orders[1] != 1 || orders[2] != 3 {
+			t.Fatalf("GetExpiredReservations returned the reservations of orders %v, want [2 1 3]", orders)
+		}
+
+		expired, err = b.Reservations.GetExpiredReservations(t.Context(), now, 1)
+		must(t, "GetExpiredReservations with a limit", err)
+		if len(expired) != 1 || expired[0].OrderID != 2 {
+			t.Fatalf("GetExpiredReservations with a limit of 1 returned %+v", expired)
+		}
+	})
+
+	t.Run("Settle", func(t *testing.T) {
+		b := newBackend(t)
+		expiresAt := time.Now().Add(time.Hour)
+		first, err := b.Reservations.CreateReservation(t.Context(), entity.InventoryReservation{OrderID: 7, ProductID: 1, Quantity: 2, ExpiresAt: expiresAt})
+		must(t, "CreateReservation", err)
+		second, err := b.Reservations.CreateReservation(t.Context(), entity.InventoryReservation{OrderID: 7, ProductID: 2, Quantity: 1, ExpiresAt: expiresAt})
+		must(t, "CreateReservation", err)
+
+		must(t, "CommitReservation", b.Reservations.CommitReservation(t.Context(), first.ID))
+		must(t, "ReleaseReservation", b.Reservations.ReleaseReservation(t.Context(), second.ID))
+		got, err := b.Reservations.GetOrderReservations(t.Context(), 7)
+		must(t, "GetOrderReservations", err)
+		if got[0].Status != entity.ReservationCommitted || got[1].Status != entity.ReservationReleased {
+			t.Fatalf("statuses after settling = %s, %s; want committed, released", got[0].Status, got[1].Status)
+		}
+
+		err = b.Reservations.ReleaseReservation(t.Context(), first.ID)
+		expectKind(t, errs.ErrConflict, "ReleaseReservation of a committed reservation", err)
+		err = b.Reservations.CommitReservation(t.Context(), second.ID)
+		expectKind(t, errs.ErrConflict, "CommitReservation of a released reservation", err)
+		expectNotFound(t, "CommitReservation of a missing reservation", b.Reservations.CommitReservation(t.Context(), 999))
+	})
+}
@@ -0,0 +1,69 @@
+// Package repositorytest is a conformance suite for implementations of the
+// domain/repository interfaces. A backend is validated by calling Run from
+// a test in its own package:
+//
+//	func TestRepositoryContract(t *testing.T) {
+//		repositorytest.Run(t, func(t *testing.T) repositorytest.Backend {
+//			return newBackend(t)
+//		})
+//	}
+package repositorytest
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/witchakornb/basic-ecommerce/domain/errs"
+	"github.com/witchakornb/basic-ecommerce/domain/repository"
+)
+
+// Backend is one empty instance of a storage backend. Every repository and
+// the unit of work must operate on the same underlying data, and report
+// missing records and unique key violations with the errs kinds
+// ErrNotFound and ErrConflict.
+type Backend struct {
+	Users         repository.UserRepository
+	Products      repository.ProductRepository
+	Orders        repository.OrderRepository
+	RefreshTokens repository.RefreshTokenRepository
+	Carts         repository.CartRepository
+	Reservations  repository.InventoryReservationRepository
+	UnitOfWork    repository.UnitOfWork
+}
+
+// Factory creates a new, empty Backend. It is called once per test and
+// should register any cleanup with t.Cleanup.
+type Factory func(t *testing.T) Backend
+
+// Run runs the whole conformance suite against the backends created by newBackend.
+func Run(t *testing.T, newBackend Factory) {
+	t.Run("Users", func(t *testing.T) { testUsers(t, newBackend) })
+	t.Run("Products", func(t *testing.T) { testProducts(t, newBackend) })
+	t.Run("Orders", func(t *testing.T) { testOrders(t, newBackend) })
+	t.Run("RefreshTokens", func(t *testing.T) { testRefreshTokens(t, newBackend) })
+	t.Run("Carts", func(t *testing.T) { testCarts(t, newBackend) })
+	t.Run("Reservations", func(t *testing.T) { testReservations(t, newBackend) })
+	t.Run("UnitOfWork", func(t *testing.T) { testUnitOfWork(t, newBackend) })
+}
+
+// expectKind fails the test unless err is a domain error of the kind.
+func expectKind(t *testing.T, kind error, what string, err error) {
+	t.Helper()
+	if !errors.Is(err, kind) {
+		t.Fatalf("%s: expected an error matching %q, got %v", what, kind, err)
+	}
+}
+
+// expectNotFound fails the test unless err is a not-found domain error.
+func expectNotFound(t *testing.T, what string, err error) {
+	t.Helper()
+	expectKind(t, errs.ErrNotFound, what, err)
+}
+
+// must fails the test if err is not nil.
+func must(t *testing.T, what string, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("%s: %v", what, err)
+	}
+}
@@ -0,0 +1,323 @@
+package repositorytest
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/witchakornb/basic-ecommerce/domain/entity"
+	"github.com/witchakornb/basic-ecommerce/domain/errs"
+	"github.com/witchakornb/basic-ecommerce/domain/repository"
+)
+
+var errAbort = errors.New("abort")
+
+func testUnitOfWork(t *testing.T, newBackend Factory) {
+	t.Run("Commit", func(t *testing.T) {
+		b := newBackend(t)
+		var userID, productID int
+		err := b.UnitOfWork.Execute(t.Context(), func(ctx context.Context, store repository.UnitOfWorkStore) error {
+			user, err := store.Users().CreateUser(ctx, entity.User{Username: "alice", Email: "alice@example.com", Role: entity.RoleCustomer})
+			if err != nil {
+				return err
+			}
+			product, err := store.Products().CreateProduct(ctx, entity.Product{Name: "Mug", Price: usd(1000), Stock: 3})
+			if err != nil {
+				return err
+			}
+			userID, productID = user.ID, product.ID
+
+			// Writes are visible to later reads of the same unit of work.
+			if err := store.Products().AdjustStock(ctx, product.ID, -1); err != nil {
+				return err
+			}
+			product, err = store.Products().GetProductByID(ctx, product.ID)
+			if err != nil {
+				return err
+			}
+			if product.Stock != 2 {
+				t.Errorf("stock inside the unit of work = %d, want 2", product.Stock)
+			}
+			return nil
+		})
+		must(t, "Execute", err)
+
+		_, err = b.Users.GetUserByID(t.Context(), userID)
+		must(t, "GetUserByID after commit", err)
+		product, err := b.Products.GetProductByID(t.Context(), productID)
+		must(t, "GetProductByID after commit", err)
+		if product.Stock != 2 {
+			t.Fatalf("stock after commit = %d, want 2", product.Stock)
+		}
+	})
+
+	t.Run("RollbackOnError", func(t *testing.T) {
+		b := newBackend(t)
+		product, err := b.Products.CreateProduct(t.Context(), entity.Product{Name: "Mug", Price: usd(1000), Stock: 3})
+		must(t, "CreateProduct", err)
+
+		var userID, orderID int
+		err = b.UnitOfWork.Execute(t.Context(), func(ctx context.Context, store repository.UnitOfWorkStore) error {
+			user, err := store.Users().CreateUser(ctx, entity.User{Username: "alice", Email: "alice@example.com", Role: entity.RoleCustomer})
+			if err != nil {
+				return err
+			}
+			userID = user.ID
+			if err := store.Products().AdjustStock(ctx, product.ID, -2); err != nil {
+				return err
+			}
+			order, err := store.Orders().CreateOrder(ctx, newOrder(user.ID))
+			if err != nil {
+				return err
+			}
+			orderID = order.ID
+			return errAbort
+		})
+		if !errors.Is(err, errAbort) {
+			t.Fatalf("Execute returned %v, want the error returned by fn", err)
+		}
+		assertRolledBack(t, b, product.ID, userID, orderID)
+	})
+
+	t.Run("RollbackOnPanic", func(t *testing.T) {
+		b := newBackend(t)
+		product, err := b.Products.CreateProduct(t.Context(), entity.Product{Name: "Mug", Price: usd(1000), Stock: 3})
+		must(t, "CreateProduct", err)
+
+		var userID, orderID int
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Fatal("Execute swallowed the panic of fn")
+				}
+			}()
+			_ = b.UnitOfWork.Execute(t.Context(), func(ctx context.Context, store repository.UnitOfWorkStore) error {
+				user, err := store.Users().CreateUser(ctx, entity.User{Username: "alice", Email: "alice@example.com", Role: entity.RoleCustomer})
+				if err != nil {
+					return err
+				}
+				userID = user.ID
+				if err := store.Products().AdjustStock(ctx, product.ID, -2); err != nil {
+					return err
+				}
+				order, err := store.Orders().CreateOrder(ctx, newOrder(user.ID))
+				if err != nil {
+					return err
+				}
+				orderID = order.ID
+				panic(errAbort)
+			})
+		}()
+		assertRolledBack(t, b, product.ID, userID, orderID)
+	})
+
+	t.Run("RollbackOnCancel", func(t *testing.T) {
+		b := newBackend(t)
+		product, err := b.Products.CreateProduct(t.Context(), entity.Product{Name: "Mug", Price: usd(1000), Stock: 3})
+		must(t, "CreateProduct", err)
+
+		// fn succeeds, but the caller gives up before the unit of work commits
+		ctx, cancel := context.WithCancel(t.Context())
+		defer cancel()
+		var userID, orderID int
+		err = b.UnitOfWork.Execute(ctx, func(ctx context.Context, store repository.UnitOfWorkStore) error {
+			user, err := store.Users().CreateUser(ctx, entity.User{Username: "alice", Email: "alice@example.com", Role: entity.RoleCustomer})
+			if err != nil {
+				return err
+			}
+			userID = user.ID
+			if err := store.Products().AdjustStock(ctx, product.ID, -2); err != nil {
+				return err
+			}
+			order, err := store.Orders().CreateOrder(ctx, newOrder(user.ID))
+			if err != nil {
+				return err
+			}
+			orderID = order.ID
+			cancel()
+			return nil
+		})
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("Execute returned %v, want context.Canceled", err)
+		}
+		assertRolledBack(t, b, product.ID, userID, orderID)
+
+		err = b.UnitOfWork.Execute(ctx, func(ctx context.Context, store repository.UnitOfWorkStore) error {
+			t.Error("Execute ran fn with a cancelled context")
+			return nil
+		})
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("Execute with a cancelled context returned %v, want context.Canceled", err)
+		}
+	})
+
+	t.Run("ConcurrentStatusUpdate", func(t *testing.T) {
+		b := newBackend(t)
+		product, err := b.Products.CreateProduct(t.Context(), entity.Product{Name: "Mug", Price: usd(1000), Stock: 0})
+		must(t, "CreateProduct", err)
+		order := newOrder(1)
+		order.Status = entity.OrderStatusPaid
+		order, err = b.Orders.CreateOrder(t.Context(), order)
+		must(t, "CreateOrder", err)
+
+		// Each request refunds the order and puts its stock back in the
+		// same unit of work, so only the one that moved the status may.
+		const requests = 10
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		refunded, rejected := 0, 0
+		for range requests {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				err := b.UnitOfWork.Execute(t.Context(), func(ctx context.Context, store repository.UnitOfWorkStore) error {
+					if err := store.Orders().UpdateOrderStatus(ctx, order.ID, entity.OrderStatusPaid, entity.OrderStatusRefunded); err != nil {
+						return err
+					}
+					return store.Products().AdjustStock(ctx, product.ID, 2)
+				})
+				mu.Lock()
+				defer mu.Unlock()
+				switch {
+				case err == nil:
+					refunded++
+				case errors.Is(err, repository.ErrOrderStatusChanged):
+					rejected++
+				default:
+					t.Errorf("Execute: %v", err)
+				}
+			}()
+		}
+		wg.Wait()
+
+		if refunded != 1 || rejected != requests-1 {
+			t.Fatalf("refunded %d times and rejected %d, want 1 and %d", refunded, rejected, requests-1)
+		}
+		got, err := b.Products.GetProductByID(t.Context(), product.ID)
+		must(t, "GetProductByID", err)
+		if got.Stock != 2 {
+			t.Fatalf("stock = %d, want 2", got.Stock)
+		}
+	})
+
+	t.Run("ConcurrentDeleteAndCancel", func(t *testing.T) {
+		b := newBackend(t)
+		product, err := b.Products.CreateProduct(t.Context(), entity.Product{Name: "Mug", Price: usd(1000), Stock: 0})
+		must(t, "CreateProduct", err)
+		order, err := b.Orders.CreateOrder(t.Context(), newOrder(1))
+		must(t, "CreateOrder", err)
+
+		// Half the requests delete the pending order and half cancel it;
+		// both put its stock back, so only the first to claim it may.
+		const requests = 10
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		restored, rejected := 0, 0
+		for i := range requests {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				err := b.UnitOfWork.Execute(t.Context(), func(ctx context.Context, store repository.UnitOfWorkStore) error {
+					var err error
+					if i%2 == 0 {
+						err = store.Orders().DeleteOrder(ctx, order.ID, entity.OrderStatusPending)
+					} else {
+						err = store.Orders().UpdateOrderStatus(ctx, order.ID, entity.OrderStatusPending, entity.OrderStatusCancelled)
+					}
+					if err != nil {
+						return err
+					}
+					return store.Products().AdjustStock(ctx, product.ID, 2)
+				})
+				mu.Lock()
+				defer mu.Unlock()
+				switch {
+				case err == nil:
+					restored++
+				case errors.Is(err, repository.ErrOrderStatusChanged), errors.Is(err, errs.ErrNotFound):
+					rejected++
+				default:
+					t.Errorf("Execute: %v", err)
+				}
+			}()
+		}
+		wg.Wait()
+
+		if restored != 1 || rejected != requests-1 {
+			t.Fatalf("restored stock %d times and rejected %d, want 1 and %d", restored, rejected, requests-1)
+		}
+		got, err := b.Products.GetProductByID(t.Context(), product.ID)
+		must(t, "GetProductByID", err)
+		if got.Stock != 2 {
+			t.Fatalf("stock = %d, want 2", got.Stock)
+		}
+	})
+
+	t.Run("ConcurrentAdjustStock", func(t *testing.T) {
+		b := newBackend(t)
+		const stock, buyers = 10, 25
+		product, err := b.Products.CreateProduct(t.Context(), entity.Product{Name: "Mug", Price: usd(1000), Stock: stock})
+		must(t, "CreateProduct", err)
+
+		// Each buyer takes one unit and records an order in the same unit of
+		// work, so a rejected buyer must leave no order behind.
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		sold, rejected := 0, 0
+		for range buyers {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				err := b.UnitOfWork.Execute(t.Context(), func(ctx context.Context, store repository.UnitOfWorkStore) error {
+					if _, err := store.Orders().CreateOrder(ctx, newOrder(1)); err != nil {
+						return err
+					}
+					return store.Products().AdjustStock(ctx, product.ID, -1)
+				})
+				mu.Lock()
+				defer mu.Unlock()
+				switch {
+				case err == nil:
+					sold++
+				case errors.Is(err, repository.ErrInsufficientStock):
+					rejected++
+				default:
+					t.Errorf("Execute: %v", err)
+				}
+			}()
+		}
+		wg.Wait()
+
+		if sold != stock || rejected != buyers-stock {
+			t.Fatalf("sold %d and rejected %d, want %d and %d", sold, rejected, stock, buyers-stock)
+		}
+		got, err := b.Products.GetProductByID(t.Context(), product.ID)
+		must(t, "GetProductByID", err)
+		if got.Stock != 0 {
+			t.Fatalf("stock = %d, want 0", got.Stock)
+		}
+		page, err := b.Orders.GetAllOrders(t.Context(), repository.OrderFilter{}, repository.ListOptions{})
+		must(t, "GetAllOrders", err)
+		if page.Total != stock {
+			t.Fatalf("%d orders were committed, want %d", page.Total, stock)
+		}
+	})
+}
+
+// assertRolledBack checks that the writes of an aborted unit of work are gone.
+func assertRolledBack(t *testing.T, b Backend, productID, userID, orderID int) {
+	t.Helper()
+	if userID == 0 || orderID == 0 {
+		t.Fatal("the unit of work did not run to the point of failure")
+	}
+	_, err := b.Users.GetUserByID(t.Context(), userID)
+	expectNotFound(t, "GetUserByID after rollback", err)
+	_, err = b.Orders.GetOrderByID(t.Context(), orderID)
+	expectNotFound(t, "GetOrderByID after rollback", err)
+	product, err := b.Products.GetProductByID(t.Context(), productID)
+	must(t, "GetProductByID after rollback", err)
+	if product.Stock != 3 {
+		t.Fatalf("stock after rollback = %d, want 3", product.Stock)
+	}
+}
@@ -0,0 +1,160 @@
+package repositorytest
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/witchakornb/basic-ecommerce/domain/entity"
+	"github.com/witchakornb/basic-ecommerce/domain/errs"
+	"github.com/witchakornb/basic-ecommerce/domain/repository"
+)
+
+func testUsers(t *testing.T, newBackend Factory) {
+	t.Run("CreateAndGet", func(t *testing.T) {
+		b := newBackend(t)
+		created, err := b.Users.CreateUser(t.Context(), entity.User{Username: "alice", Email: "alice@example.com", Password: "hash", Role: entity.RoleCustomer})
+		must(t, "CreateUser", err)
+		if created.ID == 0 {
+			t.Fatal("CreateUser did not assign an ID")
+		}
+
+		byID, err := b.Users.GetUserByID(t.Context(), created.ID)
+		must(t, "GetUserByID", err)
+		if byID.Email != "alice@example.com" || byID.Username != "alice" || byID.Role != entity.RoleCustomer {
+			t.Fatalf("GetUserByID returned %+v", byID)
+		}
+
+		byEmail, err := b.Users.GetUserByEmail(t.Context(), "alice@example.com")
+		must(t, "GetUserByEmail", err)
+		if byEmail.ID != created.ID {
+			t.Fatalf("GetUserByEmail returned user %d, want %d", byEmail.ID, created.ID)
+		}
+	})
+
+	t.Run("DuplicateEmail", func(t *testing.T) {
+		b := newBackend(t)
+		_, err := b.Users.CreateUser(t.Context(), entity.User{Username: "alice", Email: "alice@example.com", Role: entity.RoleCustomer})
+		must(t, "CreateUser", err)
+		_, err = b.Users.CreateUser(t.Context(), entity.User{Username: "bob", Email: "alice@example.com", Role: entity.RoleCustomer})
+		expectKind(t, errs.ErrConflict, "CreateUser with a duplicate email", err)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		b := newBackend(t)
+		_, err := b.Users.GetUserByID(t.Context(), 42)
+		expectNotFound(t, "GetUserByID of a missing user", err)
+		_, err = b.Users.GetUserByEmail(t.Context(), "nobody@example.com")
+		expectNotFound(t, "GetUserByEmail of a missing user", err)
+	})
+
+	t.Run("UpdateAndDelete", func(t *testing.T) {
+		b := newBackend(t)
+		user, err := b.Users.CreateUser(t.Context(), entity.User{Username: "alice", Email: "alice@example.com", Role: entity.RoleCustomer})
+		must(t, "CreateUser", err)
+
+		user.Username = "alice2"
+		user.Role = entity.RoleStaff
+		_, err = b.Users.UpdateUser(t.Context(), user)
+		must(t, "UpdateUser", err)
+		got, err := b.Users.GetUserByID(t.Context(), user.ID)
+		must(t, "GetUserByID", err)
+		if got.Username != "alice2" || got.Role != entity.RoleStaff {
+			t.Fatalf("UpdateUser was not persisted: %+v", got)
+		}
+
+		must(t, "DeleteUser", b.Users.DeleteUser(t.Context(), user.ID))
+		_, err = b.Users.GetUserByID(t.Context(), user.ID)
+		expectNotFound(t, "GetUserByID of a deleted user", err)
+		_, err = b.Users.GetUserByEmail(t.Context(), "alice@example.com")
+		expectNotFound(t, "GetUserByEmail of a deleted user", err)
+		must(t, "DeleteUser of a deleted user", b.Users.DeleteUser(t.Context(), user.ID))
+	})
+
+	t.Run("List", func(t *testing.T) {
+		b := newBackend(t)
+		for i := 1; i <= 5; i++ {
+			role := entity.RoleCustomer
+			if i%2 == 0 {
+				role = entity.RoleStaff
+			}
+			_, err := b.Users.CreateUser(t.Context(), entity.User{Username: fmt.Sprintf("user%d", i), Email: fmt.Sprintf("user%d@example.com", i), Role: role})
+			must(t, "CreateUser", err)
+		}
+
+		page, err := b.Users.GetAllUsers(t.Context(), repository.UserFilter{Role: entity.RoleStaff}, repository.ListOptions{})
+		must(t, "GetAllUsers", err)
+		if page.Total != 2 || len(page.Items) != 2 {
+			t.Fatalf("role filter returned %d of %d users, want 2 of 2", len(page.Items), page.Total)
+		}
+		page, err = b.Users.GetAllUsers(t.Context(), repository.UserFilter{Email: "user3@example.com"}, repository.ListOptions{})
+		must(t, "GetAllUsers", err)
+		if page.Total != 1 || page.Items[0].Username != "user3" {
+			t.Fatalf("email filter returned %+v, want user3", page)
+		}
+
+		var names []string
+		opts := repository.ListOptions{Limit: 2, Sort: []repository.SortKey{{Field: "username", Desc: true}}}
+		for {
+			page, err := b.Users.GetAllUsers(t.Context(), repository.UserFilter{}, opts)
+			must(t, "GetAllUsers", err)
+			if page.Total != 5 {
+				t.Fatalf("GetAllUsers Total = %d, want 5", page.Total)
+			}
+			for _, user := range page.Items {
+				names = append(names, user.Username)
+			}
+			if page.NextCursor == "" {
+				break
+			}
+			opts.Cursor = page.NextCursor
+		}
+		if got, want := fmt.Sprint(names), "[user5 user4 user3 user2 user1]"; got != want {
+			t.Fatalf("paging by cursor returned %s, want %s", got, want)
+		}
+
+		if _, err := b.Users.GetAllUsers(t.Context(), repository.UserFilter{}, repository.ListOptions{Sort: []repository.SortKey{{Field: "password"}}}); !errors.Is(err, repository.ErrInvalidListOptions) {
+			t.Fatalf("sorting by an unknown key returned %v, want ErrInvalidListOptions", err)
+		}
+	})
+
+	t.Run("SoftDeleteAndRestore", func(t *testing.T) {
+		b := newBackend(t)
+		user, err := b.Users.CreateUser(t.Context(), entity.User{Username: "alice", Email: "alice@example.com", Role: entity.RoleCustomer})
+		must(t, "CreateUser", err)
+		if user.CreatedAt.IsZero() || user.UpdatedAt.IsZero() {
+			t.Fatalf("CreateUser did not set the timestamps: %+v", user)
+		}
+		must(t, "DeleteUser", b.Users.DeleteUser(t.Context(), user.ID))
+
+		_, err = b.Users.CreateUser(t.Context(), entity.User{Username: "alice2", Email: "alice@example.com", Role: entity.RoleCustomer})
+		expectKind(t, errs.ErrConflict, "CreateUser with the email of a deleted user", err)
+
+		page, err := b.Users.GetAllUsers(t.Context(), repository.UserFilter{}, repository.ListOptions{})
+		must(t, "GetAllUsers", err)
+		if page.Total != 0 {
+			t.Fatalf("GetAllUsers listed %d deleted users", page.Total)
+		}
+		page, err = b.Users.GetAllUsers(t.Context(), repository.UserFilter{IncludeDeleted: true}, repository.ListOptions{})
+		must(t, "GetAllUsers", err)
+		if page.Total != 1 || !page.Items[0].DeletedAt.Valid {
+			t.Fatalf("GetAllUsers with deleted users returned %+v", page)
+		}
+		page, err = b.Users.GetAllUsers(t.Context(), repository.UserFilter{Email: "alice@example.com", IncludeDeleted: true}, repository.ListOptions{})
+		must(t, "GetAllUsers", err)
+		if page.Total != 1 || page.Items[0].ID != user.ID {
+			t.Fatalf("GetAllUsers of a deleted user's email returned %+v", page)
+		}
+
+		restored, err := b.Users.RestoreUser(t.Context(), user.ID)
+		must(t, "RestoreUser", err)
+		if restored.DeletedAt.Valid || restored.Email != "alice@example.com" {
+			t.Fatalf("RestoreUser returned %+v", restored)
+		}
+		_, err = b.Users.GetUserByEmail(t.Context(), "alice@example.com")
+		must(t, "GetUserByEmail of a restored user", err)
+
+		_, err = b.Users.RestoreUser(t.Context(), 42)
+		expectNotFound(t, "RestoreUser of a missing user", err)
+	})
+}
@@ -1,11 +1,15 @@
 package repository
 
+import "context"
+
 // UnitOfWork defines the interface for a unit of work.
 type UnitOfWork interface {
 	// Execute runs a function within a transaction.
 	// If the function returns an error, the transaction is rolled back.
-	// Otherwise, the transaction is committed.
-	Execute(func(store UnitOfWorkStore) error) error
+	// Otherwise, the transaction is committed. The transaction is also
+	// rolled back, with the context's error, if ctx is done before it commits.
+	// fn receives a context derived from ctx for the calls it makes to store.
+	Execute(ctx context.Context, fn func(ctx context.Context, store UnitOfWorkStore) error) error
 }
 
 // UnitOfWorkStore defines the interface for a store that can be used within a unit of work.
@@ -14,4 +18,7 @@ type UnitOfWorkStore interface {
 	Users() UserRepository
 	Products() ProductRepository
 	Orders() OrderRepository
+	RefreshTokens() RefreshTokenRepository
+	Carts() CartRepository
+	Reservations() InventoryReservationRepository
 }
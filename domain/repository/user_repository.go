@@ -1,12 +1,20 @@
 package repository
 
 import (
+	"context"
+
 	"github.com/witchakornb/basic-ecommerce/domain/entity"
 )
 
 type UserRepository interface {
-	CreateUser(user entity.User) (entity.User, error)
-	GetUserByID(id int) (entity.User, error)
-	UpdateUser(user entity.User) (entity.User, error)
-	DeleteUser(id int) error
+	CreateUser(ctx context.Context, user entity.User) (entity.User, error)
+	GetUserByID(ctx context.Context, id int) (entity.User, error)
+	GetUserByEmail(ctx context.Context, email string) (entity.User, error)
+	GetAllUsers(ctx context.Context, filter UserFilter, opts ListOptions) (Page[entity.User], error)
+	UpdateUser(ctx context.Context, user entity.User) (entity.User, error)
+	// DeleteUser soft-deletes a user: it is hidden from every lookup and
+	// list until it is restored, but keeps its email address.
+	DeleteUser(ctx context.Context, id int) error
+	// RestoreUser undeletes a soft-deleted user and returns it.
+	RestoreUser(ctx context.Context, id int) (entity.User, error)
 }
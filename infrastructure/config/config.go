@@ -0,0 +1,184 @@
+package infrastructure
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"slices"
+	"time"
+)
+
+// Config is the configuration of the application. Every setting has a key
+// used in configuration files and as a command-line flag, and most have an
+// environment variable; see Load for how the sources are merged.
+type Config struct {
+	Server    ServerConfig    `config:"server"`
+	Database  DatabaseConfig  `config:"database"`
+	Auth      AuthConfig      `config:"auth"`
+	Inventory InventoryConfig `config:"inventory"`
+	Log       LogConfig       `config:"log"`
+	Tracing   TracingConfig   `config:"tracing"`
+	Features  FeatureConfig   `config:"features"`
+}
+
+// ServerConfig configures the HTTP server.
+type ServerConfig struct {
+	Addr string `config:"addr" env:"SERVER_ADDR" usage:"address the HTTP server listens on"`
+	Mode string `config:"mode" env:"GIN_MODE" usage:"gin mode: debug, release or test"`
+	// Timeouts of the http.Server; 0 disables a timeout
+	ReadTimeout       time.Duration `config:"read_timeout" env:"SERVER_READ_TIMEOUT" usage:"maximum time to read a request, including its body"`
+	ReadHeaderTimeout time.Duration `config:"read_header_timeout" env:"SERVER_READ_HEADER_TIMEOUT" usage:"maximum time to read the request headers"`
+	WriteTimeout      time.Duration `config:"write_timeout" env:"SERVER_WRITE_TIMEOUT" usage:"maximum time to write a response"`
+	IdleTimeout       time.Duration `config:"idle_timeout" env:"SERVER_IDLE_TIMEOUT" usage:"how long keep-alive connections wait for the next request"`
+	// RequestTimeout is the deadline of the context each request is handled
+	// with, so it should be shorter than WriteTimeout to leave time to answer
+	RequestTimeout time.Duration `config:"request_timeout" env:"SERVER_REQUEST_TIMEOUT" usage:"how long the work for a request may take, including its database queries; 0 for no limit"`
+	// HealthCheckTimeout bounds each check of the health probes
+	HealthCheckTimeout time.Duration `config:"health_check_timeout" env:"SERVER_HEALTH_CHECK_TIMEOUT" usage:"how long each health check may take"`
+	// On SIGINT or SIGTERM the server reports not ready for ShutdownDelay,
+	// then drains requests and stops workers within ShutdownTimeout
+	ShutdownDelay   time.Duration `config:"shutdown_delay" env:"SERVER_SHUTDOWN_DELAY" usage:"how long to keep serving while reporting not ready when stopping"`
+	ShutdownTimeout time.Duration `config:"shutdown_timeout" env:"SERVER_SHUTDOWN_TIMEOUT" usage:"how long to wait for requests and workers to finish when stopping"`
+}
+
+// DatabaseConfig configures the database connection and its pool.
+type DatabaseConfig struct {
+	DSN             string        `config:"dsn" env:"DATABASE_URL" usage:"database to connect to, by scheme: sqlite://, postgres:// or mysql://"`
+	MaxOpenConns    int           `config:"max_open_conns" env:"DATABASE_MAX_OPEN_CONNS" usage:"maximum open connections, 0 for unlimited (SQLite uses one)"`
+	MaxIdleConns    int           `config:"max_idle_conns" env:"DATABASE_MAX_IDLE_CONNS" usage:"maximum idle connections"`
+	ConnMaxLifetime time.Duration `config:"conn_max_lifetime" env:"DATABASE_CONN_MAX_LIFETIME" usage:"close connections older than this, 0 to keep them"`
+	ConnMaxIdleTime time.Duration `config:"conn_max_idle_time" env:"DATABASE_CONN_MAX_IDLE_TIME" usage:"close connections idle longer than this, 0 to keep them"`
+	ConnectTimeout  time.Duration `config:"connect_timeout" env:"DATABASE_CONNECT_TIMEOUT" usage:"how long to wait for the database at startup, 0 to wait forever"`
+}
+
+// AuthConfig configures authentication.
+type AuthConfig struct {
+	JWTSecret       string        `config:"jwt_secret" env:"JWT_SECRET" usage:"access token signing secret of at least 32 bytes; random if empty"`
+	AccessTokenTTL  time.Duration `config:"access_token_ttl" env:"ACCESS_TOKEN_TTL" usage:"lifetime of access tokens"`
+	RefreshTokenTTL time.Duration `config:"refresh_token_ttl" env:"REFRESH_TOKEN_TTL" usage:"lifetime of refresh tokens"`
+	BcryptCost      int           `config:"bcrypt_cost" env:"BCRYPT_COST" usage:"bcrypt cost of password hashes"`
+	AdminEmail      string        `config:"admin_email" env:"ADMIN_EMAIL" usage:"email of the admin created at startup"`
+	AdminPassword   string        `config:"admin_password" env:"ADMIN_PASSWORD" usage:"password of the admin created at startup"`
+}
+
+// InventoryConfig configures how stock is held for orders awaiting payment.
+type InventoryConfig struct {
+	ReservationTTL time.Duration `config:"reservation_ttl" env:"INVENTORY_RESERVATION_TTL" usage:"how long the stock of an unpaid order is held before the order is cancelled"`
+	SweepInterval  time.Duration `config:"sweep_interval" env:"INVENTORY_SWEEP_INTERVAL" usage:"how often expired reservations are released"`
+}
+
+// LogConfig configures logging.
+type LogConfig struct {
+	Level              slog.Level    `config:"level" env:"LOG_LEVEL" usage:"minimum level logged: debug, info, warn or error"`
+	Format             string        `config:"format" env:"LOG_FORMAT" usage:"log format: json or text"`
+	SlowQueryThreshold time.Duration `config:"slow_query_threshold" env:"LOG_SLOW_QUERY_THRESHOLD" usage:"log SQL statements slower than this as warnings, 0 to never"`
+}
+
+// TracingConfig configures where the spans of requests, use cases,
+// transactions and SQL statements are exported.
+type TracingConfig struct {
+	Exporter string `config:"exporter" env:"TRACING_EXPORTER" usage:"where spans are exported: none, stdout or otlp"`
+	Endpoint string `config:"endpoint" env:"TRACING_ENDPOINT" usage:"URL of the OTLP/HTTP collector, such as http://localhost:4318; empty follows OTEL_EXPORTER_OTLP_ENDPOINT"`
+}
+
+// FeatureConfig turns optional features on and off.
+type FeatureConfig struct {
+	Search bool `config:"search" env:"FEATURE_SEARCH" usage:"serve full-text product search"`
+}
+
+// Default returns the configuration used for every setting no source sets.
+func Default() Config {
+	return Config{
+		Server: ServerConfig{
+			Addr:               ":8080",
+			Mode:               "debug",
+			ReadTimeout:        15 * time.Second,
+			ReadHeaderTimeout:  5 * time.Second,
+			WriteTimeout:       30 * time.Second,
+			IdleTimeout:        2 * time.Minute,
+			RequestTimeout:     20 * time.Second,
+			HealthCheckTimeout: 2 * time.Second,
+			ShutdownTimeout:    30 * time.Second,
+		},
+		Database: DatabaseConfig{
+			DSN:             "test.db",
+			MaxIdleConns:    2,
+			ConnMaxLifetime: 30 * time.Minute,
+			ConnMaxIdleTime: 5 * time.Minute,
+			ConnectTimeout:  10 * time.Second,
+		},
+		Auth: AuthConfig{
+			AccessTokenTTL:  15 * time.Minute,
+			RefreshTokenTTL: 30 * 24 * time.Hour,
+			BcryptCost:      10,
+		},
+		Inventory: InventoryConfig{
+			ReservationTTL: 30 * time.Minute,
+			SweepInterval:  time.Minute,
+		},
+		Log: LogConfig{
+			Level:              slog.LevelInfo,
+			Format:             "json",
+			SlowQueryThreshold: 200 * time.Millisecond,
+		},
+		Tracing: TracingConfig{
+			Exporter: "none",
+		},
+		Features: FeatureConfig{
+			Search: true,
+		},
+	}
+}
+
+// ginModes are the modes gin.SetMode accepts.
+var ginModes = []string{"debug", "release", "test"}
+
+// traceExporters are the supported span exporters.
+var traceExporters = []string{"none", "stdout", "otlp"}
+
+// Validate reports every invalid setting, one per line.
+func (c Config) Validate() error {
+	var problems []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			problems = append(problems, fmt.Errorf(format, args...))
+		}
+	}
+
+	_, _, err := net.SplitHostPort(c.Server.Addr)
+	check(err == nil, "server.addr %q must be host:port or :port, such as :8080", c.Server.Addr)
+	check(slices.Contains(ginModes, c.Server.Mode), "server.mode %q must be one of %v", c.Server.Mode, ginModes)
+	check(c.Server.ReadTimeout >= 0, "server.read_timeout must not be negative")
+	check(c.Server.ReadHeaderTimeout >= 0, "server.read_header_timeout must not be negative")
+	check(c.Server.WriteTimeout >= 0, "server.write_timeout must not be negative")
+	check(c.Server.IdleTimeout >= 0, "server.idle_timeout must not be negative")
+	check(c.Server.RequestTimeout >= 0, "server.request_timeout must not be negative")
+	check(c.Server.WriteTimeout == 0 || c.Server.RequestTimeout < c.Server.WriteTimeout, "server.request_timeout must be shorter than server.write_timeout")
+	check(c.Server.HealthCheckTimeout > 0, "server.health_check_timeout must be positive")
+	check(c.Server.ShutdownDelay >= 0, "server.shutdown_delay must not be negative")
+	check(c.Server.ShutdownTimeout > 0, "server.shutdown_timeout must be positive")
+
+	check(c.Database.DSN != "", "database.dsn must not be empty")
+	check(c.Database.MaxOpenConns >= 0, "database.max_open_conns must not be negative")
+	check(c.Database.MaxIdleConns >= 0, "database.max_idle_conns must not be negative")
+	check(c.Database.ConnMaxLifetime >= 0, "database.conn_max_lifetime must not be negative")
+	check(c.Database.ConnMaxIdleTime >= 0, "database.conn_max_idle_time must not be negative")
+	check(c.Database.ConnectTimeout >= 0, "database.connect_timeout must not be negative")
+
+	check(c.Auth.JWTSecret == "" || len(c.Auth.JWTSecret) >= 32, "auth.jwt_secret must be at least 32 bytes")
+	check(c.Auth.AccessTokenTTL > 0, "auth.access_token_ttl must be positive")
+	check(c.Auth.RefreshTokenTTL > c.Auth.AccessTokenTTL, "auth.refresh_token_ttl must be longer than auth.access_token_ttl")
+	check(c.Auth.BcryptCost >= 4 && c.Auth.BcryptCost <= 31, "auth.bcrypt_cost must be between 4 and 31")
+	check((c.Auth.AdminEmail == "") == (c.Auth.AdminPassword == ""), "auth.admin_email and auth.admin_password must be set together")
+
+	check(c.Inventory.ReservationTTL > 0, "inventory.reservation_ttl must be positive")
+	check(c.Inventory.SweepInterval > 0, "inventory.sweep_interval must be positive")
+
+	check(c.Log.Format == "json" || c.Log.Format == "text", "log.format %q must be json or text", c.Log.Format)
+	check(c.Log.SlowQueryThreshold >= 0, "log.slow_query_threshold must not be negative")
+
+	check(slices.Contains(traceExporters, c.Tracing.Exporter), "tracing.exporter %q must be one of %v", c.Tracing.Exporter, traceExporters)
+
+	return errors.Join(problems...)
+}
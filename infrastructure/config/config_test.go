@@ -0,0 +1,156 @@
+package infrastructure
+
+import (
+	"errors"
+	"flag"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// env returns a lookupEnv function backed by the map.
+func env(vars map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		value, ok := vars[key]
+		return value, ok
+	}
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("write %s: %v", name, err)
+	}
+	return path
+}
+
+func TestLoadDefaults(t *testing.T) {
+	cfg, args, err := Load([]string{"migrate", "up"}, env(nil))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg != Default() {
+		t.Fatalf("Load without sources returned %+v, want the defaults", cfg)
+	}
+	if strings.Join(args, " ") != "migrate up" {
+		t.Fatalf("Load returned the arguments %q", args)
+	}
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	yamlFile := writeFile(t, "config.yaml", `
+server:
+  addr: ":9000"
+  mode: release
+database:
+  dsn: file.db
+  max_open_conns: 8
+auth:
+  access_token_ttl: 5m
+features:
+  search: false
+`)
+	cfg, _, err := Load(
+		[]string{"-config", yamlFile, "-database.max_open_conns", "16", "-features.search"},
+		env(map[string]string{"DATABASE_URL": "postgres://localhost/shop", "SERVER_ADDR": "", "ACCESS_TOKEN_TTL": "10m", "LOG_LEVEL": "debug"}),
+	)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	// The file overrides the defaults, the environment the file and the flags everything
+	if cfg.Server.Addr != ":9000" || cfg.Server.Mode != "release" {
+		t.Errorf("server = %+v, want the file's settings", cfg.Server)
+	}
+	if cfg.Database.DSN != "postgres://localhost/shop" || cfg.Auth.AccessTokenTTL != 10*time.Minute {
+		t.Errorf("environment did not override the file: %+v %+v", cfg.Database, cfg.Auth)
+	}
+	if cfg.Database.MaxOpenConns != 16 || !cfg.Features.Search {
+		t.Errorf("flags did not override the file: %+v %+v", cfg.Database, cfg.Features)
+	}
+	if cfg.Log.Level != slog.LevelDebug {
+		t.Errorf("log.level = %v, want debug", cfg.Log.Level)
+	}
+	if cfg.Database.ConnMaxLifetime != Default().Database.ConnMaxLifetime {
+		t.Errorf("unset conn_max_lifetime = %v, want the default", cfg.Database.ConnMaxLifetime)
+	}
+}
+
+func TestLoadTOMLFromEnvironment(t *testing.T) {
+	tomlFile := writeFile(t, "config.toml", `
+[server]
+addr = "127.0.0.1:8081"
+
+[auth]
+bcrypt_cost = 12
+refresh_token_ttl = "48h"
+`)
+	cfg, _, err := Load(nil, env(map[string]string{"CONFIG_FILE": tomlFile}))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.Server.Addr != "127.0.0.1:8081" || cfg.Auth.BcryptCost != 12 || cfg.Auth.RefreshTokenTTL != 48*time.Hour {
+		t.Fatalf("Load returned %+v", cfg)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		env  map[string]string
+		file string
+		want string
+	}{
+		{name: "unknown flag", args: []string{"-server.port", "80"}, want: "flag provided but not defined"},
+		{name: "bad flag value", args: []string{"-database.max_open_conns", "many"}, want: `flag -database.max_open_conns: "many" is not an integer`},
+		{name: "bad env value", env: map[string]string{"DATABASE_CONNECT_TIMEOUT": "soon"}, want: `environment variable DATABASE_CONNECT_TIMEOUT: "soon" is not a duration`},
+		{name: "unknown file setting", file: "server:\n  port: 80\n", want: `unknown setting "server.port"`},
+		{name: "bad file value", file: "features:\n  search: maybe\n", want: `features.search: "maybe" is not a boolean`},
+		{name: "list in file", file: "server:\n  addr: [a, b]\n", want: "server.addr: must be a single value"},
+		{name: "invalid address", args: []string{"-server.addr", "8080"}, want: `server.addr "8080" must be host:port`},
+		{name: "bad log level", env: map[string]string{"LOG_LEVEL": "loud"}, want: `environment variable LOG_LEVEL: "loud" is invalid`},
+		{name: "request timeout too long", env: map[string]string{"SERVER_REQUEST_TIMEOUT": "1m"}, want: "server.request_timeout must be shorter than server.write_timeout"},
+		{name: "no reservation ttl", env: map[string]string{"INVENTORY_RESERVATION_TTL": "0s"}, want: "inventory.reservation_ttl must be positive"},
+		{name: "unknown trace exporter", env: map[string]string{"TRACING_EXPORTER": "jaeger"}, want: `tracing.exporter "jaeger" must be one of`},
+		{name: "invalid mode", env: map[string]string{"GIN_MODE": "prod"}, want: `server.mode "prod" must be one of`},
+		{name: "short secret", env: map[string]string{"JWT_SECRET": "secret"}, want: "auth.jwt_secret must be at least 32 bytes"},
+		{name: "admin without password", env: map[string]string{"ADMIN_EMAIL": "admin@example.com"}, want: "must be set together"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			args := test.args
+			if test.file != "" {
+				args = append([]string{"-config", writeFile(t, "config.yaml", test.file)}, args...)
+			}
+			_, _, err := load(args, env(test.env), io.Discard)
+			if err == nil || !strings.Contains(err.Error(), test.want) {
+				t.Fatalf("Load returned %v, want an error containing %q", err, test.want)
+			}
+		})
+	}
+}
+
+func TestValidateReportsEveryProblem(t *testing.T) {
+	cfg := Default()
+	cfg.Database.DSN = ""
+	cfg.Auth.BcryptCost = 2
+	cfg.Auth.RefreshTokenTTL = time.Minute
+	err := cfg.Validate()
+	for _, want := range []string{"database.dsn", "auth.bcrypt_cost", "auth.refresh_token_ttl"} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Validate returned %v, want a problem with %s", err, want)
+		}
+	}
+}
+
+func TestLoadHelp(t *testing.T) {
+	if _, _, err := load([]string{"-h"}, env(nil), io.Discard); !errors.Is(err, flag.ErrHelp) {
+		t.Fatalf("Load(-h) returned %v, want flag.ErrHelp", err)
+	}
+}
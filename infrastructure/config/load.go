@@ -0,0 +1,248 @@
+package infrastructure
+
+import (
+	"encoding"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// Load builds the configuration from, in increasing order of precedence:
+// the defaults, the YAML or TOML file named by the -config flag or the
+// CONFIG_FILE environment variable, the environment variables and the
+// command-line flags. It validates the result and returns it together with
+// the arguments left after the flags. Asking for help with -h returns
+// flag.ErrHelp after printing the usage.
+func Load(args []string, lookupEnv func(string) (string, bool)) (Config, []string, error) {
+	return load(args, lookupEnv, os.Stderr)
+}
+
+// load is Load with the usage and flag errors written to output.
+func load(args []string, lookupEnv func(string) (string, bool), output io.Writer) (Config, []string, error) {
+	cfg := Default()
+	settings := settingsOf(&cfg)
+
+	fs := flag.NewFlagSet("basic-ecommerce", flag.ContinueOnError)
+	fs.SetOutput(output)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: basic-ecommerce [flags] [migrate <command>]\n\nflags:\n")
+		fs.PrintDefaults()
+	}
+	configFile := fs.String("config", "", "YAML or TOML configuration `file` (env CONFIG_FILE)")
+	// Flags win over every other source, so they are only recorded here and applied last
+	var flagValues []flagValue
+	for _, s := range settings {
+		fs.Var(&settingFlag{setting: s, values: &flagValues}, s.key, s.usage())
+	}
+	if err := fs.Parse(args); err != nil {
+		return Config{}, nil, err
+	}
+
+	path := *configFile
+	if path == "" {
+		path, _ = lookupEnv("CONFIG_FILE")
+	}
+	if path != "" {
+		if err := loadFile(path, settings); err != nil {
+			return Config{}, nil, err
+		}
+	}
+
+	for _, s := range settings {
+		if s.env == "" {
+			continue
+		}
+		if raw, ok := lookupEnv(s.env); ok && raw != "" {
+			if err := s.set(raw); err != nil {
+				return Config{}, nil, fmt.Errorf("environment variable %s: %w", s.env, err)
+			}
+		}
+	}
+
+	for _, v := range flagValues {
+		if err := v.setting.set(v.raw); err != nil {
+			return Config{}, nil, fmt.Errorf("flag -%s: %w", v.setting.key, err)
+		}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return Config{}, nil, fmt.Errorf("invalid configuration:\n%w", err)
+	}
+	return cfg, fs.Args(), nil
+}
+
+// loadFile applies the settings of a YAML or TOML file, chosen by its extension.
+func loadFile(path string, settings []setting) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read configuration file: %w", err)
+	}
+	var tree map[string]any
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(content, &tree)
+	case ".toml":
+		err = toml.Unmarshal(content, &tree)
+	default:
+		return fmt.Errorf("%s: unsupported configuration file type %q, use .yaml, .yml or .toml", path, ext)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	values := make(map[string]string)
+	if err := flatten(tree, "", values); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	byKey := make(map[string]setting, len(settings))
+	for _, s := range settings {
+		byKey[s.key] = s
+	}
+	// Report problems in a stable order
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		s, ok := byKey[key]
+		if !ok {
+			return fmt.Errorf("%s: unknown setting %q", path, key)
+		}
+		if err := s.set(values[key]); err != nil {
+			return fmt.Errorf("%s: %s: %w", path, key, err)
+		}
+	}
+	return nil
+}
+
+// flatten turns nested tables into dotted keys with their scalar values as text.
+func flatten(tree map[string]any, prefix string, values map[string]string) error {
+	for name, value := range tree {
+		key := prefix + name
+		switch value := value.(type) {
+		case map[string]any:
+			if err := flatten(value, key+".", values); err != nil {
+				return err
+			}
+		case string, bool, int, int64, uint64, float64:
+			values[key] = fmt.Sprint(value)
+		default:
+			return fmt.Errorf("%s: must be a single value", key)
+		}
+	}
+	return nil
+}
+
+// setting is one configurable field of Config.
+type setting struct {
+	key   string
+	env   string
+	help  string
+	value reflect.Value
+}
+
+// settingsOf lists the settings of cfg, keyed by the dotted path of their
+// config tags, such as "database.max_open_conns".
+func settingsOf(cfg *Config) []setting {
+	var settings []setting
+	var walk func(v reflect.Value, prefix string)
+	walk = func(v reflect.Value, prefix string) {
+		for i := range v.NumField() {
+			field := v.Type().Field(i)
+			key := prefix + field.Tag.Get("config")
+			if field.Type.Kind() == reflect.Struct {
+				walk(v.Field(i), key+".")
+				continue
+			}
+			settings = append(settings, setting{key: key, env: field.Tag.Get("env"), help: field.Tag.Get("usage"), value: v.Field(i)})
+		}
+	}
+	walk(reflect.ValueOf(cfg).Elem(), "")
+	return settings
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// set parses raw into the setting.
+func (s setting) set(raw string) error {
+	if unmarshaler, ok := s.value.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		if err := unmarshaler.UnmarshalText([]byte(raw)); err != nil {
+			return fmt.Errorf("%q is invalid: %w", raw, err)
+		}
+		return nil
+	}
+	switch {
+	case s.value.Type() == durationType:
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("%q is not a duration, such as 30s or 5m", raw)
+		}
+		s.value.SetInt(int64(d))
+	case s.value.Kind() == reflect.String:
+		s.value.SetString(raw)
+	case s.value.Kind() == reflect.Int:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return fmt.Errorf("%q is not an integer", raw)
+		}
+		s.value.SetInt(int64(n))
+	case s.value.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("%q is not a boolean", raw)
+		}
+		s.value.SetBool(b)
+	default:
+		panic("config: unsupported setting type " + s.value.Type().String())
+	}
+	return nil
+}
+
+// flagValue is a setting given on the command line.
+type flagValue struct {
+	setting setting
+	raw     string
+}
+
+// settingFlag is the flag.Value of a setting, recording the values it is given.
+type settingFlag struct {
+	setting setting
+	values  *[]flagValue
+}
+
+func (f *settingFlag) String() string { return "" }
+
+func (f *settingFlag) Set(raw string) error {
+	*f.values = append(*f.values, flagValue{f.setting, raw})
+	return nil
+}
+
+// IsBoolFlag lets boolean settings be turned on by their flag alone.
+func (f *settingFlag) IsBoolFlag() bool { return f.setting.value.Kind() == reflect.Bool }
+
+// emptyDefaults are the default values not worth showing in the flag help.
+var emptyDefaults = map[string]bool{"": true, "0": true, "0s": true, "false": true}
+
+// usage describes the setting for the flag help, with its default and
+// environment variable.
+func (s setting) usage() string {
+	usage := s.help
+	if def := fmt.Sprint(s.value.Interface()); !emptyDefaults[def] {
+		usage += fmt.Sprintf(" (default %s)", def)
+	}
+	if s.env != "" {
+		usage += fmt.Sprintf(" (env %s)", s.env)
+	}
+	return usage
+}
@@ -0,0 +1,79 @@
+package infrastructure
+
+import (
+	"context"
+
+	"github.com/witchakornb/basic-ecommerce/domain/entity"
+	"github.com/witchakornb/basic-ecommerce/domain/repository"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GormCartRepository is a GORM implementation of the CartRepository interface.
+type GormCartRepository struct {
+	db *gorm.DB
+}
+
+// NewGormCartRepository creates a new GormCartRepository instance.
+func NewGormCartRepository(db *gorm.DB) repository.CartRepository {
+	return &GormCartRepository{db: db}
+}
+
+// CreateCart creates a new cart in the database.
+func (r *GormCartRepository) CreateCart(ctx context.Context, cart entity.Cart) (entity.Cart, error) {
+	err := r.db.WithContext(ctx).Create(&cart).Error
+	if err != nil {
+		return entity.Cart{}, translateError(err, "cart")
+	}
+	return cart, nil
+}
+
+// GetCartByUserID retrieves a user's cart and its items from the database.
+func (r *GormCartRepository) GetCartByUserID(ctx context.Context, userID int) (entity.Cart, error) {
+	var cart entity.Cart
+	err := r.db.WithContext(ctx).Preload("Items", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id")
+	}).Where("user_id = ?", userID).First(&cart).Error
+	if err != nil {
+		return entity.Cart{}, translateError(err, "cart")
+	}
+	return cart, nil
+}
+
+// SaveCartItem updates a stored cart item, or inserts a new one and replaces
+// the quantity and unit price of an existing item for the same product.
+func (r *GormCartRepository) SaveCartItem(ctx context.Context, item entity.CartItem) (entity.CartItem, error) {
+	if item.ID != 0 {
+		err := r.db.WithContext(ctx).Save(&item).Error
+		if err != nil {
+			return entity.CartItem{}, translateError(err, "cart_item")
+		}
+		return item, nil
+	}
+
+	err := r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "cart_id"}, {Name: "product_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"quantity", "unit_price_amount", "unit_price_currency"}),
+	}).Create(&item).Error
+	if err != nil {
+		return entity.CartItem{}, translateError(err, "cart_item")
+	}
+	// Only some databases return the ID of the row an upsert updated, so the
+	// item is read back to report the stored row on every dialect
+	var saved entity.CartItem
+	err = r.db.WithContext(ctx).Where("cart_id = ? AND product_id = ?", item.CartID, item.ProductID).First(&saved).Error
+	if err != nil {
+		return entity.CartItem{}, translateError(err, "cart_item")
+	}
+	return saved, nil
+}
+
+// DeleteCartItem removes a product from a cart in the database.
+func (r *GormCartRepository) DeleteCartItem(ctx context.Context, cartID, productID int) error {
+	return r.db.WithContext(ctx).Where("cart_id = ? AND product_id = ?", cartID, productID).Delete(&entity.CartItem{}).Error
+}
+
+// ClearCart removes every item from a cart in the database.
+func (r *GormCartRepository) ClearCart(ctx context.Context, cartID int) error {
+	return r.db.WithContext(ctx).Where("cart_id = ?", cartID).Delete(&entity.CartItem{}).Error
+}
@@ -0,0 +1,22 @@
+package infrastructure
+
+import (
+	"errors"
+
+	"github.com/witchakornb/basic-ecommerce/domain/errs"
+	"gorm.io/gorm"
+)
+
+// translateError maps GORM errors to domain errors: a missing record becomes
+// errs.NotFound(resource) and a unique constraint violation a conflict.
+// Duplicate keys are only recognized when the database is opened with
+// gorm.Config.TranslateError. Other errors are returned unchanged.
+func translateError(err error, resource string) error {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return errs.NotFound(resource)
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		return errs.New(errs.ErrConflict, resource+"_exists", resource+" already exists")
+	}
+	return err
+}
@@ -0,0 +1,25 @@
+package infrastructure
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// PingCheck returns a health check that reads the schema table, which fails
+// when no connection can be made, the database is locked or it is not the
+// migrated database; a bare SELECT 1 would not even open a SQLite file.
+func PingCheck(db *gorm.DB) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		var one int
+		return db.WithContext(ctx).Raw("SELECT 1 FROM schema_migrations LIMIT 1").Scan(&one).Error
+	}
+}
+
+// SchemaCheck returns a health check that fails while migrations are pending.
+func SchemaCheck(m *Migrator) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		scoped := &Migrator{db: m.db.WithContext(ctx), migrations: m.migrations}
+		return scoped.EnsureCurrent()
+	}
+}
@@ -0,0 +1,83 @@
+package infrastructure
+
+import (
+	"context"
+	"time"
+
+	"github.com/witchakornb/basic-ecommerce/domain/entity"
+	"github.com/witchakornb/basic-ecommerce/domain/repository"
+	"gorm.io/gorm"
+)
+
+// GormInventoryReservationRepository is a GORM implementation of the InventoryReservationRepository interface.
+type GormInventoryReservationRepository struct {
+	db *gorm.DB
+}
+
+// NewGormInventoryReservationRepository creates a new GormInventoryReservationRepository instance.
+func NewGormInventoryReservationRepository(db *gorm.DB) repository.InventoryReservationRepository {
+	return &GormInventoryReservationRepository{db: db}
+}
+
+// CreateReservation stores a new reservation in the database.
+func (r *GormInventoryReservationRepository) CreateReservation(ctx context.Context, reservation entity.InventoryReservation) (entity.InventoryReservation, error) {
+	err := r.db.WithContext(ctx).Create(&reservation).Error
+	if err != nil {
+		return entity.InventoryReservation{}, translateError(err, "reservation")
+	}
+	return reservation, nil
+}
+
+// GetOrderReservations retrieves the reservations of an order from the database.
+func (r *GormInventoryReservationRepository) GetOrderReservations(ctx context.Context, orderID int) ([]entity.InventoryReservation, error) {
+	reservations := []entity.InventoryReservation{}
+	err := r.db.WithContext(ctx).Where("order_id = ?", orderID).Order("id").Find(&reservations).Error
+	if err != nil {
+		return nil, err
+	}
+	return reservations, nil
+}
+
+// GetExpiredReservations retrieves the held reservations that expired at or before now.
+func (r *GormInventoryReservationRepository) GetExpiredReservations(ctx context.Context, now time.Time, limit int) ([]entity.InventoryReservation, error) {
+	reservations := []entity.InventoryReservation{}
+	err := r.db.WithContext(ctx).
+		Where("status = ? AND expires_at <= ?", entity.ReservationHeld, now).
+		Order("expires_at, id").Limit(limit).
+		Find(&reservations).Error
+	if err != nil {
+		return nil, err
+	}
+	return reservations, nil
+}
+
+// CommitReservation marks a held reservation as committed.
+func (r *GormInventoryReservationRepository) CommitReservation(ctx context.Context, id int) error {
+	return r.settle(ctx, id, entity.ReservationCommitted)
+}
+
+// ReleaseReservation marks a held reservation as released.
+func (r *GormInventoryReservationRepository) ReleaseReservation(ctx context.Context, id int) error {
+	return r.settle(ctx, id, entity.ReservationReleased)
+}
+
+// settle moves a held reservation to status. The condition on the current
+// status makes a concurrent transaction that settles the same reservation
+// wait for this one and then find nothing left to update.
+func (r *GormInventoryReservationRepository) settle(ctx context.Context, id int, status entity.ReservationStatus) error {
+	result := r.db.WithContext(ctx).Model(&entity.InventoryReservation{}).
+		Where("id = ? AND status = ?", id, entity.ReservationHeld).
+		Update("status", status)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		// Either the reservation does not exist or it is already settled
+		var reservation entity.InventoryReservation
+		if err := r.db.WithContext(ctx).First(&reservation, id).Error; err != nil {
+			return translateError(err, "reservation")
+		}
+		return repository.ErrReservationNotHeld
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package infrastructure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// slogLogger is a GORM logger writing to slog. Statements are logged at
+// debug level, slow ones as warnings and failed ones as errors, always
+// without their bound values, which may hold password hashes or tokens.
+// A missing record or a duplicate key is an answer rather than a failure,
+// so those statements are not logged as errors.
+type slogLogger struct {
+	logger        *slog.Logger
+	slowThreshold time.Duration
+}
+
+// NewSlogLogger returns a GORM logger writing to logger. Statements taking
+// longer than slowThreshold are logged as warnings; 0 disables this.
+func NewSlogLogger(logger *slog.Logger, slowThreshold time.Duration) logger.Interface {
+	return &slogLogger{logger: logger, slowThreshold: slowThreshold}
+}
+
+// LogMode is a no-op: the level is the slog logger's.
+func (l *slogLogger) LogMode(logger.LogLevel) logger.Interface {
+	return l
+}
+
+func (l *slogLogger) Info(ctx context.Context, msg string, args ...any) {
+	l.logger.InfoContext(ctx, fmt.Sprintf(msg, args...))
+}
+
+func (l *slogLogger) Warn(ctx context.Context, msg string, args ...any) {
+	l.logger.WarnContext(ctx, fmt.Sprintf(msg, args...))
+}
+
+func (l *slogLogger) Error(ctx context.Context, msg string, args ...any) {
+	l.logger.ErrorContext(ctx, fmt.Sprintf(msg, args...))
+}
+
+// Trace logs a statement after it ran.
+func (l *slogLogger) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
+	elapsed := time.Since(begin)
+	level, msg := slog.LevelDebug, "sql statement"
+	switch {
+	case err != nil && ctx.Err() != nil:
+		// The request was cancelled or timed out, which it already logs
+		level, msg = slog.LevelDebug, "sql statement cancelled"
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound) && !errors.Is(err, gorm.ErrDuplicatedKey):
+		level, msg = slog.LevelError, "sql statement failed"
+	case l.slowThreshold > 0 && elapsed > l.slowThreshold:
+		level, msg = slog.LevelWarn, "slow sql statement"
+	}
+	if !l.logger.Enabled(ctx, level) {
+		return
+	}
+
+	sql, rows := fc()
+	attrs := []slog.Attr{
+		slog.String("sql", sql),
+		slog.Int64("rows", rows),
+		slog.Float64("duration_ms", float64(elapsed.Microseconds())/1000),
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+	l.logger.LogAttrs(ctx, level, msg, attrs...)
+}
+
+// ParamsFilter drops the bound values, so statements are logged with placeholders.
+func (l *slogLogger) ParamsFilter(ctx context.Context, sql string, params ...any) (string, []any) {
+	return sql, nil
+}
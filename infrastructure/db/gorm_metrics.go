@@ -0,0 +1,69 @@
+package infrastructure
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Metrics receives the measurements of the database layer.
+type Metrics interface {
+	// ObserveQuery records an SQL statement of the operation, such as
+	// "query" or "create", that ran for the duration.
+	ObserveQuery(operation string, duration time.Duration, failed bool)
+	// ObserveTransaction records a unit of work that was committed or rolled back.
+	ObserveTransaction(committed bool)
+}
+
+// queryStartKey is the statement setting holding the time a statement started.
+const queryStartKey = "metrics:query_start"
+
+// metricsPlugin is a GORM plugin timing every statement.
+type metricsPlugin struct {
+	metrics Metrics
+}
+
+// NewMetricsPlugin returns a GORM plugin that reports the duration of every
+// statement to metrics. Install it with db.Use.
+func NewMetricsPlugin(metrics Metrics) gorm.Plugin {
+	return &metricsPlugin{metrics: metrics}
+}
+
+func (p *metricsPlugin) Name() string {
+	return "metrics"
+}
+
+// Initialize times the statements of each GORM callback chain, from before
+// its first callback to after its last one.
+func (p *metricsPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	return errors.Join(
+		cb.Create().Before("*").Register("metrics:before_create", p.start),
+		cb.Create().After("*").Register("metrics:after_create", p.finish("create")),
+		cb.Query().Before("*").Register("metrics:before_query", p.start),
+		cb.Query().After("*").Register("metrics:after_query", p.finish("query")),
+		cb.Update().Before("*").Register("metrics:before_update", p.start),
+		cb.Update().After("*").Register("metrics:after_update", p.finish("update")),
+		cb.Delete().Before("*").Register("metrics:before_delete", p.start),
+		cb.Delete().After("*").Register("metrics:after_delete", p.finish("delete")),
+		cb.Row().Before("*").Register("metrics:before_row", p.start),
+		cb.Row().After("*").Register("metrics:after_row", p.finish("row")),
+		cb.Raw().Before("*").Register("metrics:before_raw", p.start),
+		cb.Raw().After("*").Register("metrics:after_raw", p.finish("raw")),
+	)
+}
+
+func (p *metricsPlugin) start(db *gorm.DB) {
+	db.InstanceSet(queryStartKey, time.Now())
+}
+
+func (p *metricsPlugin) finish(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		start, ok := db.InstanceGet(queryStartKey)
+		if !ok {
+			return
+		}
+		p.metrics.ObserveQuery(operation, time.Since(start.(time.Time)), db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound))
+	}
+}
@@ -0,0 +1,81 @@
+package infrastructure
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/witchakornb/basic-ecommerce/domain/entity"
+	"github.com/witchakornb/basic-ecommerce/domain/repository"
+)
+
+// recordedMetrics counts what the database layer reports.
+type recordedMetrics struct {
+	mu        sync.Mutex
+	queries   map[string]int
+	failed    int
+	commits   int
+	rollbacks int
+}
+
+func (m *recordedMetrics) ObserveQuery(operation string, duration time.Duration, failed bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.queries[operation]++
+	if failed {
+		m.failed++
+	}
+}
+
+func (m *recordedMetrics) ObserveTransaction(committed bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if committed {
+		m.commits++
+	} else {
+		m.rollbacks++
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	db := openTestDB(t)
+	metrics := &recordedMetrics{queries: make(map[string]int)}
+	if err := db.Use(NewMetricsPlugin(metrics)); err != nil {
+		t.Fatalf("Use: %v", err)
+	}
+	uow := NewGormUnitOfWork(db, metrics)
+
+	err := uow.Execute(t.Context(), func(ctx context.Context, store repository.UnitOfWorkStore) error {
+		product, err := store.Products().CreateProduct(ctx, entity.Product{Name: "Mug", Price: entity.Money{Amount: 1000, Currency: "USD"}, Stock: 1})
+		if err != nil {
+			return err
+		}
+		_, err = store.Products().GetProductByID(ctx, product.ID)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	errAbort := errors.New("abort")
+	err = uow.Execute(t.Context(), func(ctx context.Context, store repository.UnitOfWorkStore) error {
+		if _, err := store.Products().GetProductByID(ctx, 999); err == nil {
+			t.Error("GetProductByID of a missing product succeeded")
+		}
+		return errAbort
+	})
+	if !errors.Is(err, errAbort) {
+		t.Fatalf("Execute returned %v, want the error of fn", err)
+	}
+
+	if metrics.commits != 1 || metrics.rollbacks != 1 {
+		t.Errorf("recorded %d commits and %d rollbacks, want 1 and 1", metrics.commits, metrics.rollbacks)
+	}
+	if metrics.queries["create"] != 1 || metrics.queries["query"] != 2 {
+		t.Errorf("recorded the statements %v, want 1 create and 2 queries", metrics.queries)
+	}
+	if metrics.failed != 0 {
+		t.Errorf("recorded %d failed statements, want 0: a missing record is not a failure", metrics.failed)
+	}
+}
@@ -0,0 +1,281 @@
+package infrastructure
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// schemaMigrations is the history of the database schema, oldest first.
+// Applied migrations must never be edited; change the schema by appending
+// a new migration with the next version.
+var schemaMigrations = []Migration{
+	{
+		Version: 1,
+		Name:    "create_initial_schema",
+		Up: func(tx *gorm.DB) error {
+			// AutoMigrate rather than CreateTable, so that databases created
+			// by the AutoMigrate call that used to run at startup are adopted
+			return tx.AutoMigrate(v1Tables...)
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(reversed(v1Tables)...)
+		},
+	},
+	{
+		Version: 2,
+		Name:    "clear_legacy_string_timestamps",
+		Up: func(tx *gorm.DB) error {
+			// Timestamps used to be strings that GORM never set, so SQLite
+			// databases from that time hold '' in them. An empty deleted_at
+			// would count as deleted, so they are cleared or backfilled.
+			if tx.Dialector.Name() != "sqlite" {
+				return nil
+			}
+			for _, table := range []string{"users", "products", "orders"} {
+				statements := []string{
+					"UPDATE " + table + " SET deleted_at = NULL WHERE deleted_at = ''",
+					"UPDATE " + table + " SET created_at = CURRENT_TIMESTAMP WHERE created_at = '' OR created_at IS NULL",
+					"UPDATE " + table + " SET updated_at = created_at WHERE updated_at = '' OR updated_at IS NULL",
+				}
+				for _, statement := range statements {
+					if err := tx.Exec(statement).Error; err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		},
+		Down: func(tx *gorm.DB) error {
+			// Nothing to revert: the cleared values carried no information
+			return nil
+		},
+	},
+	{
+		Version: 3,
+		Name:    "create_inventory_reservations",
+		Up: func(tx *gorm.DB) error {
+			return tx.Migrator().CreateTable(&v3InventoryReservation{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&v3InventoryReservation{})
+		},
+	},
+	{
+		Version: 4,
+		Name:    "create_product_search_index",
+		Up: func(tx *gorm.DB) error {
+			// Search is SQLite only, and needs a build with FTS5; without it
+			// there is no index and search stays unavailable
+			if tx.Dialector.Name() != "sqlite" {
+				return nil
+			}
+			if fts5, err := hasFTS5(tx); err != nil || !fts5 {
+				return err
+			}
+			// IF NOT EXISTS adopts the index that used to be created at
+			// startup; the rebuild indexes the products stored before it
+			return execAll(tx, v4ProductSearchIndex...)
+		},
+		Down: func(tx *gorm.DB) error {
+			if tx.Dialector.Name() != "sqlite" {
+				return nil
+			}
+			return execAll(tx,
+				"DROP TRIGGER IF EXISTS products_fts_update",
+				"DROP TRIGGER IF EXISTS products_fts_delete",
+				"DROP TRIGGER IF EXISTS products_fts_insert",
+				"DROP TABLE IF EXISTS products_fts",
+			)
+		},
+	},
+	{
+		Version: 5,
+		Name:    "convert_legacy_float_prices",
+		Up: func(tx *gorm.DB) error {
+			// Prices used to be float columns, which migration 1 left in
+			// place next to the money columns it added, so the products
+			// and orders of that time read as free. Those databases were
+			// always SQLite.
+			if tx.Dialector.Name() != "sqlite" {
+				return nil
+			}
+			if tx.Migrator().HasColumn("products", "price") {
+				err := execAll(tx, `UPDATE products SET price_amount = CAST(ROUND(price * 100) AS INTEGER), price_currency = '`+legacyPriceCurrency+`'
+					WHERE price IS NOT NULL AND (price_currency IS NULL OR price_currency = '')`)
+				if err != nil {
+					return err
+				}
+			}
+			if !tx.Migrator().HasColumn("orders", "total_price") {
+				return nil
+			}
+			// Orders had a single product, whose line becomes the order's item
+			return execAll(tx,
+				`UPDATE orders SET total_price_amount = CAST(ROUND(total_price * 100) AS INTEGER), total_price_currency = '`+legacyPriceCurrency+`'
+					WHERE total_price IS NOT NULL AND (total_price_currency IS NULL OR total_price_currency = '')`,
+				`INSERT INTO order_items (order_id, product_id, product_name, unit_price_amount, unit_price_currency, quantity, subtotal_amount, subtotal_currency)
+					SELECT o.id, o.product_id, COALESCE(p.name, ''),
+						CASE WHEN o.quantity > 0 THEN o.total_price_amount / o.quantity ELSE o.total_price_amount END,
+						o.total_price_currency, o.quantity, o.total_price_amount, o.total_price_currency
+					FROM orders o LEFT JOIN products p ON p.id = o.product_id
+					WHERE o.product_id IS NOT NULL AND NOT EXISTS (SELECT 1 FROM order_items i WHERE i.order_id = o.id)`,
+			)
+		},
+		Down: func(tx *gorm.DB) error {
+			// Nothing to revert: the float columns are left untouched
+			return nil
+		},
+	},
+}
+
+// legacyPriceCurrency is the currency of the prices stored as floats, which
+// had none.
+const legacyPriceCurrency = "USD"
+
+// execAll runs the statements in order and stops at the first that fails.
+func execAll(tx *gorm.DB, statements ...string) error {
+	for _, statement := range statements {
+		if err := tx.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reversed returns the tables in reverse order, so that tables are dropped
+// before the tables they reference.
+func reversed(tables []any) []any {
+	out := make([]any, len(tables))
+	for i, table := range tables {
+		out[len(tables)-1-i] = table
+	}
+	return out
+}
+
+// v1Tables is the schema of version 1, as the entities defined it then.
+var v1Tables = []any{&v1User{}, &v1RefreshToken{}, &v1Product{}, &v1Order{}, &v1OrderItem{}, &v1Cart{}, &v1CartItem{}}
+
+type v1User struct {
+	ID        int
+	Username  string
+	Email     string `gorm:"size:255;uniqueIndex"`
+	Password  string
+	Role      string `gorm:"size:20;default:customer"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+}
+
+func (v1User) TableName() string { return "users" }
+
+type v1RefreshToken struct {
+	ID        int
+	UserID    int    `gorm:"index"`
+	TokenHash string `gorm:"size:64;uniqueIndex"`
+	ExpiresAt time.Time
+	RevokedAt *time.Time
+	CreatedAt time.Time
+}
+
+func (v1RefreshToken) TableName() string { return "refresh_tokens" }
+
+type v1Product struct {
+	ID            int
+	Name          string
+	Description   string
+	PriceAmount   int64
+	PriceCurrency string `gorm:"size:3"`
+	Stock         int
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	DeletedAt     gorm.DeletedAt `gorm:"index"`
+}
+
+func (v1Product) TableName() string { return "products" }
+
+type v1Order struct {
+	ID                 int
+	CustomerID         int           `gorm:"index"`
+	Status             string        `gorm:"size:20;default:pending;index"`
+	Items              []v1OrderItem `gorm:"foreignKey:OrderID"`
+	TotalPriceAmount   int64
+	TotalPriceCurrency string `gorm:"size:3"`
+	CreatedAt          time.Time
+	UpdatedAt          time.Time
+	DeletedAt          gorm.DeletedAt `gorm:"index"`
+}
+
+func (v1Order) TableName() string { return "orders" }
+
+type v1OrderItem struct {
+	ID                int
+	OrderID           int `gorm:"index"`
+	ProductID         int
+	ProductName       string
+	UnitPriceAmount   int64
+	UnitPriceCurrency string `gorm:"size:3"`
+	Quantity          int
+	SubtotalAmount    int64
+	SubtotalCurrency  string `gorm:"size:3"`
+}
+
+func (v1OrderItem) TableName() string { return "order_items" }
+
+type v1Cart struct {
+	ID        int
+	UserID    int          `gorm:"uniqueIndex"`
+	Items     []v1CartItem `gorm:"foreignKey:CartID"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (v1Cart) TableName() string { return "carts" }
+
+type v1CartItem struct {
+	ID                int
+	CartID            int `gorm:"uniqueIndex:idx_cart_items_cart_product"`
+	ProductID         int `gorm:"uniqueIndex:idx_cart_items_cart_product"`
+	Quantity          int
+	UnitPriceAmount   int64
+	UnitPriceCurrency string `gorm:"size:3"`
+}
+
+func (v1CartItem) TableName() string { return "cart_items" }
+
+// v4ProductSearchIndex creates an FTS5 index over the name and description
+// of products. The index is external content backed by the products table
+// and kept in sync by triggers, so every insert, update and delete made
+// through GormProductRepository is reflected without extra writes.
+var v4ProductSearchIndex = []string{
+	`CREATE VIRTUAL TABLE IF NOT EXISTS products_fts USING fts5(
+		name, description,
+		content='products', content_rowid='id',
+		tokenize='unicode61 remove_diacritics 2', prefix='2 3'
+	)`,
+	`CREATE TRIGGER IF NOT EXISTS products_fts_insert AFTER INSERT ON products BEGIN
+		INSERT INTO products_fts(rowid, name, description) VALUES (new.id, new.name, new.description);
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS products_fts_delete AFTER DELETE ON products BEGIN
+		INSERT INTO products_fts(products_fts, rowid, name, description) VALUES ('delete', old.id, old.name, old.description);
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS products_fts_update AFTER UPDATE OF name, description ON products BEGIN
+		INSERT INTO products_fts(products_fts, rowid, name, description) VALUES ('delete', old.id, old.name, old.description);
+		INSERT INTO products_fts(rowid, name, description) VALUES (new.id, new.name, new.description);
+	END`,
+	`INSERT INTO products_fts(products_fts) VALUES ('rebuild')`,
+}
+
+// v3InventoryReservation is the inventory_reservations table of version 3.
+type v3InventoryReservation struct {
+	ID        int
+	OrderID   int `gorm:"index"`
+	ProductID int
+	Quantity  int
+	Status    string    `gorm:"size:20;default:held;index:idx_inventory_reservations_status_expires_at"`
+	ExpiresAt time.Time `gorm:"index:idx_inventory_reservations_status_expires_at"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (v3InventoryReservation) TableName() string { return "inventory_reservations" }
@@ -0,0 +1,214 @@
+package infrastructure
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrSchemaOutdated is returned by Migrator.EnsureCurrent when the database
+// has not been migrated to the latest version.
+var ErrSchemaOutdated = errors.New("database schema is not up to date")
+
+// Migration is one versioned change of the database schema. Up applies it
+// and Down reverts it; both run inside a transaction together with the
+// update of the schema table. MySQL commits schema changes implicitly, so
+// there a failed migration can be left half applied. Migrations must not
+// use the entity types, which keep changing, but a snapshot of the tables
+// as of the migration.
+type Migration struct {
+	Version int
+	Name    string
+	Up      func(tx *gorm.DB) error
+	Down    func(tx *gorm.DB) error
+}
+
+// schemaMigration is a row of the schema table, recording one applied migration.
+type schemaMigration struct {
+	Version   int `gorm:"primaryKey;autoIncrement:false"`
+	Name      string
+	AppliedAt time.Time
+}
+
+func (schemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// MigrationStatus tells whether a migration has been applied, and when.
+type MigrationStatus struct {
+	Migration
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// Migrator applies and reverts migrations, recording the applied versions
+// in the schema_migrations table.
+type Migrator struct {
+	db         *gorm.DB
+	migrations []Migration
+}
+
+// NewMigrator returns a Migrator for the migrations, which must have
+// distinct positive versions. They are applied in order of version.
+func NewMigrator(db *gorm.DB, migrations []Migration) (*Migrator, error) {
+	sorted := slices.Clone(migrations)
+	slices.SortFunc(sorted, func(a, b Migration) int { return a.Version - b.Version })
+	for i, migration := range sorted {
+		if migration.Version <= 0 {
+			return nil, fmt.Errorf("migration %q: version must be positive", migration.Name)
+		}
+		if i > 0 && sorted[i-1].Version == migration.Version {
+			return nil, fmt.Errorf("migrations %q and %q have the same version %d", sorted[i-1].Name, migration.Name, migration.Version)
+		}
+		if migration.Up == nil || migration.Down == nil {
+			return nil, fmt.Errorf("migration %d %q: both Up and Down are required", migration.Version, migration.Name)
+		}
+	}
+	return &Migrator{db: db, migrations: sorted}, nil
+}
+
+// NewSchemaMigrator returns a Migrator for the schema of this application.
+func NewSchemaMigrator(db *gorm.DB) (*Migrator, error) {
+	return NewMigrator(db, schemaMigrations)
+}
+
+// Latest returns the version of the newest migration, or 0 if there are none.
+func (m *Migrator) Latest() int {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Status lists every migration and whether it has been applied.
+func (m *Migrator) Status() ([]MigrationStatus, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+	statuses := make([]MigrationStatus, len(m.migrations))
+	for i, migration := range m.migrations {
+		row, ok := applied[migration.Version]
+		statuses[i] = MigrationStatus{Migration: migration, Applied: ok, AppliedAt: row.AppliedAt}
+	}
+	return statuses, nil
+}
+
+// EnsureCurrent returns an error wrapping ErrSchemaOutdated unless every
+// migration has been applied.
+func (m *Migrator) EnsureCurrent() error {
+	statuses, err := m.Status()
+	if err != nil {
+		return err
+	}
+	var pending int
+	for _, status := range statuses {
+		if !status.Applied {
+			pending++
+		}
+	}
+	if pending > 0 {
+		return fmt.Errorf("%w: %d of %d migrations are pending", ErrSchemaOutdated, pending, len(statuses))
+	}
+	return nil
+}
+
+// Up applies every pending migration and returns the migrations applied.
+func (m *Migrator) Up() ([]Migration, error) {
+	return m.To(m.Latest())
+}
+
+// Down reverts the most recently applied migration, if any, and returns it.
+func (m *Migrator) Down() ([]Migration, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+	var previous, current int
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; ok {
+			previous, current = current, migration.Version
+		}
+	}
+	if current == 0 {
+		return nil, nil
+	}
+	return m.To(previous)
+}
+
+// To migrates the database to the version: migrations up to and including
+// it are applied in order, later ones are reverted newest first. Version 0
+// reverts everything. It returns the migrations applied or reverted; on
+// error, those that completed before it stay in effect.
+func (m *Migrator) To(version int) ([]Migration, error) {
+	if version != 0 && !slices.ContainsFunc(m.migrations, func(migration Migration) bool { return migration.Version == version }) {
+		return nil, fmt.Errorf("unknown migration version %d", version)
+	}
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		migration := m.migrations[i]
+		if _, ok := applied[migration.Version]; !ok || migration.Version <= version {
+			continue
+		}
+		if err := m.run(migration, migration.Down, func(tx *gorm.DB) error {
+			return tx.Delete(&schemaMigration{Version: migration.Version}).Error
+		}); err != nil {
+			return done, err
+		}
+		done = append(done, migration)
+	}
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; ok || migration.Version > version {
+			continue
+		}
+		if err := m.run(migration, migration.Up, func(tx *gorm.DB) error {
+			return tx.Create(&schemaMigration{Version: migration.Version, Name: migration.Name, AppliedAt: time.Now().UTC()}).Error
+		}); err != nil {
+			return done, err
+		}
+		done = append(done, migration)
+	}
+	return done, nil
+}
+
+// run executes one direction of a migration and records it in the schema
+// table in a single transaction.
+func (m *Migrator) run(migration Migration, change, record func(tx *gorm.DB) error) error {
+	err := m.db.Transaction(func(tx *gorm.DB) error {
+		if err := change(tx); err != nil {
+			return err
+		}
+		return record(tx)
+	})
+	if err != nil {
+		return fmt.Errorf("migration %d %s: %w", migration.Version, migration.Name, err)
+	}
+	return nil
+}
+
+// applied returns the rows of the schema table by version, creating the
+// table if it does not exist yet.
+func (m *Migrator) applied() (map[int]schemaMigration, error) {
+	if !m.db.Migrator().HasTable(&schemaMigration{}) {
+		if err := m.db.Migrator().CreateTable(&schemaMigration{}); err != nil {
+			return nil, fmt.Errorf("create schema table: %w", err)
+		}
+	}
+	var rows []schemaMigration
+	if err := m.db.Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	applied := make(map[int]schemaMigration, len(rows))
+	for _, row := range rows {
+		applied[row.Version] = row
+	}
+	return applied, nil
+}
@@ -0,0 +1,201 @@
+package infrastructure
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/witchakornb/basic-ecommerce/domain/entity"
+	"gorm.io/gorm"
+)
+
+func TestSchemaMigrations(t *testing.T) {
+	db := openEmptyTestDB(t)
+	migrator, err := NewSchemaMigrator(db)
+	if err != nil {
+		t.Fatalf("NewSchemaMigrator: %v", err)
+	}
+	if err := migrator.EnsureCurrent(); !errors.Is(err, ErrSchemaOutdated) {
+		t.Fatalf("EnsureCurrent of an empty database returned %v, want ErrSchemaOutdated", err)
+	}
+
+	done, err := migrator.Up()
+	if err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+	if len(done) != len(schemaMigrations) {
+		t.Fatalf("Up applied %d migrations, want %d", len(done), len(schemaMigrations))
+	}
+	if err := migrator.EnsureCurrent(); err != nil {
+		t.Fatalf("EnsureCurrent after Up: %v", err)
+	}
+	if err := SchemaCheck(migrator)(context.Background()); err != nil {
+		t.Fatalf("SchemaCheck after Up: %v", err)
+	}
+	if done, err := migrator.Up(); err != nil || len(done) != 0 {
+		t.Fatalf("second Up applied %v, %v; want nothing", done, err)
+	}
+
+	done, err = migrator.Down()
+	if err != nil || len(done) != 1 || done[0].Version != migrator.Latest() {
+		t.Fatalf("Down reverted %v, %v; want the latest migration", done, err)
+	}
+	if err := migrator.EnsureCurrent(); !errors.Is(err, ErrSchemaOutdated) {
+		t.Fatalf("EnsureCurrent after Down returned %v, want ErrSchemaOutdated", err)
+	}
+	if err := SchemaCheck(migrator)(context.Background()); !errors.Is(err, ErrSchemaOutdated) {
+		t.Fatalf("SchemaCheck after Down returned %v, want ErrSchemaOutdated", err)
+	}
+
+	if _, err := migrator.To(0); err != nil {
+		t.Fatalf("To(0): %v", err)
+	}
+	if db.Migrator().HasTable("users") {
+		t.Fatal("To(0) left the users table behind")
+	}
+	statuses, err := migrator.Status()
+	if err != nil {
+		t.Fatalf("Status: %v", err)
+	}
+	for _, status := range statuses {
+		if status.Applied {
+			t.Fatalf("migration %d is still applied after To(0)", status.Version)
+		}
+	}
+
+	if _, err := migrator.To(99); err == nil {
+		t.Fatal("To an unknown version succeeded")
+	}
+	if _, err := migrator.Up(); err != nil {
+		t.Fatalf("Up after To(0): %v", err)
+	}
+}
+
+// legacyUser is the users table as AutoMigrate created it while timestamps
+// were strings.
+type legacyUser struct {
+	ID        int
+	Username  string
+	Email     string `gorm:"uniqueIndex"`
+	Password  string
+	Role      string `gorm:"default:customer"`
+	CreatedAt string
+	UpdatedAt string
+	DeletedAt string
+}
+
+func (legacyUser) TableName() string { return "users" }
+
+func TestSchemaMigrationsAdoptLegacyDatabase(t *testing.T) {
+	db := openEmptyTestDB(t)
+	if err := db.AutoMigrate(&legacyUser{}); err != nil {
+		t.Fatalf("create legacy table: %v", err)
+	}
+	if err := db.Session(&gorm.Session{SkipHooks: true}).Create(&legacyUser{Username: "alice", Email: "alice@example.com", Role: "customer"}).Error; err != nil {
+		t.Fatalf("insert legacy user: %v", err)
+	}
+
+	migrator, err := NewSchemaMigrator(db)
+	if err != nil {
+		t.Fatalf("NewSchemaMigrator: %v", err)
+	}
+	if _, err := migrator.Up(); err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+
+	user, err := NewGormUserRepository(db).GetUserByEmail(t.Context(), "alice@example.com")
+	if err != nil {
+		t.Fatalf("GetUserByEmail of a legacy user: %v", err)
+	}
+	if user.CreatedAt.IsZero() || user.DeletedAt.Valid {
+		t.Fatalf("legacy user was migrated to %+v", user)
+	}
+}
+
+// baselineProduct and baselineOrder are the products and orders tables as
+// AutoMigrate created them while prices were floats and an order had a
+// single product.
+type baselineProduct struct {
+	ID          int
+	Name        string
+	Description string
+	Price       float64
+	Stock       int
+	CreatedAt   string
+	UpdatedAt   string
+	DeletedAt   string
+}
+
+func (baselineProduct) TableName() string { return "products" }
+
+type baselineOrder struct {
+	ID         int
+	CustomerID int
+	ProductID  int
+	Quantity   int
+	TotalPrice float64
+	CreatedAt  string
+	UpdatedAt  string
+	DeletedAt  string
+}
+
+func (baselineOrder) TableName() string { return "orders" }
+
+func TestSchemaMigrationsConvertLegacyPrices(t *testing.T) {
+	db := openEmptyTestDB(t)
+	if err := db.AutoMigrate(&legacyUser{}, &baselineProduct{}, &baselineOrder{}); err != nil {
+		t.Fatalf("create baseline tables: %v", err)
+	}
+	legacy := db.Session(&gorm.Session{SkipHooks: true})
+	if err := legacy.Create(&legacyUser{Username: "alice", Email: "alice@example.com", Role: "customer"}).Error; err != nil {
+		t.Fatalf("insert baseline user: %v", err)
+	}
+	if err := legacy.Create(&baselineProduct{Name: "Mug", Price: 19.99, Stock: 3}).Error; err != nil {
+		t.Fatalf("insert baseline product: %v", err)
+	}
+	if err := legacy.Create(&baselineOrder{CustomerID: 1, ProductID: 1, Quantity: 2, TotalPrice: 39.98}).Error; err != nil {
+		t.Fatalf("insert baseline order: %v", err)
+	}
+
+	migrator, err := NewSchemaMigrator(db)
+	if err != nil {
+		t.Fatalf("NewSchemaMigrator: %v", err)
+	}
+	if _, err := migrator.Up(); err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+
+	product, err := NewGormProductRepository(db).GetProductByID(t.Context(), 1)
+	if err != nil {
+		t.Fatalf("GetProductByID of a baseline product: %v", err)
+	}
+	if want := (entity.Money{Amount: 1999, Currency: "USD"}); product.Price != want || product.Stock != 3 {
+		t.Fatalf("baseline product was migrated to price %v and stock %d, want %v and 3", product.Price, product.Stock, want)
+	}
+
+	order, err := NewGormOrderRepository(db).GetOrderByID(t.Context(), 1)
+	if err != nil {
+		t.Fatalf("GetOrderByID of a baseline order: %v", err)
+	}
+	if want := (entity.Money{Amount: 3998, Currency: "USD"}); order.TotalPrice != want {
+		t.Fatalf("baseline order total = %v, want %v", order.TotalPrice, want)
+	}
+	wantItem := entity.OrderItem{
+		ProductID:   1,
+		ProductName: "Mug",
+		UnitPrice:   entity.Money{Amount: 1999, Currency: "USD"},
+		Quantity:    2,
+		Subtotal:    entity.Money{Amount: 3998, Currency: "USD"},
+	}
+	if len(order.Items) != 1 {
+		t.Fatalf("baseline order has items %+v, want one line", order.Items)
+	}
+	item := order.Items[0]
+	item.ID, item.OrderID = 0, 0
+	if item != wantItem {
+		t.Fatalf("baseline order item = %+v, want %+v", item, wantItem)
+	}
+	if order.Status != entity.OrderStatusPending {
+		t.Fatalf("baseline order status = %s, want %s", order.Status, entity.OrderStatusPending)
+	}
+}
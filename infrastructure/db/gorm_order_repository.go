@@ -1,6 +1,8 @@
 package infrastructure
 
 import (
+	"context"
+
 	"github.com/witchakornb/basic-ecommerce/domain/entity"
 	"github.com/witchakornb/basic-ecommerce/domain/repository"
 	"gorm.io/gorm"
@@ -16,41 +18,94 @@ func NewGormOrderRepository(db *gorm.DB) repository.OrderRepository {
 	return &GormOrderRepository{db: db}
 }
 
-// CreateOrder creates a new order in the database
-func (r *GormOrderRepository) CreateOrder(order entity.Order) (entity.Order, error) {
-	err := r.db.Create(&order).Error
+// CreateOrder creates a new order and its items in the database
+func (r *GormOrderRepository) CreateOrder(ctx context.Context, order entity.Order) (entity.Order, error) {
+	err := r.db.WithContext(ctx).Create(&order).Error
 	if err != nil {
-		return entity.Order{}, err
+		return entity.Order{}, translateError(err, "order")
 	}
 	return order, nil
 }
 
-// GetOrderByID retrieves an order by ID from the database
-func (r *GormOrderRepository) GetOrderByID(id int) (entity.Order, error) {
+// GetOrderByID retrieves an order and its items by ID from the database
+func (r *GormOrderRepository) GetOrderByID(ctx context.Context, id int) (entity.Order, error) {
 	var order entity.Order
-	err := r.db.First(&order, id).Error
+	err := r.db.WithContext(ctx).Preload("Items").First(&order, id).Error
 	if err != nil {
-		return entity.Order{}, err
+		return entity.Order{}, translateError(err, "order")
 	}
 	return order, nil
 }
 
-// GetAllOrders retrieves all orders from the database
-func (r *GormOrderRepository) GetAllOrders() ([]entity.Order, error) {
-	var orders []entity.Order
-	err := r.db.Find(&orders).Error
-	if err != nil {
-		return nil, err
+// orderSortFields are the keys orders can be sorted by.
+var orderSortFields = map[string]sortField[entity.Order]{
+	"id":          {column: "id", value: func(o entity.Order) any { return o.ID }},
+	"status":      {column: "status", value: func(o entity.Order) any { return o.Status }},
+	"total_price": {column: "total_price_amount", value: func(o entity.Order) any { return o.TotalPrice.Amount }},
+	"created_at":  {column: "created_at", value: func(o entity.Order) any { return o.CreatedAt }},
+}
+
+// GetAllOrders retrieves one page of the orders matching the filter, and their items, from the database
+func (r *GormOrderRepository) GetAllOrders(ctx context.Context, filter repository.OrderFilter, opts repository.ListOptions) (repository.Page[entity.Order], error) {
+	query := withDeleted(r.db.WithContext(ctx).Model(&entity.Order{}), filter.IncludeDeleted)
+	if filter.CustomerID != 0 {
+		query = query.Where("customer_id = ?", filter.CustomerID)
 	}
-	return orders, nil
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.CreatedFrom != nil {
+		query = query.Where("created_at >= ?", filter.CreatedFrom.UTC())
+	}
+	if filter.CreatedTo != nil {
+		query = query.Where("created_at <= ?", filter.CreatedTo.UTC())
+	}
+	return findPage(query, opts, orderSortFields, "Items")
 }
 
-// DeleteOrder deletes an order by ID from the database
-func (r *GormOrderRepository) DeleteOrder(id int) error {
-	var order entity.Order
-	err := r.db.Delete(&order, id).Error
-	if err != nil {
-		return err
+// UpdateOrderStatus moves an order from one status to another in the
+// database. The condition on the current status makes a concurrent
+// transaction moving the same order wait for this one and then find
+// nothing left to update.
+func (r *GormOrderRepository) UpdateOrderStatus(ctx context.Context, id int, from, to entity.OrderStatus) error {
+	result := r.db.WithContext(ctx).Model(&entity.Order{}).
+		Where("id = ? AND status = ?", id, from).
+		Update("status", to)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		// Either the order does not exist or its status has changed
+		if _, err := r.GetOrderByID(ctx, id); err != nil {
+			return err
+		}
+		return repository.ErrOrderStatusChanged
+	}
+	return nil
+}
+
+// DeleteOrder soft-deletes an order by ID in the database if it still has
+// the status; its items are kept. The condition serializes it with
+// UpdateOrderStatus the same way two status updates are serialized.
+func (r *GormOrderRepository) DeleteOrder(ctx context.Context, id int, status entity.OrderStatus) error {
+	result := r.db.WithContext(ctx).Where("status = ?", status).Delete(&entity.Order{}, id)
+	if result.Error != nil {
+		return translateError(result.Error, "order")
+	}
+	if result.RowsAffected == 0 {
+		// Either the order does not exist, is deleted or its status has changed
+		if _, err := r.GetOrderByID(ctx, id); err != nil {
+			return err
+		}
+		return repository.ErrOrderStatusChanged
 	}
 	return nil
 }
+
+// RestoreOrder undeletes a soft-deleted order in the database
+func (r *GormOrderRepository) RestoreOrder(ctx context.Context, id int) (entity.Order, error) {
+	if err := restore[entity.Order](r.db.WithContext(ctx), id, "order"); err != nil {
+		return entity.Order{}, err
+	}
+	return r.GetOrderByID(ctx, id)
+}
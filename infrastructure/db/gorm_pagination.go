@@ -0,0 +1,149 @@
+package infrastructure
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/witchakornb/basic-ecommerce/domain/repository"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// sortField maps a public sort key to its column and to the value of that
+// column on a loaded row, which is what cursors are built from.
+type sortField[T any] struct {
+	column string
+	value  func(T) any
+}
+
+// findPage loads one page of the rows matched by query. Sorting is always
+// completed with the primary key so that the order, and therefore every
+// cursor, is deterministic. Preloads are applied after counting.
+func findPage[T any](query *gorm.DB, opts repository.ListOptions, fields map[string]sortField[T], preloads ...string) (repository.Page[T], error) {
+	limit := opts.Limit
+	if limit == 0 {
+		limit = repository.DefaultListLimit
+	}
+	if limit < 0 || limit > repository.MaxListLimit || opts.Offset < 0 {
+		return repository.Page[T]{}, fmt.Errorf("%w: limit must be between 1 and %d", repository.ErrInvalidListOptions, repository.MaxListLimit)
+	}
+
+	keys, err := sortKeys(opts.Sort, fields)
+	if err != nil {
+		return repository.Page[T]{}, err
+	}
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return repository.Page[T]{}, err
+	}
+
+	page := query.Session(&gorm.Session{})
+	for _, key := range keys {
+		page = page.Order(clause.OrderByColumn{Column: clause.Column{Name: fields[key.Field].column}, Desc: key.Desc})
+	}
+	if opts.Cursor != "" {
+		values, err := decodeCursor(opts.Cursor, keys, fields)
+		if err != nil {
+			return repository.Page[T]{}, err
+		}
+		page = page.Where(keysetCondition(keys, fields, values))
+	} else {
+		page = page.Offset(opts.Offset)
+	}
+	for _, preload := range preloads {
+		page = page.Preload(preload)
+	}
+
+	var items []T
+	if err := page.Limit(limit + 1).Find(&items).Error; err != nil {
+		return repository.Page[T]{}, err
+	}
+
+	result := repository.Page[T]{Items: items, Total: total}
+	if len(items) > limit {
+		result.Items = items[:limit]
+		result.NextCursor, err = encodeCursor(result.Items[limit-1], keys, fields)
+		if err != nil {
+			return repository.Page[T]{}, err
+		}
+	}
+	return result, nil
+}
+
+// sortKeys validates the requested sort keys and appends the id tie-breaker.
+func sortKeys[T any](requested []repository.SortKey, fields map[string]sortField[T]) ([]repository.SortKey, error) {
+	keys := make([]repository.SortKey, 0, len(requested)+1)
+	hasID := false
+	for _, key := range requested {
+		if _, ok := fields[key.Field]; !ok {
+			return nil, fmt.Errorf("%w: unknown sort key %q", repository.ErrInvalidListOptions, key.Field)
+		}
+		hasID = hasID || key.Field == "id"
+		keys = append(keys, key)
+	}
+	if !hasID {
+		keys = append(keys, repository.SortKey{Field: "id"})
+	}
+	return keys, nil
+}
+
+// keysetCondition matches the rows that sort after the cursor values:
+// (k1 > v1) OR (k1 = v1 AND k2 > v2) OR ..., with < for descending keys.
+func keysetCondition[T any](keys []repository.SortKey, fields map[string]sortField[T], values []any) clause.Expression {
+	var or []clause.Expression
+	for i, key := range keys {
+		and := make([]clause.Expression, 0, i+1)
+		for j := 0; j < i; j++ {
+			and = append(and, clause.Eq{Column: clause.Column{Name: fields[keys[j].Field].column}, Value: values[j]})
+		}
+		column := clause.Column{Name: fields[key.Field].column}
+		if key.Desc {
+			and = append(and, clause.Lt{Column: column, Value: values[i]})
+		} else {
+			and = append(and, clause.Gt{Column: column, Value: values[i]})
+		}
+		or = append(or, clause.And(and...))
+	}
+	return clause.Or(or...)
+}
+
+// encodeCursor encodes the sort key values of the last row of a page.
+func encodeCursor[T any](last T, keys []repository.SortKey, fields map[string]sortField[T]) (string, error) {
+	values := make([]any, len(keys))
+	for i, key := range keys {
+		values[i] = fields[key.Field].value(last)
+	}
+	raw, err := json.Marshal(values)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+// decodeCursor decodes a cursor into values typed like the sort columns.
+func decodeCursor[T any](cursor string, keys []repository.SortKey, fields map[string]sortField[T]) ([]any, error) {
+	invalid := fmt.Errorf("%w: malformed cursor", repository.ErrInvalidListOptions)
+
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, invalid
+	}
+	var encoded []json.RawMessage
+	if err := json.Unmarshal(raw, &encoded); err != nil || len(encoded) != len(keys) {
+		return nil, invalid
+	}
+
+	var zero T
+	values := make([]any, len(keys))
+	for i, key := range keys {
+		value := reflect.New(reflect.TypeOf(fields[key.Field].value(zero)))
+		if err := json.Unmarshal(encoded[i], value.Interface()); err != nil {
+			return nil, invalid
+		}
+		values[i] = value.Elem().Interface()
+	}
+	return values, nil
+}
@@ -1,6 +1,8 @@
 package infrastructure
 
 import (
+	"context"
+
 	"github.com/witchakornb/basic-ecommerce/domain/entity"
 	"github.com/witchakornb/basic-ecommerce/domain/repository"
 	"gorm.io/gorm"
@@ -17,49 +19,102 @@ func NewGormProductRepository(db *gorm.DB) repository.ProductRepository {
 }
 
 // CreateProduct creates a new product in the database.
-func (r *GormProductRepository) CreateProduct(product entity.Product) (entity.Product, error) {
-	err := r.db.Create(&product).Error
+func (r *GormProductRepository) CreateProduct(ctx context.Context, product entity.Product) (entity.Product, error) {
+	err := r.db.WithContext(ctx).Create(&product).Error
 	if err != nil {
-		return entity.Product{}, err
+		return entity.Product{}, translateError(err, "product")
 	}
 	return product, nil
 }
 
 // GetProductByID retrieves a product by ID from the database.
-func (r *GormProductRepository) GetProductByID(id int) (entity.Product, error) {
+func (r *GormProductRepository) GetProductByID(ctx context.Context, id int) (entity.Product, error) {
 	var product entity.Product
-	err := r.db.First(&product, id).Error
+	err := r.db.WithContext(ctx).First(&product, id).Error
 	if err != nil {
-		return entity.Product{}, err
+		return entity.Product{}, translateError(err, "product")
 	}
 	return product, nil
 }
 
-// GetAllProducts retrieves all products from the database.
-func (r *GormProductRepository) GetAllProducts() ([]entity.Product, error) {
-	var products []entity.Product
-	err := r.db.Find(&products).Error
-	if err != nil {
-		return nil, err
+// productSortFields are the keys products can be sorted by.
+var productSortFields = map[string]sortField[entity.Product]{
+	"id":         {column: "id", value: func(p entity.Product) any { return p.ID }},
+	"name":       {column: "name", value: func(p entity.Product) any { return p.Name }},
+	"price":      {column: "price_amount", value: func(p entity.Product) any { return p.Price.Amount }},
+	"stock":      {column: "stock", value: func(p entity.Product) any { return p.Stock }},
+	"created_at": {column: "created_at", value: func(p entity.Product) any { return p.CreatedAt }},
+}
+
+// GetAllProducts retrieves one page of the products matching the filter from the database.
+func (r *GormProductRepository) GetAllProducts(ctx context.Context, filter repository.ProductFilter, opts repository.ListOptions) (repository.Page[entity.Product], error) {
+	query := withDeleted(r.db.WithContext(ctx).Model(&entity.Product{}), filter.IncludeDeleted)
+	if filter.MinPrice != nil {
+		query = query.Where("price_currency = ? AND price_amount >= ?", filter.MinPrice.Currency, filter.MinPrice.Amount)
 	}
-	return products, nil
+	if filter.MaxPrice != nil {
+		query = query.Where("price_currency = ? AND price_amount <= ?", filter.MaxPrice.Currency, filter.MaxPrice.Amount)
+	}
+	if filter.InStock {
+		query = query.Where("stock > 0")
+	}
+	return findPage(query, opts, productSortFields)
+}
+
+// productColumns are the columns each product field is stored in.
+var productColumns = map[repository.ProductField][]string{
+	repository.ProductName:        {"name"},
+	repository.ProductDescription: {"description"},
+	repository.ProductPrice:       {"price_amount", "price_currency"},
+	repository.ProductStock:       {"stock"},
 }
 
-// UpdateProduct updates an existing product in the database.
-func (r *GormProductRepository) UpdateProduct(product entity.Product) (entity.Product, error) {
-	err := r.db.Save(&product).Error
+// UpdateProduct updates the columns of the fields of an existing product in
+// the database; the other columns are not written.
+func (r *GormProductRepository) UpdateProduct(ctx context.Context, product entity.Product, fields []repository.ProductField) (entity.Product, error) {
+	columns := []string{"updated_at"}
+	for _, field := range fields {
+		columns = append(columns, productColumns[field]...)
+	}
+	err := r.db.WithContext(ctx).Model(&entity.Product{ID: product.ID}).Select(columns).Updates(&product).Error
 	if err != nil {
-		return entity.Product{}, err
+		return entity.Product{}, translateError(err, "product")
 	}
-	return product, nil
+	return r.GetProductByID(ctx, product.ID)
 }
 
-// DeleteProduct deletes a product by ID from the database.
-func (r *GormProductRepository) DeleteProduct(id int) error {
-	var product entity.Product
-	err := r.db.Delete(&product, id).Error
-	if err != nil {
-		return err
+// AdjustStock atomically adds delta to the product's stock in the database.
+// The condition on the current stock keeps concurrent decrements from overselling.
+// It is unscoped, so that soft-deleted products keep their stock up to date.
+func (r *GormProductRepository) AdjustStock(ctx context.Context, id int, delta int) error {
+	result := r.db.WithContext(ctx).Unscoped().Model(&entity.Product{}).
+		Where("id = ? AND stock + ? >= 0", id, delta).
+		Update("stock", gorm.Expr("stock + ?", delta))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		// Either the product does not exist or there is not enough stock
+		var product entity.Product
+		if err := r.db.WithContext(ctx).Unscoped().Select("id").First(&product, id).Error; err != nil {
+			return translateError(err, "product")
+		}
+		return repository.ErrInsufficientStock
 	}
 	return nil
 }
+
+// DeleteProduct soft-deletes a product by ID from the database.
+func (r *GormProductRepository) DeleteProduct(ctx context.Context, id int) error {
+	var product entity.Product
+	err := r.db.WithContext(ctx).Delete(&product, id).Error
+	return translateError(err, "product")
+}
+
+// RestoreProduct undeletes a soft-deleted product in the database.
+func (r *GormProductRepository) RestoreProduct(ctx context.Context, id int) (entity.Product, error) {
+	if err := restore[entity.Product](r.db.WithContext(ctx), id, "product"); err != nil {
+		return entity.Product{}, err
+	}
+	return r.GetProductByID(ctx, id)
+}
@@ -0,0 +1,121 @@
+package infrastructure
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"unicode"
+
+	"github.com/witchakornb/basic-ecommerce/domain/entity"
+	"github.com/witchakornb/basic-ecommerce/domain/repository"
+	"gorm.io/gorm"
+)
+
+// GormProductSearch is an SQLite FTS5 implementation of the ProductSearch interface.
+type GormProductSearch struct {
+	db      *gorm.DB
+	enabled bool
+}
+
+// NewGormProductSearch returns a GormProductSearch over the index that
+// migration 4 creates. If the database has no index (it is not SQLite,
+// SQLite was built without FTS5, or it was migrated by such a build) the
+// error is returned together with a ProductSearch that answers every query
+// with ErrSearchUnavailable.
+func NewGormProductSearch(db *gorm.DB) (repository.ProductSearch, error) {
+	if err := checkProductSearchIndex(db); err != nil {
+		return &GormProductSearch{db: db}, err
+	}
+	return &GormProductSearch{db: db, enabled: true}, nil
+}
+
+// NewDisabledProductSearch returns a ProductSearch that answers every query
+// with ErrSearchUnavailable, for when search is turned off.
+func NewDisabledProductSearch() repository.ProductSearch {
+	return &GormProductSearch{}
+}
+
+// checkProductSearchIndex reports why the database cannot be searched, if it cannot.
+func checkProductSearchIndex(db *gorm.DB) error {
+	if db.Dialector.Name() != "sqlite" {
+		return errors.New("full-text search requires sqlite")
+	}
+	fts5, err := hasFTS5(db)
+	if err != nil {
+		return err
+	}
+	if !fts5 {
+		return errors.New("sqlite was built without FTS5, build with -tags sqlite_fts5")
+	}
+	if !db.Migrator().HasTable("products_fts") {
+		return errors.New("the search index is missing because the database was migrated without FTS5; recreate it with 'migrate to 3' and 'migrate up'")
+	}
+	return nil
+}
+
+// hasFTS5 reports whether the SQLite library db runs on has the FTS5 extension.
+func hasFTS5(db *gorm.DB) (bool, error) {
+	var fts5 bool
+	err := db.Raw("SELECT sqlite_compileoption_used('ENABLE_FTS5')").Scan(&fts5).Error
+	return fts5, err
+}
+
+// productSearchRow is a products row joined with its search metadata.
+type productSearchRow struct {
+	entity.Product     `gorm:"embedded"`
+	Rank               float64
+	NameHighlight      string
+	DescriptionSnippet string
+}
+
+// SearchProducts returns the products matching every term of the query, best match first.
+// Matches in the name weigh ten times as much as matches in the description.
+func (s *GormProductSearch) SearchProducts(ctx context.Context, query string, limit int) ([]repository.ProductSearchResult, error) {
+	if !s.enabled {
+		return nil, repository.ErrSearchUnavailable
+	}
+	match := buildMatchExpression(query)
+	if match == "" {
+		return []repository.ProductSearchResult{}, nil
+	}
+
+	var rows []productSearchRow
+	err := s.db.WithContext(ctx).Raw(`
+		SELECT products.*,
+			bm25(products_fts, 10.0, 1.0) AS rank,
+			highlight(products_fts, 0, '<mark>', '</mark>') AS name_highlight,
+			snippet(products_fts, 1, '<mark>', '</mark>', '…', 16) AS description_snippet
+		FROM products_fts
+		JOIN products ON products.id = products_fts.rowid
+		WHERE products_fts MATCH ? AND products.deleted_at IS NULL
+		ORDER BY rank
+		LIMIT ?`, match, limit).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]repository.ProductSearchResult, 0, len(rows))
+	for _, row := range rows {
+		results = append(results, repository.ProductSearchResult{
+			Product:            row.Product,
+			Rank:               row.Rank,
+			NameHighlight:      row.NameHighlight,
+			DescriptionSnippet: row.DescriptionSnippet,
+		})
+	}
+	return results, nil
+}
+
+// buildMatchExpression turns free text into an FTS5 query in which every
+// word must match as a prefix. Words are quoted so that FTS5 operators and
+// punctuation typed by users are searched for literally.
+func buildMatchExpression(query string) string {
+	words := strings.FieldsFunc(query, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	terms := make([]string, 0, len(words))
+	for _, word := range words {
+		terms = append(terms, `"`+word+`"*`)
+	}
+	return strings.Join(terms, " ")
+}
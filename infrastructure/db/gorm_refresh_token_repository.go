@@ -0,0 +1,67 @@
+package infrastructure
+
+import (
+	"context"
+	"time"
+
+	"github.com/witchakornb/basic-ecommerce/domain/entity"
+	"github.com/witchakornb/basic-ecommerce/domain/repository"
+	"gorm.io/gorm"
+)
+
+// GormRefreshTokenRepository is a GORM implementation of the RefreshTokenRepository interface.
+type GormRefreshTokenRepository struct {
+	db *gorm.DB
+}
+
+// NewGormRefreshTokenRepository creates a new GormRefreshTokenRepository instance.
+func NewGormRefreshTokenRepository(db *gorm.DB) repository.RefreshTokenRepository {
+	return &GormRefreshTokenRepository{db: db}
+}
+
+// CreateRefreshToken stores a new refresh token in the database.
+func (r *GormRefreshTokenRepository) CreateRefreshToken(ctx context.Context, token entity.RefreshToken) (entity.RefreshToken, error) {
+	err := r.db.WithContext(ctx).Create(&token).Error
+	if err != nil {
+		return entity.RefreshToken{}, translateError(err, "refresh_token")
+	}
+	return token, nil
+}
+
+// GetRefreshTokenByHash retrieves a refresh token by its hash from the database.
+func (r *GormRefreshTokenRepository) GetRefreshTokenByHash(ctx context.Context, tokenHash string) (entity.RefreshToken, error) {
+	var token entity.RefreshToken
+	err := r.db.WithContext(ctx).Where("token_hash = ?", tokenHash).First(&token).Error
+	if err != nil {
+		return entity.RefreshToken{}, translateError(err, "refresh_token")
+	}
+	return token, nil
+}
+
+// RevokeRefreshToken marks a refresh token as revoked. The condition on
+// revoked_at makes a concurrent transaction revoking the same token wait
+// for this one and then find nothing left to update.
+func (r *GormRefreshTokenRepository) RevokeRefreshToken(ctx context.Context, id int) error {
+	result := r.db.WithContext(ctx).Model(&entity.RefreshToken{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		// Either the token does not exist or it is already revoked
+		var token entity.RefreshToken
+		if err := r.db.WithContext(ctx).First(&token, id).Error; err != nil {
+			return translateError(err, "refresh_token")
+		}
+		return repository.ErrRefreshTokenRevoked
+	}
+	return nil
+}
+
+// RevokeUserRefreshTokens marks every active refresh token of a user as revoked.
+func (r *GormRefreshTokenRepository) RevokeUserRefreshTokens(ctx context.Context, userID int) error {
+	return r.db.WithContext(ctx).Model(&entity.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+}
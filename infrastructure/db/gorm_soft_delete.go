@@ -0,0 +1,31 @@
+package infrastructure
+
+import (
+	"github.com/witchakornb/basic-ecommerce/domain/errs"
+	"gorm.io/gorm"
+)
+
+// User, Product and Order have a gorm.DeletedAt field, so GORM soft-deletes
+// them: Delete sets deleted_at and every query skips rows where it is set,
+// unless it is made Unscoped.
+
+// restore clears deleted_at on the row of T with the ID, or returns a
+// not-found error for the resource if there is no such row at all.
+func restore[T any](db *gorm.DB, id int, resource string) error {
+	result := db.Unscoped().Model(new(T)).Where("id = ?", id).Update("deleted_at", nil)
+	if result.Error != nil {
+		return translateError(result.Error, resource)
+	}
+	if result.RowsAffected == 0 {
+		return errs.NotFound(resource)
+	}
+	return nil
+}
+
+// withDeleted makes query also match soft-deleted rows if includeDeleted is set.
+func withDeleted(query *gorm.DB, includeDeleted bool) *gorm.DB {
+	if includeDeleted {
+		return query.Unscoped()
+	}
+	return query
+}
@@ -0,0 +1,83 @@
+package infrastructure
+
+import (
+	"errors"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+// tracer creates the spans of transactions and SQL statements.
+var tracer = otel.Tracer("github.com/witchakornb/basic-ecommerce/infrastructure/db")
+
+// statementSpanKey is the statement setting holding the span of a statement.
+const statementSpanKey = "tracing:span"
+
+// tracingPlugin is a GORM plugin tracing every statement.
+type tracingPlugin struct{}
+
+// NewTracingPlugin returns a GORM plugin that records a span for every
+// statement, a child of the span in the statement's context. Spans carry the
+// SQL with its placeholders, never the bound values. Install it with db.Use.
+func NewTracingPlugin() gorm.Plugin {
+	return tracingPlugin{}
+}
+
+func (tracingPlugin) Name() string {
+	return "tracing"
+}
+
+// Initialize spans each GORM callback chain, from before its first callback
+// to after its last one.
+func (p tracingPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	return errors.Join(
+		cb.Create().Before("*").Register("tracing:before_create", p.start("create")),
+		cb.Create().After("*").Register("tracing:after_create", p.finish),
+		cb.Query().Before("*").Register("tracing:before_query", p.start("query")),
+		cb.Query().After("*").Register("tracing:after_query", p.finish),
+		cb.Update().Before("*").Register("tracing:before_update", p.start("update")),
+		cb.Update().After("*").Register("tracing:after_update", p.finish),
+		cb.Delete().Before("*").Register("tracing:before_delete", p.start("delete")),
+		cb.Delete().After("*").Register("tracing:after_delete", p.finish),
+		cb.Row().Before("*").Register("tracing:before_row", p.start("row")),
+		cb.Row().After("*").Register("tracing:after_row", p.finish),
+		cb.Raw().Before("*").Register("tracing:before_raw", p.start("raw")),
+		cb.Raw().After("*").Register("tracing:after_raw", p.finish),
+	)
+}
+
+func (tracingPlugin) start(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		ctx, span := tracer.Start(db.Statement.Context, "gorm."+operation,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				attribute.String("db.system", db.Dialector.Name()),
+				semconv.DBOperationName(operation),
+			),
+		)
+		db.Statement.Context = ctx
+		db.InstanceSet(statementSpanKey, span)
+	}
+}
+
+func (tracingPlugin) finish(db *gorm.DB) {
+	value, ok := db.InstanceGet(statementSpanKey)
+	if !ok {
+		return
+	}
+	span := value.(trace.Span)
+	span.SetAttributes(
+		semconv.DBQueryText(db.Statement.SQL.String()),
+		attribute.Int64("db.rows_affected", db.Statement.RowsAffected),
+	)
+	if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+		span.RecordError(db.Error)
+		span.SetStatus(codes.Error, db.Error.Error())
+	}
+	span.End()
+}
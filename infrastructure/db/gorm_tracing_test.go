@@ -0,0 +1,57 @@
+package infrastructure
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/witchakornb/basic-ecommerce/domain/entity"
+	"github.com/witchakornb/basic-ecommerce/domain/repository"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	db := openTestDB(t)
+	if err := db.Use(NewTracingPlugin()); err != nil {
+		t.Fatalf("Use: %v", err)
+	}
+	err := NewGormUnitOfWork(db, nil).Execute(t.Context(), func(ctx context.Context, store repository.UnitOfWorkStore) error {
+		_, err := store.Users().CreateUser(ctx, entity.User{Username: "alice", Email: "alice@example.com", Password: "secret-hash", Role: entity.RoleCustomer})
+		return err
+	})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+
+	spans := recorder.Ended()
+	var transaction, insert sdktrace.ReadOnlySpan
+	for _, span := range spans {
+		switch span.Name() {
+		case "UnitOfWork.Execute":
+			transaction = span
+		case "gorm.create":
+			insert = span
+		}
+	}
+	if transaction == nil || insert == nil {
+		t.Fatalf("recorded %d spans without the transaction and the insert", len(spans))
+	}
+	for _, attr := range insert.Attributes() {
+		if attr.Key == "db.query.text" {
+			sql := attr.Value.AsString()
+			if !strings.Contains(sql, "INSERT INTO") || strings.Contains(sql, "secret-hash") {
+				t.Errorf("db.query.text = %q, want the INSERT without its values", sql)
+			}
+		}
+	}
+	if insert.Parent().SpanID() != transaction.SpanContext().SpanID() {
+		t.Error("the insert is not a child of its transaction")
+	}
+}
@@ -1,20 +1,29 @@
 package infrastructure
 
 import (
+	"context"
+	"errors"
+	"fmt"
+
 	"github.com/witchakornb/basic-ecommerce/domain/repository"
+	"go.opentelemetry.io/otel/attribute"
 	"gorm.io/gorm"
 )
 
 // gormUnitOfWork implements the UnitOfWork interface for GORM.
 type gormUnitOfWork struct {
-	db *gorm.DB
+	db      *gorm.DB
+	metrics Metrics
 }
 
 // gormUnitOfWorkStore implements the UnitOfWorkStore interface.
 type gormUnitOfWorkStore struct {
-	userRepo    repository.UserRepository
-	productRepo repository.ProductRepository
-	orderRepo   repository.OrderRepository
+	userRepo        repository.UserRepository
+	productRepo     repository.ProductRepository
+	orderRepo       repository.OrderRepository
+	tokenRepo       repository.RefreshTokenRepository
+	cartRepo        repository.CartRepository
+	reservationRepo repository.InventoryReservationRepository
 }
 
 func (s *gormUnitOfWorkStore) Users() repository.UserRepository {
@@ -29,19 +38,58 @@ func (s *gormUnitOfWorkStore) Orders() repository.OrderRepository {
 	return s.orderRepo
 }
 
-// NewGormUnitOfWork creates a new GORM unit of work.
-func NewGormUnitOfWork(db *gorm.DB) repository.UnitOfWork {
-	return &gormUnitOfWork{db: db}
+func (s *gormUnitOfWorkStore) RefreshTokens() repository.RefreshTokenRepository {
+	return s.tokenRepo
+}
+
+func (s *gormUnitOfWorkStore) Carts() repository.CartRepository {
+	return s.cartRepo
+}
+
+func (s *gormUnitOfWorkStore) Reservations() repository.InventoryReservationRepository {
+	return s.reservationRepo
+}
+
+// NewGormUnitOfWork creates a new GORM unit of work. It reports whether
+// each transaction committed to metrics, unless metrics is nil.
+func NewGormUnitOfWork(db *gorm.DB, metrics Metrics) repository.UnitOfWork {
+	return &gormUnitOfWork{db: db, metrics: metrics}
 }
 
-// Execute runs a function within a GORM transaction.
-func (uow *gormUnitOfWork) Execute(fn func(store repository.UnitOfWorkStore) error) error {
-	return uow.db.Transaction(func(tx *gorm.DB) error {
+// Execute runs a function within a GORM transaction bound to ctx, so the
+// database aborts its statements and rolls it back once ctx is done. The
+// transaction is traced as a span named "UnitOfWork.Execute".
+func (uow *gormUnitOfWork) Execute(ctx context.Context, fn func(ctx context.Context, store repository.UnitOfWorkStore) error) error {
+	ctx, span := tracer.Start(ctx, "UnitOfWork.Execute")
+	// A panicking fn is rolled back, so the outcome is only known to be a
+	// commit once Transaction returns without error
+	committed := false
+	defer func() {
+		span.SetAttributes(attribute.Bool("db.transaction.committed", committed))
+		span.End()
+		if uow.metrics != nil {
+			uow.metrics.ObserveTransaction(committed)
+		}
+	}()
+	err := uow.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		store := &gormUnitOfWorkStore{
-			userRepo:    NewGormUserRepository(tx),
-			productRepo: NewGormProductRepository(tx),
-			orderRepo:   NewGormOrderRepository(tx),
+			userRepo:        NewGormUserRepository(tx),
+			productRepo:     NewGormProductRepository(tx),
+			orderRepo:       NewGormOrderRepository(tx),
+			tokenRepo:       NewGormRefreshTokenRepository(tx),
+			cartRepo:        NewGormCartRepository(tx),
+			reservationRepo: NewGormInventoryReservationRepository(tx),
 		}
-		return fn(store)
+		return fn(ctx, store)
 	})
+	// database/sql may report a transaction rolled back by ctx as
+	// sql.ErrTxDone; callers are told why it was rolled back
+	if err != nil && ctx.Err() != nil && !errors.Is(err, ctx.Err()) {
+		err = fmt.Errorf("%w: %w", ctx.Err(), err)
+	}
+	if err != nil {
+		span.RecordError(err)
+	}
+	committed = err == nil
+	return err
 }
@@ -1,6 +1,8 @@
 package infrastructure
 
 import (
+	"context"
+
 	"github.com/witchakornb/basic-ecommerce/domain/entity"
 	"github.com/witchakornb/basic-ecommerce/domain/repository"
 	"gorm.io/gorm"
@@ -15,39 +17,74 @@ func NewGormUserRepository(db *gorm.DB) repository.UserRepository {
 }
 
 // CreateUser creates a new user in the database.
-func (r *gormUserRepository) CreateUser(user entity.User) (entity.User, error) {
-	err := r.db.Create(&user).Error
+func (r *gormUserRepository) CreateUser(ctx context.Context, user entity.User) (entity.User, error) {
+	err := r.db.WithContext(ctx).Create(&user).Error
 	if err != nil {
-		return entity.User{}, err
+		return entity.User{}, translateError(err, "user")
 	}
 	return user, nil
 }
 
 // GetUserByID retrieves a user by ID from the database.
-func (r *gormUserRepository) GetUserByID(id int) (entity.User, error) {
+func (r *gormUserRepository) GetUserByID(ctx context.Context, id int) (entity.User, error) {
 	var user entity.User
-	err := r.db.First(&user, id).Error
+	err := r.db.WithContext(ctx).First(&user, id).Error
 	if err != nil {
-		return entity.User{}, err
+		return entity.User{}, translateError(err, "user")
 	}
 	return user, nil
 }
 
-// GetAllUsers retrieves all users from the database.
-func (r *gormUserRepository) UpdateUser(user entity.User) (entity.User, error) {
-	err := r.db.Save(&user).Error
+// GetUserByEmail retrieves a user by email address from the database.
+func (r *gormUserRepository) GetUserByEmail(ctx context.Context, email string) (entity.User, error) {
+	var user entity.User
+	err := r.db.WithContext(ctx).Where("email = ?", email).First(&user).Error
 	if err != nil {
-		return entity.User{}, err
+		return entity.User{}, translateError(err, "user")
 	}
 	return user, nil
 }
 
-// DeleteUser deletes a user by ID from the database.
-func (r *gormUserRepository) DeleteUser(id int) error {
-	var user entity.User
-	err := r.db.Delete(&user, id).Error
+// userSortFields are the keys users can be sorted by.
+var userSortFields = map[string]sortField[entity.User]{
+	"id":         {column: "id", value: func(u entity.User) any { return u.ID }},
+	"username":   {column: "username", value: func(u entity.User) any { return u.Username }},
+	"email":      {column: "email", value: func(u entity.User) any { return u.Email }},
+	"created_at": {column: "created_at", value: func(u entity.User) any { return u.CreatedAt }},
+}
+
+// GetAllUsers retrieves one page of the users matching the filter from the database.
+func (r *gormUserRepository) GetAllUsers(ctx context.Context, filter repository.UserFilter, opts repository.ListOptions) (repository.Page[entity.User], error) {
+	query := withDeleted(r.db.WithContext(ctx).Model(&entity.User{}), filter.IncludeDeleted)
+	if filter.Role != "" {
+		query = query.Where("role = ?", filter.Role)
+	}
+	if filter.Email != "" {
+		query = query.Where("email = ?", filter.Email)
+	}
+	return findPage(query, opts, userSortFields)
+}
+
+// UpdateUser updates an existing user in the database.
+func (r *gormUserRepository) UpdateUser(ctx context.Context, user entity.User) (entity.User, error) {
+	err := r.db.WithContext(ctx).Save(&user).Error
 	if err != nil {
-		return err
+		return entity.User{}, translateError(err, "user")
+	}
+	return user, nil
+}
+
+// DeleteUser soft-deletes a user by ID from the database.
+func (r *gormUserRepository) DeleteUser(ctx context.Context, id int) error {
+	var user entity.User
+	err := r.db.WithContext(ctx).Delete(&user, id).Error
+	return translateError(err, "user")
+}
+
+// RestoreUser undeletes a soft-deleted user in the database.
+func (r *gormUserRepository) RestoreUser(ctx context.Context, id int) (entity.User, error) {
+	if err := restore[entity.User](r.db.WithContext(ctx), id, "user"); err != nil {
+		return entity.User{}, err
 	}
-	return nil
+	return r.GetUserByID(ctx, id)
 }
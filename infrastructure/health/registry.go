@@ -0,0 +1,149 @@
+package infrastructure
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Check reports the health of one component: nil if it is healthy, or why not.
+// It must return once ctx is done.
+type Check func(ctx context.Context) error
+
+// Status is the health of a component or of the whole server.
+type Status string
+
+const (
+	StatusUp   Status = "up"
+	StatusDown Status = "down"
+)
+
+// ComponentReport is the outcome of one check.
+type ComponentReport struct {
+	Name    string
+	Status  Status
+	Latency time.Duration
+	Error   string
+}
+
+// Report is the outcome of a probe: up only if every component is up.
+type Report struct {
+	Status     Status
+	Components []ComponentReport
+}
+
+// errShuttingDown is reported by the readiness probe once the server stops accepting traffic.
+var errShuttingDown = errors.New("shutting down")
+
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+// Registry holds the checks of the liveness and readiness probes. Liveness
+// checks tell whether the process works at all and should be restarted if
+// not; readiness checks tell whether it can serve requests now. The
+// readiness probe runs both, and also fails while the server is not ready.
+type Registry struct {
+	timeout time.Duration
+	ready   atomic.Bool
+
+	mu        sync.RWMutex
+	liveness  []namedCheck
+	readiness []namedCheck
+}
+
+// NewRegistry creates a Registry whose checks each get at most timeout to
+// complete. It is not ready until SetReady(true).
+func NewRegistry(timeout time.Duration) *Registry {
+	return &Registry{timeout: timeout}
+}
+
+// AddLiveness registers a check of the liveness probe.
+func (r *Registry) AddLiveness(name string, check Check) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.liveness = append(r.liveness, namedCheck{name, check})
+}
+
+// AddReadiness registers a check of the readiness probe.
+func (r *Registry) AddReadiness(name string, check Check) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.readiness = append(r.readiness, namedCheck{name, check})
+}
+
+// SetReady marks whether the server accepts traffic.
+func (r *Registry) SetReady(ready bool) {
+	r.ready.Store(ready)
+}
+
+// Live runs the liveness checks.
+func (r *Registry) Live(ctx context.Context) Report {
+	r.mu.RLock()
+	checks := append([]namedCheck(nil), r.liveness...)
+	r.mu.RUnlock()
+	return r.run(ctx, checks)
+}
+
+// Ready runs the readiness and liveness checks.
+func (r *Registry) Ready(ctx context.Context) Report {
+	r.mu.RLock()
+	checks := make([]namedCheck, 0, len(r.readiness)+len(r.liveness)+1)
+	checks = append(checks, namedCheck{"server", func(context.Context) error {
+		if !r.ready.Load() {
+			return errShuttingDown
+		}
+		return nil
+	}})
+	checks = append(checks, r.readiness...)
+	checks = append(checks, r.liveness...)
+	r.mu.RUnlock()
+	return r.run(ctx, checks)
+}
+
+// run executes the checks concurrently and reports them in registration order.
+func (r *Registry) run(ctx context.Context, checks []namedCheck) Report {
+	report := Report{Status: StatusUp, Components: make([]ComponentReport, len(checks))}
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			report.Components[i] = r.runCheck(ctx, c)
+		}()
+	}
+	wg.Wait()
+	for _, component := range report.Components {
+		if component.Status != StatusUp {
+			report.Status = StatusDown
+		}
+	}
+	return report
+}
+
+// runCheck executes one check within the timeout. A check that does not
+// return in time is reported down and left to finish in the background.
+func (r *Registry) runCheck(ctx context.Context, c namedCheck) ComponentReport {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() { done <- c.check(ctx) }()
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	component := ComponentReport{Name: c.name, Status: StatusUp, Latency: time.Since(start)}
+	if err != nil {
+		component.Status = StatusDown
+		component.Error = err.Error()
+	}
+	return component
+}
@@ -0,0 +1,70 @@
+package infrastructure
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRegistry(t *testing.T) {
+	registry := NewRegistry(50 * time.Millisecond)
+	registry.AddLiveness("workers", func(context.Context) error { return nil })
+	registry.AddReadiness("database", func(context.Context) error { return nil })
+
+	if report := registry.Live(context.Background()); report.Status != StatusUp || len(report.Components) != 1 {
+		t.Fatalf("Live returned %+v, want the workers up", report)
+	}
+	report := registry.Ready(context.Background())
+	if report.Status != StatusDown || report.Components[0].Name != "server" || report.Components[0].Error != "shutting down" {
+		t.Fatalf("Ready before SetReady returned %+v, want the server down", report)
+	}
+
+	registry.SetReady(true)
+	report = registry.Ready(context.Background())
+	if report.Status != StatusUp {
+		t.Fatalf("Ready returned %+v, want up", report)
+	}
+	var names []string
+	for _, component := range report.Components {
+		names = append(names, component.Name)
+	}
+	if len(names) != 3 || names[0] != "server" || names[1] != "database" || names[2] != "workers" {
+		t.Fatalf("Ready reported the components %v, want server, database and workers", names)
+	}
+}
+
+func TestRegistryReportsFailures(t *testing.T) {
+	registry := NewRegistry(20 * time.Millisecond)
+	registry.SetReady(true)
+	registry.AddReadiness("database", func(context.Context) error { return errors.New("database is locked") })
+	registry.AddReadiness("slow", func(ctx context.Context) error {
+		<-ctx.Done()
+		time.Sleep(time.Second)
+		return nil
+	})
+	registry.AddReadiness("stuck", func(context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	})
+
+	start := time.Now()
+	report := registry.Ready(context.Background())
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatalf("Ready took %v, want the checks cut off at their timeout", elapsed)
+	}
+	if report.Status != StatusDown {
+		t.Fatalf("Ready returned %s, want down", report.Status)
+	}
+	for _, component := range report.Components[1:] {
+		if component.Status != StatusDown || component.Error == "" {
+			t.Errorf("component %s = %+v, want down with an error", component.Name, component)
+		}
+	}
+	if report.Components[1].Error != "database is locked" {
+		t.Errorf("database error = %q", report.Components[1].Error)
+	}
+	if stuck := report.Components[3]; stuck.Error != context.DeadlineExceeded.Error() {
+		t.Errorf("stuck check error = %q, want the deadline", stuck.Error)
+	}
+}
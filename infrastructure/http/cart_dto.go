@@ -0,0 +1,45 @@
+package infrastructure
+
+import (
+	"time"
+
+	"github.com/witchakornb/basic-ecommerce/domain/entity"
+)
+
+type addCartItemRequest struct {
+	ProductID int `json:"product_id" binding:"required,gt=0"`
+	Quantity  int `json:"quantity" binding:"required,gt=0"`
+}
+
+type updateCartItemRequest struct {
+	Quantity int `json:"quantity" binding:"required,gt=0"`
+}
+
+// CartResponse is the JSON representation of a cart returned by the API.
+type CartResponse struct {
+	Items     []CartItemResponse `json:"items"`
+	Total     entity.Money       `json:"total"`
+	UpdatedAt time.Time          `json:"updated_at"`
+}
+
+// CartItemResponse is a product line of a CartResponse.
+type CartItemResponse struct {
+	ProductID int          `json:"product_id"`
+	Quantity  int          `json:"quantity"`
+	UnitPrice entity.Money `json:"unit_price"`
+	Subtotal  entity.Money `json:"subtotal"`
+}
+
+// newCartResponse converts a cart entity into a CartResponse
+func newCartResponse(cart entity.Cart) CartResponse {
+	items := make([]CartItemResponse, len(cart.Items))
+	for i, item := range cart.Items {
+		items[i] = CartItemResponse{
+			ProductID: item.ProductID,
+			Quantity:  item.Quantity,
+			UnitPrice: item.UnitPrice,
+			Subtotal:  item.Subtotal,
+		}
+	}
+	return CartResponse{Items: items, Total: cart.Total, UpdatedAt: cart.UpdatedAt}
+}
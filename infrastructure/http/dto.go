@@ -0,0 +1,16 @@
+package infrastructure
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// deletedAt returns the time a soft-deleted record was deleted, or nil if
+// it is not deleted, so that responses only carry deleted_at when it is set.
+func deletedAt(deleted gorm.DeletedAt) *time.Time {
+	if !deleted.Valid {
+		return nil
+	}
+	return &deleted.Time
+}
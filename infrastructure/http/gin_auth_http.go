@@ -0,0 +1,79 @@
+package infrastructure
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/witchakornb/basic-ecommerce/usecase"
+)
+
+// AuthHandler handles HTTP requests related to authentication
+type AuthHandler struct {
+	authUseCase usecase.AuthUseCase
+}
+
+// NewAuthHandler creates a new AuthHandler
+func NewAuthHandler(authUseCase usecase.AuthUseCase) *AuthHandler {
+	return &AuthHandler{
+		authUseCase: authUseCase,
+	}
+}
+
+type loginRequest struct {
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required"`
+}
+
+type refreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// Login handles exchanging credentials for a token pair
+func (h *AuthHandler) Login(c *gin.Context) {
+	var req loginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(bindError(err))
+		return
+	}
+
+	tokens, err := h.authUseCase.Login(c.Request.Context(), req.Email, req.Password)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// Refresh handles exchanging a refresh token for a new token pair
+func (h *AuthHandler) Refresh(c *gin.Context) {
+	var req refreshTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(bindError(err))
+		return
+	}
+
+	tokens, err := h.authUseCase.Refresh(c.Request.Context(), req.RefreshToken)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// Logout handles revoking a refresh token
+func (h *AuthHandler) Logout(c *gin.Context) {
+	var req refreshTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(bindError(err))
+		return
+	}
+
+	if err := h.authUseCase.Logout(c.Request.Context(), req.RefreshToken); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,83 @@
+package infrastructure
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/witchakornb/basic-ecommerce/domain/entity"
+	"github.com/witchakornb/basic-ecommerce/domain/errs"
+	"github.com/witchakornb/basic-ecommerce/domain/policy"
+	"github.com/witchakornb/basic-ecommerce/usecase"
+)
+
+// principalContextKey is the gin context key holding the authenticated principal.
+const principalContextKey = "auth.principal"
+
+// errMissingToken is reported for requests to protected routes without a bearer token.
+var errMissingToken = errs.New(errs.ErrUnauthorized, "missing_token", "missing bearer token")
+
+// AuthMiddleware rejects requests without a valid bearer access token and
+// stores the authenticated principal on the context.
+func AuthMiddleware(authUseCase usecase.AuthUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.GetHeader("Authorization")
+		token, found := strings.CutPrefix(header, "Bearer ")
+		if !found || token == "" {
+			c.Error(errMissingToken)
+			c.Abort()
+			return
+		}
+
+		principal, err := authUseCase.Authenticate(c.Request.Context(), token)
+		if err != nil {
+			c.Error(err)
+			c.Abort()
+			return
+		}
+
+		c.Set(principalContextKey, principal)
+		c.Next()
+	}
+}
+
+// OptionalAuthMiddleware authenticates requests that carry a bearer token
+// like AuthMiddleware, and lets requests without one through anonymously.
+func OptionalAuthMiddleware(authUseCase usecase.AuthUseCase) gin.HandlerFunc {
+	requireAuth := AuthMiddleware(authUseCase)
+	return func(c *gin.Context) {
+		if c.GetHeader("Authorization") == "" {
+			c.Next()
+			return
+		}
+		requireAuth(c)
+	}
+}
+
+// RequirePermission rejects requests whose principal's role does not grant the action.
+// It must run after AuthMiddleware.
+func RequirePermission(action policy.Action) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, ok := currentPrincipal(c)
+		if !ok {
+			c.Error(errMissingToken)
+			c.Abort()
+			return
+		}
+		if err := policy.Authorize(principal, action); err != nil {
+			c.Error(err)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// currentPrincipal returns the authenticated principal set by AuthMiddleware.
+func currentPrincipal(c *gin.Context) (entity.Principal, bool) {
+	value, ok := c.Get(principalContextKey)
+	if !ok {
+		return entity.Principal{}, false
+	}
+	principal, ok := value.(entity.Principal)
+	return principal, ok
+}
@@ -0,0 +1,143 @@
+package infrastructure
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/witchakornb/basic-ecommerce/domain/entity"
+	"github.com/witchakornb/basic-ecommerce/domain/policy"
+	memory "github.com/witchakornb/basic-ecommerce/infrastructure/memory"
+	infrasecurity "github.com/witchakornb/basic-ecommerce/infrastructure/security"
+	"github.com/witchakornb/basic-ecommerce/usecase"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// serve sends the request to the router and returns the recorded response.
+func serve(router http.Handler, method, path, body string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+// decodeProblem checks that the response is a problem with the status and
+// code, and returns it.
+func decodeProblem(t *testing.T, rec *httptest.ResponseRecorder, status int, code string) Problem {
+	t.Helper()
+	if rec.Code != status {
+		t.Fatalf("status = %d, want %d; body %s", rec.Code, status, rec.Body)
+	}
+	if contentType := rec.Header().Get("Content-Type"); contentType != problemContentType {
+		t.Fatalf("Content-Type = %q, want %q", contentType, problemContentType)
+	}
+	var problem Problem
+	if err := json.Unmarshal(rec.Body.Bytes(), &problem); err != nil {
+		t.Fatalf("decode problem %s: %v", rec.Body, err)
+	}
+	if problem.Status != status || problem.Code != code || problem.Title != http.StatusText(status) {
+		t.Fatalf("problem = %+v, want status %d and code %s", problem, status, code)
+	}
+	return problem
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	store := memory.NewStore()
+	hasher, err := infrasecurity.NewBcryptPasswordHasher(bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("NewBcryptPasswordHasher: %v", err)
+	}
+	users := usecase.NewUserUseCase(memory.NewMemoryUserRepository(store), hasher)
+	customer, err := users.CreateUser(t.Context(), entity.User{Username: "alice", Email: "alice@example.com", Password: "correct horse battery", Role: entity.RoleCustomer})
+	if err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	secret := []byte("0123456789abcdef0123456789abcdef")
+	tokens, err := infrasecurity.NewJWTAccessTokenManager(secret, "shop", time.Minute)
+	if err != nil {
+		t.Fatalf("NewJWTAccessTokenManager: %v", err)
+	}
+	auth := usecase.NewAuthUseCase(memory.NewMemoryUnitOfWork(store), users, tokens, time.Hour)
+	pair, err := auth.Login(t.Context(), "alice@example.com", "correct horse battery")
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	expiredTokens, err := infrasecurity.NewJWTAccessTokenManager(secret, "shop", -time.Minute)
+	if err != nil {
+		t.Fatalf("NewJWTAccessTokenManager: %v", err)
+	}
+	expired, _, err := expiredTokens.Issue(customer)
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+
+	router := gin.New()
+	router.Use(ErrorMiddleware())
+	router.GET("/me", AuthMiddleware(auth), func(c *gin.Context) {
+		principal, _ := currentPrincipal(c)
+		c.JSON(http.StatusOK, principal)
+	})
+	router.GET("/orders", AuthMiddleware(auth), RequirePermission(policy.ReadOrders), func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+	router.GET("/products", OptionalAuthMiddleware(auth), func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+
+	tests := []struct {
+		name          string
+		path          string
+		authorization string
+		status        int
+		code          string // of the problem, if the request is rejected
+	}{
+		{name: "access token", path: "/me", authorization: "Bearer " + pair.AccessToken, status: http.StatusOK},
+		{name: "no header", path: "/me", status: http.StatusUnauthorized, code: "missing_token"},
+		{name: "other scheme", path: "/me", authorization: "Basic YWxpY2U6c2VjcmV0", status: http.StatusUnauthorized, code: "missing_token"},
+		{name: "scheme without token", path: "/me", authorization: "Bearer ", status: http.StatusUnauthorized, code: "missing_token"},
+		{name: "token without scheme", path: "/me", authorization: pair.AccessToken, status: http.StatusUnauthorized, code: "missing_token"},
+		{name: "garbled token", path: "/me", authorization: "Bearer not-a-token", status: http.StatusUnauthorized, code: "invalid_token"},
+		{name: "expired token", path: "/me", authorization: "Bearer " + expired, status: http.StatusUnauthorized, code: "invalid_token"},
+		{name: "refresh token", path: "/me", authorization: "Bearer " + pair.RefreshToken, status: http.StatusUnauthorized, code: "invalid_token"},
+		{name: "role without the permission", path: "/orders", authorization: "Bearer " + pair.AccessToken, status: http.StatusForbidden, code: "forbidden"},
+		{name: "optional without header", path: "/products", status: http.StatusNoContent},
+		{name: "optional with a bad token", path: "/products", authorization: "Bearer not-a-token", status: http.StatusUnauthorized, code: "invalid_token"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			header := http.Header{}
+			if test.authorization != "" {
+				header.Set("Authorization", test.authorization)
+			}
+			rec := serve(router, http.MethodGet, test.path, "", header)
+			if test.code != "" {
+				decodeProblem(t, rec, test.status, test.code)
+				return
+			}
+			if rec.Code != test.status {
+				t.Fatalf("status = %d, want %d; body %s", rec.Code, test.status, rec.Body)
+			}
+			if test.path != "/me" {
+				return
+			}
+			var principal entity.Principal
+			if err := json.Unmarshal(rec.Body.Bytes(), &principal); err != nil {
+				t.Fatalf("decode principal: %v", err)
+			}
+			if want := (entity.Principal{UserID: customer.ID, Role: entity.RoleCustomer}); principal != want {
+				t.Fatalf("principal = %+v, want %+v", principal, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package infrastructure
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/witchakornb/basic-ecommerce/domain/entity"
+	"github.com/witchakornb/basic-ecommerce/usecase"
+)
+
+// CartHandler handles HTTP requests related to the authenticated user's cart
+type CartHandler struct {
+	cartUseCase usecase.CartUseCase
+}
+
+// NewCartHandler creates a new CartHandler
+func NewCartHandler(cartUseCase usecase.CartUseCase) *CartHandler {
+	return &CartHandler{
+		cartUseCase: cartUseCase,
+	}
+}
+
+// GetCart handles retrieving the cart
+func (h *CartHandler) GetCart(c *gin.Context) {
+	principal, _ := currentPrincipal(c)
+	cart, err := h.cartUseCase.GetCart(c.Request.Context(), principal.UserID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, newCartResponse(cart))
+}
+
+// AddItem handles adding a product to the cart
+func (h *CartHandler) AddItem(c *gin.Context) {
+	var req addCartItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(bindError(err))
+		return
+	}
+
+	principal, _ := currentPrincipal(c)
+	cart, err := h.cartUseCase.AddItem(c.Request.Context(), principal.UserID, req.ProductID, req.Quantity)
+	respondCart(c, cart, err)
+}
+
+// UpdateItem handles changing the quantity of a product in the cart
+func (h *CartHandler) UpdateItem(c *gin.Context) {
+	productID, err := parseID(c, "productId")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var req updateCartItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(bindError(err))
+		return
+	}
+
+	principal, _ := currentPrincipal(c)
+	cart, err := h.cartUseCase.UpdateItem(c.Request.Context(), principal.UserID, productID, req.Quantity)
+	respondCart(c, cart, err)
+}
+
+// RemoveItem handles removing a product from the cart
+func (h *CartHandler) RemoveItem(c *gin.Context) {
+	productID, err := parseID(c, "productId")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	principal, _ := currentPrincipal(c)
+	cart, err := h.cartUseCase.RemoveItem(c.Request.Context(), principal.UserID, productID)
+	respondCart(c, cart, err)
+}
+
+// Checkout handles converting the cart into an order
+func (h *CartHandler) Checkout(c *gin.Context) {
+	principal, _ := currentPrincipal(c)
+	order, err := h.cartUseCase.Checkout(c.Request.Context(), principal.UserID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, newOrderResponse(order))
+}
+
+// respondCart writes the cart, or the error of the cart operation that produced it
+func respondCart(c *gin.Context, cart entity.Cart, err error) {
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, newCartResponse(cart))
+}
@@ -0,0 +1,38 @@
+package infrastructure
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	infrahealth "github.com/witchakornb/basic-ecommerce/infrastructure/health"
+)
+
+// HealthHandler answers the liveness and readiness probes with the status
+// and latency of every component the registry checks.
+type HealthHandler struct {
+	registry *infrahealth.Registry
+}
+
+// NewHealthHandler creates a new HealthHandler
+func NewHealthHandler(registry *infrahealth.Registry) *HealthHandler {
+	return &HealthHandler{registry: registry}
+}
+
+// Live handles the liveness probe
+func (h *HealthHandler) Live(c *gin.Context) {
+	respondHealth(c, h.registry.Live(c.Request.Context()))
+}
+
+// Ready handles the readiness probe
+func (h *HealthHandler) Ready(c *gin.Context) {
+	respondHealth(c, h.registry.Ready(c.Request.Context()))
+}
+
+// respondHealth answers 200 if every component is up and 503 otherwise.
+func respondHealth(c *gin.Context, report infrahealth.Report) {
+	status := http.StatusOK
+	if report.Status != infrahealth.StatusUp {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, newHealthResponse(report))
+}
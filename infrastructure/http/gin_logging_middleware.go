@@ -0,0 +1,94 @@
+package infrastructure
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"runtime/debug"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	infralogging "github.com/witchakornb/basic-ecommerce/infrastructure/logging"
+)
+
+// requestIDHeader carries the ID that correlates a request with its log records.
+const requestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength bounds the request IDs accepted from clients.
+const maxRequestIDLength = 128
+
+// RequestIDMiddleware takes the request ID from the X-Request-ID header, or
+// generates one if it is missing or malformed, echoes it in the response and
+// puts it on the request context, so every record logged while handling the
+// request carries it.
+func RequestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(requestIDHeader)
+		if !isValidRequestID(id) {
+			id = newRequestID()
+		}
+		c.Header(requestIDHeader, id)
+		c.Request = c.Request.WithContext(infralogging.WithRequestID(c.Request.Context(), id))
+		c.Next()
+	}
+}
+
+// isValidRequestID accepts IDs of letters, digits and -_.: only, so that
+// client-supplied IDs cannot forge log content.
+func isValidRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.', r == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	id := make([]byte, 16)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
+
+// AccessLogMiddleware logs every request once it has been handled, as an
+// error if the server failed to answer it.
+func AccessLogMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		if status >= 500 {
+			level = slog.LevelError
+		}
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		slog.LogAttrs(c.Request.Context(), level, "http request",
+			slog.String("method", c.Request.Method),
+			slog.String("route", route),
+			slog.String("path", c.Request.URL.Path),
+			slog.Int("status", status),
+			slog.Float64("duration_ms", float64(time.Since(start).Microseconds())/1000),
+			slog.Int("bytes", c.Writer.Size()),
+			slog.String("client_ip", c.ClientIP()),
+		)
+	}
+}
+
+// RecoveryMiddleware answers a request whose handler panicked with a 500
+// problem and logs the panic with its stack.
+func RecoveryMiddleware() gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(io.Discard, func(c *gin.Context, recovered any) {
+		writeProblem(c, fmt.Errorf("panic: %v\n%s", recovered, debug.Stack()))
+	})
+}
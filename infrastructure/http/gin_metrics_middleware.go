@@ -0,0 +1,54 @@
+package infrastructure
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// routeGroupKey is the gin context key holding the route group of a request.
+const routeGroupKey = "routeGroup"
+
+// RequestMetrics receives the measurements of the HTTP layer.
+type RequestMetrics interface {
+	// ObserveHTTPRequest records a request answered with the status after the duration.
+	ObserveHTTPRequest(group, method string, status int, duration time.Duration)
+}
+
+// MetricsMiddleware records the latency and status of every request under
+// the name of its route group, set by RouteGroup. Requests that match no
+// route are recorded as "unmatched" and methods outside the standard set as
+// "other", which keeps the label values bounded.
+// It must run before ErrorMiddleware to see the status of error responses.
+func MetricsMiddleware(metrics RequestMetrics) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		group := c.GetString(routeGroupKey)
+		if group == "" {
+			group = "unmatched"
+		}
+		metrics.ObserveHTTPRequest(group, methodLabel(c.Request.Method), c.Writer.Status(), time.Since(start))
+	}
+}
+
+// methodLabel returns the method if it is a standard HTTP method, otherwise
+// "other", since clients may send any token as the method.
+func methodLabel(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		return method
+	}
+	return "other"
+}
+
+// RouteGroup names the route group the requests of its routes are recorded under.
+func RouteGroup(name string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(routeGroupKey, name)
+		c.Next()
+	}
+}
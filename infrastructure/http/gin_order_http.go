@@ -3,114 +3,174 @@ package infrastructure
 import (
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/witchakornb/basic-ecommerce/domain/entity"
+	"github.com/witchakornb/basic-ecommerce/domain/repository"
 	"github.com/witchakornb/basic-ecommerce/usecase"
 )
 
 // OrderHandler handles HTTP requests related to orders
 type OrderHandler struct {
-	orderUseCase    usecase.OrderUseCase
-	productUserCase usecase.ProductUseCase
-	userUsercase    usecase.UserUseCase
+	orderUseCase usecase.OrderUseCase
 }
 
 // NewOrderHandler creates a new OrderHandler
-func NewOrderHandler(orderUseCase usecase.OrderUseCase, productUserCase usecase.ProductUseCase, userUsercase usecase.UserUseCase) *OrderHandler {
+func NewOrderHandler(orderUseCase usecase.OrderUseCase) *OrderHandler {
 	return &OrderHandler{
-		orderUseCase:    orderUseCase,
-		productUserCase: productUserCase,
-		userUsercase:    userUsercase,
+		orderUseCase: orderUseCase,
 	}
 }
 
-// CreateOrder handles the creation of a new order
+// CreateOrder handles the creation of a new order for the authenticated user
 func (h *OrderHandler) CreateOrder(c *gin.Context) {
-	var order entity.Order
-	if err := c.ShouldBindJSON(&order); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	var req createOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(bindError(err))
 		return
 	}
-	// Validate user ID and product ID
-	if _, err := h.userUsercase.GetUserByID(order.CustomerID); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
-		return
-	}
-	product, err := h.productUserCase.GetProductByID(order.ProductID)
+
+	// The customer is always the caller
+	principal, _ := currentPrincipal(c)
+	// The use case validates the product and stock and creates the order in one transaction
+	createdOrder, err := h.orderUseCase.CreateOrder(c.Request.Context(), req.order(principal.UserID))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		c.Error(err)
 		return
 	}
 
-	// Check if product is in stock
-	if product.Stock < order.Quantity {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Not enough stock"})
-		return
-	}
+	c.JSON(http.StatusCreated, newOrderResponse(createdOrder))
+}
 
-	// Update product stock
-	product.Stock -= order.Quantity
-	_, err = h.productUserCase.UpdateProduct(product)
+// GetOrderByID handles retrieving an order by ID
+func (h *OrderHandler) GetOrderByID(c *gin.Context) {
+	idInt, err := parseID(c, "id")
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update product stock"})
+		c.Error(err)
 		return
 	}
 
-	// Create order
-
-	createdOrder, err := h.orderUseCase.CreateOrder(order)
+	principal, _ := currentPrincipal(c)
+	order, err := h.orderUseCase.GetOrderByID(c.Request.Context(), principal, idInt)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusCreated, createdOrder)
+	c.JSON(http.StatusOK, newOrderResponse(order))
 }
 
-// GetOrderByID handles retrieving an order by ID
-func (h *OrderHandler) GetOrderByID(c *gin.Context) {
-	id := c.Param("id")
-	idInt, err := strconv.Atoi(id)
+// GetAllOrders handles retrieving a page of the orders visible to the caller.
+// Supports ?customer_id=&status=&created_from=&created_to= (RFC 3339)&include_deleted=true plus the common list parameters.
+func (h *OrderHandler) GetAllOrders(c *gin.Context) {
+	opts, err := parseListOptions(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	filter, err := parseOrderFilter(c)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		c.Error(err)
+		return
+	}
+	filter.IncludeDeleted, err = parseIncludeDeleted(c)
+	if err != nil {
+		c.Error(err)
 		return
 	}
 
-	order, err := h.orderUseCase.GetOrderByID(idInt)
+	principal, _ := currentPrincipal(c)
+	orders, err := h.orderUseCase.GetAllOrders(c.Request.Context(), principal, filter, opts)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusOK, order)
+	c.JSON(http.StatusOK, newListResponse(c, orders, opts, newOrderResponse))
 }
 
-// GetAllOrders handles retrieving all orders
-func (h *OrderHandler) GetAllOrders(c *gin.Context) {
-	orders, err := h.orderUseCase.GetAllOrders()
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
+// parseOrderFilter reads the order list filters from the query string
+func parseOrderFilter(c *gin.Context) (repository.OrderFilter, error) {
+	filter := repository.OrderFilter{Status: entity.OrderStatus(c.Query("status"))}
+	if filter.Status != "" && !filter.Status.IsValid() {
+		return repository.OrderFilter{}, invalidFilter("unknown status " + string(filter.Status))
 	}
 
-	c.JSON(http.StatusOK, orders)
+	if customerID := c.Query("customer_id"); customerID != "" {
+		id, err := strconv.Atoi(customerID)
+		if err != nil {
+			return repository.OrderFilter{}, invalidFilter("customer_id must be a number")
+		}
+		filter.CustomerID = id
+	}
+
+	for param, target := range map[string]**time.Time{"created_from": &filter.CreatedFrom, "created_to": &filter.CreatedTo} {
+		value := c.Query(param)
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return repository.OrderFilter{}, invalidFilter(param + " must be an RFC 3339 timestamp")
+		}
+		*target = &t
+	}
+	return filter, nil
+}
+
+// TransitionOrder returns a handler that moves an order to the next status
+func (h *OrderHandler) TransitionOrder(next entity.OrderStatus) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		idInt, err := parseID(c, "id")
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		principal, _ := currentPrincipal(c)
+		order, err := h.orderUseCase.TransitionOrder(c.Request.Context(), principal, idInt, next)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, newOrderResponse(order))
+	}
 }
 
 // DeleteOrder handles deleting an order by ID
 func (h *OrderHandler) DeleteOrder(c *gin.Context) {
-	id := c.Param("id")
-	idInt, err := strconv.Atoi(id)
+	idInt, err := parseID(c, "id")
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		c.Error(err)
 		return
 	}
 
-	err = h.orderUseCase.DeleteOrder(idInt)
+	principal, _ := currentPrincipal(c)
+	err = h.orderUseCase.DeleteOrder(c.Request.Context(), principal, idInt)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
 	c.JSON(http.StatusNoContent, nil)
 }
+
+// RestoreOrder handles undeleting a soft-deleted order
+func (h *OrderHandler) RestoreOrder(c *gin.Context) {
+	idInt, err := parseID(c, "id")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	principal, _ := currentPrincipal(c)
+	order, err := h.orderUseCase.RestoreOrder(c.Request.Context(), principal, idInt)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, newOrderResponse(order))
+}
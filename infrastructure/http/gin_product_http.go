@@ -1,11 +1,13 @@
 package infrastructure
 
 import (
+	"fmt"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/witchakornb/basic-ecommerce/domain/entity"
+	"github.com/witchakornb/basic-ecommerce/domain/repository"
 	"github.com/witchakornb/basic-ecommerce/usecase"
 )
 
@@ -23,81 +25,209 @@ func NewProductHandler(productUseCase usecase.ProductUseCase) *ProductHandler {
 
 // CreateProduct handles the creation of a new product
 func (h *ProductHandler) CreateProduct(c *gin.Context) {
-	var product entity.Product
-	if err := c.ShouldBindJSON(&product); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	var req productRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(bindError(err))
 		return
 	}
 
-	createdProduct, err := h.productUseCase.CreateProduct(product)
+	createdProduct, err := h.productUseCase.CreateProduct(c.Request.Context(), req.product(0))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusCreated, createdProduct)
+	c.JSON(http.StatusCreated, newProductResponse(createdProduct))
 }
 
 // GetProductByID handles retrieving a product by ID
 func (h *ProductHandler) GetProductByID(c *gin.Context) {
-	id := c.Param("id")
-	idInt, err := strconv.Atoi(id)
+	idInt, err := parseID(c, "id")
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		c.Error(err)
 		return
 	}
 
-	product, err := h.productUseCase.GetProductByID(idInt)
+	product, err := h.productUseCase.GetProductByID(c.Request.Context(), idInt)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusOK, product)
+	c.JSON(http.StatusOK, newProductResponse(product))
 }
 
-// GetAllProducts handles retrieving all products
+// GetAllProducts handles retrieving a page of products.
+// Supports ?min_price=&max_price=&currency=&in_stock=true&include_deleted=true plus the common list parameters.
 func (h *ProductHandler) GetAllProducts(c *gin.Context) {
-	products, err := h.productUseCase.GetAllProducts()
+	opts, err := parseListOptions(c)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
+		return
+	}
+	filter, err := parseProductFilter(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	filter.IncludeDeleted, err = parseIncludeDeleted(c)
+	if err != nil {
+		c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusOK, products)
+	products, err := h.productUseCase.GetAllProducts(c.Request.Context(), filter, opts)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, newListResponse(c, products, opts, newProductResponse))
 }
 
-// UpdateProduct handles updating a product
+// SearchProducts handles full-text product search via ?q=&limit=
+func (h *ProductHandler) SearchProducts(c *gin.Context) {
+	limit := repository.DefaultListLimit
+	if l := c.Query("limit"); l != "" {
+		n, err := strconv.Atoi(l)
+		if err != nil || n < 1 || n > repository.MaxListLimit {
+			c.Error(fmt.Errorf("%w: limit must be a number between 1 and %d", repository.ErrInvalidListOptions, repository.MaxListLimit))
+			return
+		}
+		limit = n
+	}
+
+	results, err := h.productUseCase.SearchProducts(c.Request.Context(), c.Query("q"), limit)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response := make([]ProductSearchResponse, len(results))
+	for i, result := range results {
+		response[i] = newProductSearchResponse(result)
+	}
+	c.JSON(http.StatusOK, gin.H{"data": response})
+}
+
+// parseProductFilter reads the product list filters from the query string
+func parseProductFilter(c *gin.Context) (repository.ProductFilter, error) {
+	var filter repository.ProductFilter
+	currency := c.Query("currency")
+	for param, target := range map[string]**entity.Money{"min_price": &filter.MinPrice, "max_price": &filter.MaxPrice} {
+		value := c.Query(param)
+		if value == "" {
+			continue
+		}
+		if currency == "" {
+			return repository.ProductFilter{}, invalidFilter("currency is required with " + param)
+		}
+		price, err := entity.ParseMoney(value, currency)
+		if err != nil {
+			return repository.ProductFilter{}, invalidFilter(param + ": " + err.Error())
+		}
+		*target = &price
+	}
+
+	if inStock := c.Query("in_stock"); inStock != "" {
+		b, err := strconv.ParseBool(inStock)
+		if err != nil {
+			return repository.ProductFilter{}, invalidFilter("in_stock must be true or false")
+		}
+		filter.InStock = b
+	}
+	return filter, nil
+}
+
+// UpdateProduct handles replacing a product
 func (h *ProductHandler) UpdateProduct(c *gin.Context) {
-	var product entity.Product
-	if err := c.ShouldBindJSON(&product); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	idInt, err := parseID(c, "id")
+	if err != nil {
+		c.Error(err)
 		return
 	}
 
-	updatedProduct, err := h.productUseCase.UpdateProduct(product)
+	var req productRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(bindError(err))
+		return
+	}
+
+	h.updateProduct(c, req.product(idInt))
+}
+
+// PatchProduct handles partially updating a product with a JSON Merge Patch
+func (h *ProductHandler) PatchProduct(c *gin.Context) {
+	idInt, err := parseID(c, "id")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	product, err := h.productUseCase.GetProductByID(c.Request.Context(), idInt)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	var req productRequest
+	patched, err := bindMergePatch(c, newProductRequest(product), &req)
+	if err != nil {
+		c.Error(bindError(err))
+		return
+	}
+
+	// Only the patched fields are written, so the patch does not undo stock
+	// taken by orders since the product was read
+	updatedProduct, err := h.productUseCase.PatchProduct(c.Request.Context(), req.product(idInt), productFields(patched))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, newProductResponse(updatedProduct))
+}
+
+// updateProduct stores the changed product and writes it
+func (h *ProductHandler) updateProduct(c *gin.Context, product entity.Product) {
+	updatedProduct, err := h.productUseCase.UpdateProduct(c.Request.Context(), product)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusOK, updatedProduct)
+	c.JSON(http.StatusOK, newProductResponse(updatedProduct))
 }
 
 // DeleteProduct handles deleting a product by ID
 func (h *ProductHandler) DeleteProduct(c *gin.Context) {
-	id := c.Param("id")
-	idInt, err := strconv.Atoi(id)
+	idInt, err := parseID(c, "id")
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		c.Error(err)
 		return
 	}
 
-	err = h.productUseCase.DeleteProduct(idInt)
+	err = h.productUseCase.DeleteProduct(c.Request.Context(), idInt)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
 	c.JSON(http.StatusNoContent, nil)
 }
+
+// RestoreProduct handles undeleting a soft-deleted product
+func (h *ProductHandler) RestoreProduct(c *gin.Context) {
+	idInt, err := parseID(c, "id")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	product, err := h.productUseCase.RestoreProduct(c.Request.Context(), idInt)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, newProductResponse(product))
+}
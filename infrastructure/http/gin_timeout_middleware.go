@@ -0,0 +1,25 @@
+package infrastructure
+
+import (
+	"context"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TimeoutMiddleware gives the context of every request a deadline of
+// timeout, so the database work done for it is cancelled once it is late.
+// The context is also cancelled when the client disconnects. A timeout of 0
+// sets no deadline.
+func TimeoutMiddleware(timeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if timeout <= 0 {
+			c.Next()
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
@@ -0,0 +1,56 @@
+package infrastructure
+
+import (
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+	infralogging "github.com/witchakornb/basic-ecommerce/infrastructure/logging"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer creates the spans of HTTP requests.
+var tracer = otel.Tracer("github.com/witchakornb/basic-ecommerce/infrastructure/http")
+
+// TracingMiddleware records a span for every request, named after its
+// method and route, such as "POST /api/cart/checkout". A trace context sent
+// by the client in the traceparent header is continued. The span is put on
+// the request context, so the spans of use cases, transactions and SQL
+// statements become its children.
+func TracingMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := otel.GetTextMapPropagator().Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+		route := c.FullPath()
+		name := c.Request.Method + " " + route
+		if route == "" {
+			name = c.Request.Method
+		}
+		ctx, span := tracer.Start(ctx, name,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(c.Request.Method),
+				semconv.HTTPRoute(route),
+				semconv.URLPath(c.Request.URL.Path),
+				semconv.ClientAddress(c.ClientIP()),
+				attribute.String("http.request.id", infralogging.RequestID(ctx)),
+			),
+		)
+		defer span.End()
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		status := c.Writer.Status()
+		span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+		if len(c.Errors) > 0 {
+			span.RecordError(c.Errors.Last().Err)
+		}
+		if status >= 500 {
+			span.SetStatus(codes.Error, fmt.Sprintf("answered with %d", status))
+		}
+	}
+}
@@ -2,10 +2,11 @@ package infrastructure
 
 import (
 	"net/http"
-	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/witchakornb/basic-ecommerce/domain/entity"
+	"github.com/witchakornb/basic-ecommerce/domain/policy"
+	"github.com/witchakornb/basic-ecommerce/domain/repository"
 	"github.com/witchakornb/basic-ecommerce/usecase"
 )
 
@@ -21,72 +22,178 @@ func NewUserHandler(userUseCase usecase.UserUseCase) *UserHandler {
 	}
 }
 
-// CreateUser handles the creation of a new user
+// CreateUser handles the registration of a new customer
 func (h *UserHandler) CreateUser(c *gin.Context) {
-	var user entity.User
-	if err := c.ShouldBindJSON(&user); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	var req createUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(bindError(err))
 		return
 	}
-
-	createdUser, err := h.userUseCase.CreateUser(user)
+	// Self-registered users are always customers; admins assign other roles
+	createdUser, err := h.userUseCase.CreateUser(c.Request.Context(), req.user())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusCreated, createdUser)
+	c.JSON(http.StatusCreated, newUserResponse(createdUser))
 }
 
 // GetUserByID handles retrieving a user by ID
 func (h *UserHandler) GetUserByID(c *gin.Context) {
-	id := c.Param("id")
-	idInt, err := strconv.Atoi(id)
+	idInt, err := parseID(c, "id")
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		c.Error(err)
+		return
+	}
+
+	principal, _ := currentPrincipal(c)
+	if err := policy.AuthorizeOwner(principal, idInt, policy.ReadUsers); err != nil {
+		c.Error(err)
 		return
 	}
 
-	user, err := h.userUseCase.GetUserByID(idInt)
+	user, err := h.userUseCase.GetUserByID(c.Request.Context(), idInt)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusOK, user)
+	c.JSON(http.StatusOK, newUserResponse(user))
 }
 
-// UpdateUser handles updating a user
+// GetAllUsers handles retrieving a page of users.
+// Supports ?role=&include_deleted=true plus the common list parameters.
+func (h *UserHandler) GetAllUsers(c *gin.Context) {
+	opts, err := parseListOptions(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	filter := repository.UserFilter{Role: entity.Role(c.Query("role"))}
+	if filter.Role != "" && !filter.Role.IsValid() {
+		c.Error(invalidFilter("unknown role " + string(filter.Role)))
+		return
+	}
+	filter.IncludeDeleted, err = parseIncludeDeleted(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	users, err := h.userUseCase.GetAllUsers(c.Request.Context(), filter, opts)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, newListResponse(c, users, opts, newUserResponse))
+}
+
+// UpdateUser handles replacing a user
 func (h *UserHandler) UpdateUser(c *gin.Context) {
-	var user entity.User
-	if err := c.ShouldBindJSON(&user); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	id, principal, ok := h.authorizeUserChange(c)
+	if !ok {
+		return
+	}
+
+	var req updateUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(bindError(err))
+		return
+	}
+	h.updateUser(c, principal, req.user(id))
+}
+
+// PatchUser handles partially updating a user with a JSON Merge Patch
+func (h *UserHandler) PatchUser(c *gin.Context) {
+	id, principal, ok := h.authorizeUserChange(c)
+	if !ok {
+		return
+	}
+
+	user, err := h.userUseCase.GetUserByID(c.Request.Context(), id)
+	if err != nil {
+		c.Error(err)
 		return
 	}
+	var req updateUserRequest
+	if _, err := bindMergePatch(c, newUpdateUserRequest(user), &req); err != nil {
+		c.Error(bindError(err))
+		return
+	}
+	h.updateUser(c, principal, req.user(id))
+}
+
+// authorizeUserChange reads the ID of the user to change and checks the
+// caller may change them. On failure it records the error and returns false.
+func (h *UserHandler) authorizeUserChange(c *gin.Context) (int, entity.Principal, bool) {
+	id, err := parseID(c, "id")
+	if err != nil {
+		c.Error(err)
+		return 0, entity.Principal{}, false
+	}
+
+	principal, _ := currentPrincipal(c)
+	if err := policy.AuthorizeOwner(principal, id, policy.ManageUsers); err != nil {
+		c.Error(err)
+		return 0, entity.Principal{}, false
+	}
+	return id, principal, true
+}
+
+// updateUser stores the changed user and writes it
+func (h *UserHandler) updateUser(c *gin.Context, principal entity.Principal, user entity.User) {
+	// Only user managers may change roles; everyone else keeps their current one
+	if !policy.Can(principal, policy.ManageUsers) {
+		user.Role = ""
+	}
 
-	updatedUser, err := h.userUseCase.UpdateUser(user)
+	updatedUser, err := h.userUseCase.UpdateUser(c.Request.Context(), user)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusOK, updatedUser)
+	c.JSON(http.StatusOK, newUserResponse(updatedUser))
 }
 
 // DeleteUser handles deleting a user by ID
 func (h *UserHandler) DeleteUser(c *gin.Context) {
-	id := c.Param("id")
-	idInt, err := strconv.Atoi(id)
+	idInt, err := parseID(c, "id")
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		c.Error(err)
 		return
 	}
 
-	err = h.userUseCase.DeleteUser(idInt)
+	principal, _ := currentPrincipal(c)
+	if err := policy.AuthorizeOwner(principal, idInt, policy.ManageUsers); err != nil {
+		c.Error(err)
+		return
+	}
+
+	err = h.userUseCase.DeleteUser(c.Request.Context(), idInt)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
 	c.JSON(http.StatusNoContent, nil)
 }
+
+// RestoreUser handles undeleting a soft-deleted user
+func (h *UserHandler) RestoreUser(c *gin.Context) {
+	idInt, err := parseID(c, "id")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	user, err := h.userUseCase.RestoreUser(c.Request.Context(), idInt)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, newUserResponse(user))
+}
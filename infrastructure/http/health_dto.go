@@ -0,0 +1,32 @@
+package infrastructure
+
+import (
+	infrahealth "github.com/witchakornb/basic-ecommerce/infrastructure/health"
+)
+
+// healthResponse is the body of the liveness and readiness probes.
+type healthResponse struct {
+	Status     infrahealth.Status  `json:"status"`
+	Components []componentResponse `json:"components"`
+}
+
+// componentResponse is the health of one component.
+type componentResponse struct {
+	Name      string             `json:"name"`
+	Status    infrahealth.Status `json:"status"`
+	LatencyMs float64            `json:"latency_ms"`
+	Error     string             `json:"error,omitempty"`
+}
+
+func newHealthResponse(report infrahealth.Report) healthResponse {
+	components := make([]componentResponse, len(report.Components))
+	for i, component := range report.Components {
+		components[i] = componentResponse{
+			Name:      component.Name,
+			Status:    component.Status,
+			LatencyMs: float64(component.Latency.Microseconds()) / 1000,
+			Error:     component.Error,
+		}
+	}
+	return healthResponse{Status: report.Status, Components: components}
+}
@@ -0,0 +1,127 @@
+package infrastructure
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/witchakornb/basic-ecommerce/domain/errs"
+	"github.com/witchakornb/basic-ecommerce/domain/policy"
+	"github.com/witchakornb/basic-ecommerce/domain/repository"
+)
+
+// ListResponse is the envelope returned by every list endpoint
+type ListResponse[T any] struct {
+	Data  []T       `json:"data"`
+	Meta  ListMeta  `json:"meta"`
+	Links ListLinks `json:"links"`
+}
+
+// ListMeta describes the returned page
+type ListMeta struct {
+	Total      int64  `json:"total"`
+	Limit      int    `json:"limit"`
+	Offset     int    `json:"offset,omitempty"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// ListLinks holds ready-made URLs for the current and the next page
+type ListLinks struct {
+	Self string `json:"self"`
+	Next string `json:"next,omitempty"`
+}
+
+// parseListOptions reads the limit, offset, cursor and sort query parameters.
+// Sort is a comma separated list of keys, each optionally prefixed with "-"
+// for descending order, e.g. ?sort=-price,name.
+func parseListOptions(c *gin.Context) (repository.ListOptions, error) {
+	opts := repository.ListOptions{
+		Limit:  repository.DefaultListLimit,
+		Cursor: c.Query("cursor"),
+	}
+
+	if limit := c.Query("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > repository.MaxListLimit {
+			return repository.ListOptions{}, fmt.Errorf("%w: limit must be a number between 1 and %d", repository.ErrInvalidListOptions, repository.MaxListLimit)
+		}
+		opts.Limit = n
+	}
+	if offset := c.Query("offset"); offset != "" {
+		n, err := strconv.Atoi(offset)
+		if err != nil || n < 0 {
+			return repository.ListOptions{}, fmt.Errorf("%w: offset must be a non-negative number", repository.ErrInvalidListOptions)
+		}
+		opts.Offset = n
+	}
+	if opts.Cursor != "" && opts.Offset != 0 {
+		return repository.ListOptions{}, fmt.Errorf("%w: offset and cursor cannot be combined", repository.ErrInvalidListOptions)
+	}
+
+	for _, field := range strings.Split(c.Query("sort"), ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		opts.Sort = append(opts.Sort, repository.SortKey{
+			Field: strings.TrimPrefix(field, "-"),
+			Desc:  strings.HasPrefix(field, "-"),
+		})
+	}
+	return opts, nil
+}
+
+// parseIncludeDeleted reads ?include_deleted=true, which lists soft-deleted
+// records as well and is only allowed to principals that may manage them.
+func parseIncludeDeleted(c *gin.Context) (bool, error) {
+	value := c.Query("include_deleted")
+	if value == "" {
+		return false, nil
+	}
+	include, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, invalidFilter("include_deleted must be true or false")
+	}
+	if !include {
+		return false, nil
+	}
+	principal, ok := currentPrincipal(c)
+	if !ok {
+		return false, errMissingToken
+	}
+	return true, policy.Authorize(principal, policy.ManageDeleted)
+}
+
+// newListResponse wraps a page, converting every item with convert
+func newListResponse[T, R any](c *gin.Context, page repository.Page[T], opts repository.ListOptions, convert func(T) R) ListResponse[R] {
+	data := make([]R, 0, len(page.Items))
+	for _, item := range page.Items {
+		data = append(data, convert(item))
+	}
+
+	response := ListResponse[R]{
+		Data: data,
+		Meta: ListMeta{
+			Total:      page.Total,
+			Limit:      opts.Limit,
+			Offset:     opts.Offset,
+			NextCursor: page.NextCursor,
+		},
+		Links: ListLinks{Self: c.Request.URL.RequestURI()},
+	}
+	if page.NextCursor != "" {
+		next := *c.Request.URL
+		query := next.Query()
+		query.Del("offset")
+		query.Set("cursor", page.NextCursor)
+		next.RawQuery = query.Encode()
+		response.Links.Next = next.RequestURI()
+	}
+	return response
+}
+
+// invalidFilter returns the error for a list filter query parameter with a bad value
+func invalidFilter(message string) error {
+	return errs.New(errs.ErrValidation, "invalid_filter", message)
+}
@@ -0,0 +1,122 @@
+package infrastructure
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"reflect"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/witchakornb/basic-ecommerce/domain/errs"
+)
+
+// mergePatchContentType is the media type of a JSON Merge Patch document.
+const mergePatchContentType = "application/merge-patch+json"
+
+// bindMergePatch applies the request body as a JSON Merge Patch (RFC 7386)
+// to the JSON form of current and binds and validates the result into obj,
+// like c.ShouldBindJSON does for a complete body. Members set to null in
+// the patch are removed, so they end up with their zero value in obj, and
+// members obj has no field for, such as the ID, are rejected. It returns
+// the names of the top-level members the patch sets or removes.
+func bindMergePatch(c *gin.Context, current, obj any) (patched []string, err error) {
+	if mediaType, _, _ := mime.ParseMediaType(c.ContentType()); mediaType != mergePatchContentType && mediaType != "application/json" {
+		return nil, &requestError{
+			status:  http.StatusUnsupportedMediaType,
+			code:    "unsupported_media_type",
+			message: "patch must be sent as " + mergePatchContentType,
+		}
+	}
+	patch, err := c.GetRawData()
+	if err != nil {
+		return nil, err
+	}
+	doc, err := json.Marshal(current)
+	if err != nil {
+		return nil, err
+	}
+	merged, patched, err := mergePatch(doc, patch)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkMembers(obj, patched); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(merged, obj); err != nil {
+		return nil, err
+	}
+	return patched, binding.Validator.ValidateStruct(obj)
+}
+
+// mergePatch applies a JSON Merge Patch to a JSON document and returns the
+// result with the sorted names of the top-level members of the patch.
+func mergePatch(doc, patch []byte) ([]byte, []string, error) {
+	target, err := decodeJSON(doc)
+	if err != nil {
+		return nil, nil, err
+	}
+	p, err := decodeJSON(patch)
+	if err != nil {
+		return nil, nil, err
+	}
+	var patched []string
+	if members, ok := p.(map[string]any); ok {
+		for name := range members {
+			patched = append(patched, name)
+		}
+		slices.Sort(patched)
+	}
+	merged, err := json.Marshal(mergeValue(target, p))
+	return merged, patched, err
+}
+
+// checkMembers returns a validation error naming the members that the
+// struct obj points to has no JSON field for.
+func checkMembers(obj any, members []string) error {
+	t := reflect.TypeOf(obj).Elem()
+	var fields []errs.FieldError
+	for _, member := range members {
+		known := slices.ContainsFunc(reflect.VisibleFields(t), func(field reflect.StructField) bool {
+			return field.IsExported() && jsonFieldName(field) == member
+		})
+		if !known {
+			fields = append(fields, errs.FieldError{Field: member, Reason: "cannot be changed"})
+		}
+	}
+	return errs.Invalid(fields...)
+}
+
+// mergeValue is the MergePatch function of RFC 7386: a patch object is
+// merged into the target member by member, anything else replaces it.
+func mergeValue(target, patch any) any {
+	patchObject, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	targetObject, ok := target.(map[string]any)
+	if !ok {
+		targetObject = make(map[string]any)
+	}
+	for name, value := range patchObject {
+		if value == nil {
+			delete(targetObject, name)
+			continue
+		}
+		targetObject[name] = mergeValue(targetObject[name], value)
+	}
+	return targetObject
+}
+
+// decodeJSON decodes a JSON document, keeping numbers exact.
+func decodeJSON(data []byte) (any, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
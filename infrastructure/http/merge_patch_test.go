@@ -0,0 +1,143 @@
+package infrastructure
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/witchakornb/basic-ecommerce/domain/errs"
+)
+
+func TestMergePatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		doc     string
+		patch   string
+		want    string
+		patched []string
+	}{
+		{name: "replace member", doc: `{"a": "b"}`, patch: `{"a": "c"}`, want: `{"a": "c"}`, patched: []string{"a"}},
+		{name: "add member", doc: `{"a": "b"}`, patch: `{"b": "c"}`, want: `{"a": "b", "b": "c"}`, patched: []string{"b"}},
+		{name: "null removes member", doc: `{"a": "b", "b": "c"}`, patch: `{"a": null}`, want: `{"b": "c"}`, patched: []string{"a"}},
+		{name: "null of a missing member", doc: `{"a": "b"}`, patch: `{"c": null}`, want: `{"a": "b"}`, patched: []string{"c"}},
+		{name: "nested objects merge", doc: `{"price": {"amount": "1.00", "currency": "USD"}}`, patch: `{"price": {"amount": "2.50"}}`, want: `{"price": {"amount": "2.50", "currency": "USD"}}`, patched: []string{"price"}},
+		{name: "nested null", doc: `{"a": {"b": "c", "d": "e"}}`, patch: `{"a": {"d": null}}`, want: `{"a": {"b": "c"}}`, patched: []string{"a"}},
+		{name: "object replaces scalar", doc: `{"a": "b"}`, patch: `{"a": {"c": null, "d": 1}}`, want: `{"a": {"d": 1}}`, patched: []string{"a"}},
+		{name: "arrays are replaced", doc: `{"a": [{"b": "c"}, 2]}`, patch: `{"a": [3]}`, want: `{"a": [3]}`, patched: []string{"a"}},
+		{name: "empty patch", doc: `{"a": "b"}`, patch: `{}`, want: `{"a": "b"}`},
+		{name: "non-object patch replaces the document", doc: `{"a": "b"}`, patch: `["c"]`, want: `["c"]`},
+		{name: "numbers stay exact", doc: `{"a": 1}`, patch: `{"a": 12345678901234567890}`, want: `{"a": 12345678901234567890}`, patched: []string{"a"}},
+		{name: "several members", doc: `{}`, patch: `{"c": 1, "a": 2, "b": 3}`, want: `{"a": 2, "b": 3, "c": 1}`, patched: []string{"a", "b", "c"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			merged, patched, err := mergePatch([]byte(test.doc), []byte(test.patch))
+			if err != nil {
+				t.Fatalf("mergePatch: %v", err)
+			}
+			got, err := decodeJSON(merged)
+			if err != nil {
+				t.Fatalf("decode merged %s: %v", merged, err)
+			}
+			want, err := decodeJSON([]byte(test.want))
+			if err != nil {
+				t.Fatalf("decode want: %v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Fatalf("merged = %s, want %s", merged, test.want)
+			}
+			if !slices.Equal(patched, test.patched) {
+				t.Fatalf("patched members = %v, want %v", patched, test.patched)
+			}
+		})
+	}
+
+	if _, _, err := mergePatch([]byte(`{}`), []byte(`{"a": `)); err == nil {
+		t.Fatal("mergePatch of a malformed patch succeeded")
+	}
+}
+
+// patchRequest is the resource a merge patch is applied to in TestBindMergePatch.
+type patchRequest struct {
+	Name string            `json:"name" binding:"required"`
+	Tags []string          `json:"tags"`
+	Meta map[string]string `json:"meta"`
+}
+
+func TestBindMergePatch(t *testing.T) {
+	if err := RegisterValidators(); err != nil {
+		t.Fatalf("RegisterValidators: %v", err)
+	}
+	current := patchRequest{Name: "mug", Tags: []string{"kitchen", "blue"}, Meta: map[string]string{"color": "blue", "size": "m"}}
+	router := gin.New()
+	router.Use(ErrorMiddleware())
+	router.PATCH("/things", func(c *gin.Context) {
+		var req patchRequest
+		patched, err := bindMergePatch(c, current, &req)
+		if err != nil {
+			c.Error(bindError(err))
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"result": req, "patched": patched})
+	})
+	mergePatchHeader := http.Header{"Content-Type": {mergePatchContentType}}
+
+	t.Run("applied", func(t *testing.T) {
+		rec := serve(router, http.MethodPatch, "/things", `{"tags": ["sale"], "meta": {"size": null, "shape": "round"}}`, mergePatchHeader)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status = %d, want 200; body %s", rec.Code, rec.Body)
+		}
+		var body struct {
+			Result  patchRequest `json:"result"`
+			Patched []string     `json:"patched"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("decode body: %v", err)
+		}
+		want := patchRequest{Name: "mug", Tags: []string{"sale"}, Meta: map[string]string{"color": "blue", "shape": "round"}}
+		if !reflect.DeepEqual(body.Result, want) {
+			t.Fatalf("patched resource = %+v, want %+v", body.Result, want)
+		}
+		if !slices.Equal(body.Patched, []string{"meta", "tags"}) {
+			t.Fatalf("patched members = %v, want [meta tags]", body.Patched)
+		}
+	})
+
+	t.Run("plain JSON", func(t *testing.T) {
+		rec := serve(router, http.MethodPatch, "/things", `{"name": "cup"}`, http.Header{"Content-Type": {"application/json"}})
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status = %d, want 200; body %s", rec.Code, rec.Body)
+		}
+	})
+
+	tests := []struct {
+		name   string
+		body   string
+		header http.Header
+		status int
+		code   string
+		fields []errs.FieldError
+	}{
+		{
+			name: "null of a required member", body: `{"name": null}`, header: mergePatchHeader, status: http.StatusUnprocessableEntity, code: "validation_failed",
+			fields: []errs.FieldError{{Field: "name", Reason: "is required"}},
+		},
+		{
+			name: "members that cannot be changed", body: `{"name": "cup", "id": 9, "created_at": null}`, header: mergePatchHeader, status: http.StatusUnprocessableEntity, code: "validation_failed",
+			fields: []errs.FieldError{{Field: "created_at", Reason: "cannot be changed"}, {Field: "id", Reason: "cannot be changed"}},
+		},
+		{name: "other media type", body: `{"name": "cup"}`, header: http.Header{"Content-Type": {"text/plain"}}, status: http.StatusUnsupportedMediaType, code: "unsupported_media_type"},
+		{name: "malformed", body: `{"name": `, header: mergePatchHeader, status: http.StatusBadRequest, code: "malformed_body"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			problem := decodeProblem(t, serve(router, http.MethodPatch, "/things", test.body, test.header), test.status, test.code)
+			if test.fields != nil && !slices.Equal(problem.Errors, test.fields) {
+				t.Fatalf("errors = %+v, want %+v", problem.Errors, test.fields)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package infrastructure
+
+import (
+	"time"
+
+	"github.com/witchakornb/basic-ecommerce/domain/entity"
+)
+
+// createOrderRequest is the body of an order placement. Names, prices and
+// totals are always taken from the catalog.
+type createOrderRequest struct {
+	Items []orderItemRequest `json:"items" binding:"required,min=1,dive"`
+}
+
+type orderItemRequest struct {
+	ProductID int `json:"product_id" binding:"required,gt=0"`
+	Quantity  int `json:"quantity" binding:"required,gt=0"`
+}
+
+// order converts the request into an order of the customer.
+func (r createOrderRequest) order(customerID int) entity.Order {
+	order := entity.Order{CustomerID: customerID}
+	for _, item := range r.Items {
+		order.Items = append(order.Items, entity.OrderItem{ProductID: item.ProductID, Quantity: item.Quantity})
+	}
+	return order
+}
+
+// OrderResponse is the JSON representation of an order returned by the API.
+type OrderResponse struct {
+	ID         int                 `json:"id"`
+	CustomerID int                 `json:"customer_id"`
+	Status     entity.OrderStatus  `json:"status"`
+	Items      []OrderItemResponse `json:"items"`
+	TotalPrice entity.Money        `json:"total_price"`
+	CreatedAt  time.Time           `json:"created_at"`
+	UpdatedAt  time.Time           `json:"updated_at"`
+	DeletedAt  *time.Time          `json:"deleted_at,omitempty"`
+}
+
+// OrderItemResponse is a product line of an OrderResponse.
+type OrderItemResponse struct {
+	ProductID   int          `json:"product_id"`
+	ProductName string       `json:"product_name"`
+	UnitPrice   entity.Money `json:"unit_price"`
+	Quantity    int          `json:"quantity"`
+	Subtotal    entity.Money `json:"subtotal"`
+}
+
+// newOrderResponse converts an order entity into an OrderResponse
+func newOrderResponse(order entity.Order) OrderResponse {
+	items := make([]OrderItemResponse, len(order.Items))
+	for i, item := range order.Items {
+		items[i] = OrderItemResponse{
+			ProductID:   item.ProductID,
+			ProductName: item.ProductName,
+			UnitPrice:   item.UnitPrice,
+			Quantity:    item.Quantity,
+			Subtotal:    item.Subtotal,
+		}
+	}
+	return OrderResponse{
+		ID:         order.ID,
+		CustomerID: order.CustomerID,
+		Status:     order.Status,
+		Items:      items,
+		TotalPrice: order.TotalPrice,
+		CreatedAt:  order.CreatedAt,
+		UpdatedAt:  order.UpdatedAt,
+		DeletedAt:  deletedAt(order.DeletedAt),
+	}
+}
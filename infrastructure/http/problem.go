@@ -0,0 +1,127 @@
+package infrastructure
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/witchakornb/basic-ecommerce/domain/errs"
+)
+
+// problemContentType is the media type of RFC 7807 problem details.
+const problemContentType = "application/problem+json"
+
+// Problem is an RFC 7807 problem details object. Code is an extension
+// member holding the stable code of the error, which clients should use
+// instead of parsing Detail.
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	Code     string `json:"code"`
+	// Errors lists the invalid fields of a validation error.
+	Errors []errs.FieldError `json:"errors,omitempty"`
+}
+
+// kindStatuses maps each kind of domain error to its HTTP status code.
+var kindStatuses = map[error]int{
+	errs.ErrNotFound:          http.StatusNotFound,
+	errs.ErrConflict:          http.StatusConflict,
+	errs.ErrInsufficientStock: http.StatusConflict,
+	errs.ErrValidation:        http.StatusUnprocessableEntity,
+	errs.ErrForbidden:         http.StatusForbidden,
+	errs.ErrUnauthorized:      http.StatusUnauthorized,
+	errs.ErrUnavailable:       http.StatusServiceUnavailable,
+}
+
+// statusClientClosedRequest is the non-standard status, introduced by
+// nginx, of requests abandoned by the client before they were answered.
+const statusClientClosedRequest = 499
+
+// requestError is a request the server could not parse, such as malformed
+// JSON or a non-numeric ID. It is usually reported as 400 Bad Request.
+type requestError struct {
+	status  int
+	code    string
+	message string
+}
+
+func (e *requestError) Error() string {
+	return e.message
+}
+
+// badRequest returns the error for a request that could not be parsed.
+func badRequest(code, message string) error {
+	return &requestError{status: http.StatusBadRequest, code: code, message: message}
+}
+
+// ErrorMiddleware renders the last error a handler attached with c.Error as
+// problem+json. Domain errors are mapped to a status by their kind and
+// requests cut off by their context to 504 or 499; any other error is
+// logged and reported as a 500 without its details.
+func ErrorMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+		writeProblem(c, c.Errors.Last().Err)
+	}
+}
+
+func writeProblem(c *gin.Context, err error) {
+	problem := Problem{Type: "about:blank", Instance: c.Request.URL.Path}
+
+	var reqErr *requestError
+	switch kind := errs.KindOf(err); {
+	case errors.As(err, &reqErr):
+		problem.Status = reqErr.status
+		problem.Code = reqErr.code
+		problem.Detail = reqErr.message
+	case errors.Is(err, context.DeadlineExceeded):
+		problem.Status = http.StatusGatewayTimeout
+		problem.Code = "request_timeout"
+		problem.Detail = "the request took too long to process"
+	case errors.Is(err, context.Canceled):
+		// Nobody reads the answer; the status only shows in the access log
+		problem.Status = statusClientClosedRequest
+		problem.Code = "client_closed_request"
+		problem.Detail = "the client closed the request"
+	case kind != nil:
+		problem.Status = kindStatuses[kind]
+		problem.Code = errs.Code(err)
+		problem.Detail = err.Error()
+		problem.Errors = errs.Fields(err)
+	default:
+		slog.ErrorContext(c.Request.Context(), "request failed",
+			"method", c.Request.Method,
+			"path", c.Request.URL.Path,
+			"error", err,
+		)
+		problem.Status = http.StatusInternalServerError
+		problem.Code = "internal_error"
+		problem.Detail = "an unexpected error occurred"
+	}
+	problem.Title = http.StatusText(problem.Status)
+	if problem.Status == statusClientClosedRequest {
+		problem.Title = "Client Closed Request"
+	}
+
+	c.Header("Content-Type", problemContentType)
+	c.AbortWithStatusJSON(problem.Status, problem)
+}
+
+// parseID reads a numeric path parameter.
+func parseID(c *gin.Context, param string) (int, error) {
+	id, err := strconv.Atoi(c.Param(param))
+	if err != nil {
+		return 0, badRequest("invalid_id", param+" must be a number")
+	}
+	return id, nil
+}
@@ -0,0 +1,110 @@
+package infrastructure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/witchakornb/basic-ecommerce/domain/errs"
+)
+
+func TestErrorMiddleware(t *testing.T) {
+	fields := []errs.FieldError{{Field: "price", Reason: "must not be negative"}}
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		code   string
+		detail string
+		fields []errs.FieldError
+	}{
+		{name: "not found", err: errs.NotFound("order"), status: http.StatusNotFound, code: "order_not_found", detail: "order not found"},
+		{name: "conflict", err: errs.New(errs.ErrConflict, "cart_empty", "cart is empty"), status: http.StatusConflict, code: "cart_empty", detail: "cart is empty"},
+		{name: "insufficient stock", err: errs.ErrInsufficientStock, status: http.StatusConflict, code: "insufficient_stock", detail: "not enough stock"},
+		{name: "validation", err: errs.Invalid(fields...), status: http.StatusUnprocessableEntity, code: "validation_failed", fields: fields},
+		{name: "forbidden", err: errs.ErrForbidden, status: http.StatusForbidden, code: "forbidden", detail: "forbidden"},
+		{name: "unauthorized", err: errs.New(errs.ErrUnauthorized, "invalid_token", "invalid or expired token"), status: http.StatusUnauthorized, code: "invalid_token", detail: "invalid or expired token"},
+		{name: "unavailable", err: errs.New(errs.ErrUnavailable, "search_unavailable", "search is unavailable"), status: http.StatusServiceUnavailable, code: "search_unavailable", detail: "search is unavailable"},
+		{name: "wrapped domain error", err: fmt.Errorf("load order: %w", errs.NotFound("order")), status: http.StatusNotFound, code: "order_not_found"},
+		{name: "bad request", err: badRequest("invalid_id", "id must be a number"), status: http.StatusBadRequest, code: "invalid_id", detail: "id must be a number"},
+		{name: "deadline", err: fmt.Errorf("query: %w", context.DeadlineExceeded), status: http.StatusGatewayTimeout, code: "request_timeout", detail: "the request took too long to process"},
+		{name: "unexpected", err: errors.New("connection refused by 10.0.0.7"), status: http.StatusInternalServerError, code: "internal_error", detail: "an unexpected error occurred"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(ErrorMiddleware())
+			router.GET("/orders/:id", func(c *gin.Context) {
+				c.Error(test.err)
+			})
+
+			rec := serve(router, http.MethodGet, "/orders/7", "", nil)
+			problem := decodeProblem(t, rec, test.status, test.code)
+			if problem.Type != "about:blank" || problem.Instance != "/orders/7" {
+				t.Fatalf("problem = %+v, want type about:blank and instance /orders/7", problem)
+			}
+			if test.detail != "" && problem.Detail != test.detail {
+				t.Fatalf("detail = %q, want %q", problem.Detail, test.detail)
+			}
+			if !slices.Equal(problem.Errors, test.fields) {
+				t.Fatalf("errors = %+v, want %+v", problem.Errors, test.fields)
+			}
+		})
+	}
+}
+
+func TestErrorMiddlewareClientClosedRequest(t *testing.T) {
+	router := gin.New()
+	router.Use(ErrorMiddleware())
+	router.GET("/orders", func(c *gin.Context) {
+		c.Error(context.Canceled)
+	})
+
+	rec := serve(router, http.MethodGet, "/orders", "", nil)
+	if rec.Code != statusClientClosedRequest {
+		t.Fatalf("status = %d, want %d", rec.Code, statusClientClosedRequest)
+	}
+	if body := rec.Body.String(); !strings.Contains(body, `"title":"Client Closed Request"`) || !strings.Contains(body, `"code":"client_closed_request"`) {
+		t.Fatalf("body = %s, want a client_closed_request problem", body)
+	}
+}
+
+func TestErrorMiddlewareKeepsWrittenResponses(t *testing.T) {
+	router := gin.New()
+	router.Use(ErrorMiddleware())
+	router.GET("/orders", func(c *gin.Context) {
+		c.String(http.StatusAccepted, "queued")
+		c.Error(errs.ErrConflict)
+	})
+
+	rec := serve(router, http.MethodGet, "/orders", "", nil)
+	if rec.Code != http.StatusAccepted || rec.Body.String() != "queued" {
+		t.Fatalf("response = %d %q, want the handler's 202 queued", rec.Code, rec.Body)
+	}
+}
+
+func TestParseID(t *testing.T) {
+	router := gin.New()
+	router.Use(ErrorMiddleware())
+	router.GET("/orders/:id", func(c *gin.Context) {
+		id, err := parseID(c, "id")
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, id)
+	})
+
+	if rec := serve(router, http.MethodGet, "/orders/42", "", nil); rec.Code != http.StatusOK || rec.Body.String() != "42" {
+		t.Fatalf("response = %d %s, want 200 42", rec.Code, rec.Body)
+	}
+	problem := decodeProblem(t, serve(router, http.MethodGet, "/orders/abc", "", nil), http.StatusBadRequest, "invalid_id")
+	if problem.Detail != "id must be a number" {
+		t.Fatalf("detail = %q, want %q", problem.Detail, "id must be a number")
+	}
+}
@@ -0,0 +1,90 @@
+package infrastructure
+
+import (
+	"time"
+
+	"github.com/witchakornb/basic-ecommerce/domain/entity"
+	"github.com/witchakornb/basic-ecommerce/domain/repository"
+)
+
+// productRequest is the body of a product creation or replacement.
+type productRequest struct {
+	Name        string       `json:"name" binding:"required,max=255"`
+	Description string       `json:"description" binding:"max=2000"`
+	Price       entity.Money `json:"price" binding:"money_nonnegative"`
+	Stock       int          `json:"stock" binding:"gte=0"`
+}
+
+// newProductRequest returns the replacement that leaves the product
+// unchanged, which a merge patch is applied to.
+func newProductRequest(product entity.Product) productRequest {
+	return productRequest{Name: product.Name, Description: product.Description, Price: product.Price, Stock: product.Stock}
+}
+
+// productFields returns the product fields set by the members of a
+// productRequest patch; other members are ignored.
+func productFields(members []string) []repository.ProductField {
+	fields := make([]repository.ProductField, 0, len(members))
+	for _, member := range members {
+		switch member {
+		case "name":
+			fields = append(fields, repository.ProductName)
+		case "description":
+			fields = append(fields, repository.ProductDescription)
+		case "price":
+			fields = append(fields, repository.ProductPrice)
+		case "stock":
+			fields = append(fields, repository.ProductStock)
+		}
+	}
+	return fields
+}
+
+// product converts the request into the product entity with the ID.
+func (r productRequest) product(id int) entity.Product {
+	return entity.Product{ID: id, Name: r.Name, Description: r.Description, Price: r.Price, Stock: r.Stock}
+}
+
+// ProductResponse is the JSON representation of a product returned by the API.
+type ProductResponse struct {
+	ID          int          `json:"id"`
+	Name        string       `json:"name"`
+	Description string       `json:"description"`
+	Price       entity.Money `json:"price"`
+	Stock       int          `json:"stock"`
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+	DeletedAt   *time.Time   `json:"deleted_at,omitempty"`
+}
+
+// newProductResponse converts a product entity into a ProductResponse
+func newProductResponse(product entity.Product) ProductResponse {
+	return ProductResponse{
+		ID:          product.ID,
+		Name:        product.Name,
+		Description: product.Description,
+		Price:       product.Price,
+		Stock:       product.Stock,
+		CreatedAt:   product.CreatedAt,
+		UpdatedAt:   product.UpdatedAt,
+		DeletedAt:   deletedAt(product.DeletedAt),
+	}
+}
+
+// ProductSearchResponse is a product matching a search, with the matched terms marked
+type ProductSearchResponse struct {
+	ProductResponse
+	Rank               float64 `json:"rank"`
+	NameHighlight      string  `json:"name_highlight"`
+	DescriptionSnippet string  `json:"description_snippet"`
+}
+
+// newProductSearchResponse converts a search result into a ProductSearchResponse
+func newProductSearchResponse(result repository.ProductSearchResult) ProductSearchResponse {
+	return ProductSearchResponse{
+		ProductResponse:    newProductResponse(result.Product),
+		Rank:               result.Rank,
+		NameHighlight:      result.NameHighlight,
+		DescriptionSnippet: result.DescriptionSnippet,
+	}
+}
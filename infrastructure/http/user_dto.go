@@ -0,0 +1,64 @@
+package infrastructure
+
+import (
+	"time"
+
+	"github.com/witchakornb/basic-ecommerce/domain/entity"
+)
+
+// createUserRequest is the body of a registration.
+type createUserRequest struct {
+	Username string `json:"username" binding:"required,max=50"`
+	Email    string `json:"email" binding:"required,email,max=255"`
+	Password string `json:"password" binding:"required,min=8,max=72"`
+}
+
+// user converts the request into a user entity with the customer role.
+func (r createUserRequest) user() entity.User {
+	return entity.User{Username: r.Username, Email: r.Email, Password: r.Password, Role: entity.RoleCustomer}
+}
+
+// updateUserRequest is the body of a user update. An empty password or
+// role keeps the current one.
+type updateUserRequest struct {
+	Username string      `json:"username" binding:"required,max=50"`
+	Email    string      `json:"email" binding:"required,email,max=255"`
+	Password string      `json:"password,omitempty" binding:"omitempty,min=8,max=72"`
+	Role     entity.Role `json:"role,omitempty" binding:"omitempty,oneof=customer staff admin"`
+}
+
+// newUpdateUserRequest returns the update that leaves the user unchanged,
+// which a merge patch is applied to.
+func newUpdateUserRequest(user entity.User) updateUserRequest {
+	return updateUserRequest{Username: user.Username, Email: user.Email, Role: user.Role}
+}
+
+// user converts the request into the user entity with the ID.
+func (r updateUserRequest) user(id int) entity.User {
+	return entity.User{ID: id, Username: r.Username, Email: r.Email, Password: r.Password, Role: r.Role}
+}
+
+// UserResponse is the JSON representation of a user returned by the API.
+// It deliberately has no password field.
+type UserResponse struct {
+	ID        int         `json:"id"`
+	Username  string      `json:"username"`
+	Email     string      `json:"email"`
+	Role      entity.Role `json:"role"`
+	CreatedAt time.Time   `json:"created_at"`
+	UpdatedAt time.Time   `json:"updated_at"`
+	DeletedAt *time.Time  `json:"deleted_at,omitempty"`
+}
+
+// newUserResponse converts a user entity into a UserResponse
+func newUserResponse(user entity.User) UserResponse {
+	return UserResponse{
+		ID:        user.ID,
+		Username:  user.Username,
+		Email:     user.Email,
+		Role:      user.Role,
+		CreatedAt: user.CreatedAt,
+		UpdatedAt: user.UpdatedAt,
+		DeletedAt: deletedAt(user.DeletedAt),
+	}
+}
@@ -0,0 +1,132 @@
+package infrastructure
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/witchakornb/basic-ecommerce/domain/entity"
+	"github.com/witchakornb/basic-ecommerce/domain/errs"
+)
+
+// RegisterValidators configures the validator Gin binds request bodies
+// with: fields are reported by their JSON names, and the custom tags used
+// by the request types of this package are registered. It must be called
+// once before the router serves requests.
+func RegisterValidators() error {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return errors.New("unexpected validator engine")
+	}
+	v.RegisterTagNameFunc(jsonFieldName)
+	return v.RegisterValidation("money_nonnegative", func(fl validator.FieldLevel) bool {
+		money, ok := fl.Field().Interface().(entity.Money)
+		return ok && !money.IsNegative()
+	})
+}
+
+// jsonFieldName returns the name a struct field has in JSON.
+func jsonFieldName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	switch name {
+	case "-":
+		return ""
+	case "":
+		return field.Name
+	}
+	return name
+}
+
+// bindError classifies an error of c.ShouldBindJSON: a body that breaks a
+// binding rule or holds a value the domain rejects (such as an invalid
+// amount of money) is a validation error listing the invalid fields,
+// anything else is malformed.
+func bindError(err error) error {
+	var reqErr *requestError
+	if errs.KindOf(err) != nil || errors.As(err, &reqErr) {
+		return err
+	}
+
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		fields := make([]errs.FieldError, len(validationErrs))
+		for i, fe := range validationErrs {
+			fields[i] = errs.FieldError{Field: fieldPath(fe), Reason: fieldReason(fe)}
+		}
+		return errs.Invalid(fields...)
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		return errs.Invalid(errs.FieldError{Field: typeErr.Field, Reason: "must be " + jsonTypeName(typeErr.Type)})
+	}
+	return badRequest("malformed_body", err.Error())
+}
+
+// fieldPath is the path of an invalid field within the request body, such
+// as "items[0].quantity".
+func fieldPath(fe validator.FieldError) string {
+	_, path, _ := strings.Cut(fe.Namespace(), ".")
+	return path
+}
+
+// fieldReason explains in words which rule an invalid field breaks.
+func fieldReason(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "email":
+		return "must be a valid email address"
+	case "oneof":
+		return "must be one of " + strings.ReplaceAll(fe.Param(), " ", ", ")
+	case "money_nonnegative":
+		return "must not be negative"
+	case "gt":
+		return "must be greater than " + fe.Param()
+	case "gte":
+		return "must be at least " + fe.Param()
+	case "min", "max":
+		return lengthReason(fe)
+	}
+	return fmt.Sprintf("must satisfy %s", fe.Tag())
+}
+
+// lengthReason explains a min or max rule, which limits the length of
+// strings and slices and the value of numbers.
+func lengthReason(fe validator.FieldError) string {
+	bound := "at least"
+	if fe.Tag() == "max" {
+		bound = "at most"
+	}
+	switch fe.Kind() {
+	case reflect.String:
+		return fmt.Sprintf("must be %s %s characters long", bound, fe.Param())
+	case reflect.Slice, reflect.Array, reflect.Map:
+		if fe.Tag() == "min" && fe.Param() == "1" {
+			return "must not be empty"
+		}
+		return fmt.Sprintf("must have %s %s items", bound, fe.Param())
+	}
+	return fmt.Sprintf("must be %s %s", bound, fe.Param())
+}
+
+// jsonTypeName names the JSON type a Go type is decoded from.
+func jsonTypeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	}
+	return "an object"
+}
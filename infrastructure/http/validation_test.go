@@ -0,0 +1,91 @@
+package infrastructure
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/witchakornb/basic-ecommerce/domain/entity"
+	"github.com/witchakornb/basic-ecommerce/domain/errs"
+)
+
+// validationRequest exercises the binding rules the request types use.
+type validationRequest struct {
+	Name  string       `json:"name" binding:"required,max=5"`
+	Email string       `json:"email" binding:"omitempty,email"`
+	Role  string       `json:"role" binding:"omitempty,oneof=customer staff"`
+	Price entity.Money `json:"price" binding:"money_nonnegative"`
+	Items []struct {
+		Quantity int `json:"quantity" binding:"gt=0"`
+	} `json:"items" binding:"required,min=1,dive"`
+}
+
+func TestBindErrorProblems(t *testing.T) {
+	if err := RegisterValidators(); err != nil {
+		t.Fatalf("RegisterValidators: %v", err)
+	}
+	router := gin.New()
+	router.Use(ErrorMiddleware())
+	router.POST("/things", func(c *gin.Context) {
+		var req validationRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.Error(bindError(err))
+			return
+		}
+		c.Status(http.StatusNoContent)
+	})
+
+	const price = `"price": {"amount": "1.50", "currency": "USD"}`
+	tests := []struct {
+		name   string
+		body   string
+		status int
+		code   string
+		fields []errs.FieldError
+	}{
+		{name: "valid", body: `{"name": "mug", ` + price + `, "items": [{"quantity": 1}]}`, status: http.StatusNoContent},
+		{
+			name: "missing fields", body: `{` + price + `}`, status: http.StatusUnprocessableEntity, code: "validation_failed",
+			fields: []errs.FieldError{{Field: "name", Reason: "is required"}, {Field: "items", Reason: "is required"}},
+		},
+		{
+			name: "broken rules", body: `{"name": "teapot", "email": "nope", "role": "admin", ` + price + `, "items": []}`, status: http.StatusUnprocessableEntity, code: "validation_failed",
+			fields: []errs.FieldError{
+				{Field: "name", Reason: "must be at most 5 characters long"},
+				{Field: "email", Reason: "must be a valid email address"},
+				{Field: "role", Reason: "must be one of customer, staff"},
+				{Field: "items", Reason: "must not be empty"},
+			},
+		},
+		{
+			name: "nested field", body: `{"name": "mug", ` + price + `, "items": [{"quantity": 1}, {"quantity": 0}]}`, status: http.StatusUnprocessableEntity, code: "validation_failed",
+			fields: []errs.FieldError{{Field: "items[1].quantity", Reason: "must be greater than 0"}},
+		},
+		{
+			name: "negative money", body: `{"name": "mug", "price": {"amount": "-1", "currency": "USD"}, "items": [{"quantity": 1}]}`, status: http.StatusUnprocessableEntity, code: "validation_failed",
+			fields: []errs.FieldError{{Field: "price", Reason: "must not be negative"}},
+		},
+		{
+			name: "wrong type", body: `{"name": 5, ` + price + `, "items": [{"quantity": 1}]}`, status: http.StatusUnprocessableEntity, code: "validation_failed",
+			fields: []errs.FieldError{{Field: "name", Reason: "must be a string"}},
+		},
+		{name: "money the domain rejects", body: `{"name": "mug", "price": {"amount": "1.505", "currency": "USD"}, "items": [{"quantity": 1}]}`, status: http.StatusUnprocessableEntity, code: "invalid_amount"},
+		{name: "malformed", body: `{"name": `, status: http.StatusBadRequest, code: "malformed_body"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rec := serve(router, http.MethodPost, "/things", test.body, http.Header{"Content-Type": {"application/json"}})
+			if test.code == "" {
+				if rec.Code != test.status {
+					t.Fatalf("status = %d, want %d; body %s", rec.Code, test.status, rec.Body)
+				}
+				return
+			}
+			problem := decodeProblem(t, rec, test.status, test.code)
+			if test.fields != nil && !slices.Equal(problem.Errors, test.fields) {
+				t.Fatalf("errors = %+v, want %+v", problem.Errors, test.fields)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package infrastructure
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// redacted replaces the value of sensitive attributes.
+const redacted = "[REDACTED]"
+
+// sensitiveKeys are the attribute keys whose values are never written,
+// compared case-insensitively and wherever they are nested.
+var sensitiveKeys = map[string]bool{
+	"password":      true,
+	"password_hash": true,
+	"token":         true,
+	"access_token":  true,
+	"refresh_token": true,
+	"token_hash":    true,
+	"secret":        true,
+	"jwt_secret":    true,
+	"authorization": true,
+}
+
+// requestIDKey is the context key of the request ID.
+type requestIDKey struct{}
+
+// WithRequestID returns a copy of ctx carrying the request ID, which every
+// record logged with that context is tagged with.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the request ID carried by ctx, or "" if there is none.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// New returns a logger writing records at level and above to w, as JSON or
+// as text depending on format. Records logged with a context carrying a
+// request ID get a request_id attribute, those logged within a span its
+// trace_id and span_id, and sensitive attributes such as
+// password are redacted.
+func New(w io.Writer, format string, level slog.Level) (*slog.Logger, error) {
+	opts := &slog.HandlerOptions{Level: level, ReplaceAttr: redact}
+	var handler slog.Handler
+	switch format {
+	case "json":
+		handler = slog.NewJSONHandler(w, opts)
+	case "text":
+		handler = slog.NewTextHandler(w, opts)
+	default:
+		return nil, fmt.Errorf("unknown log format %q, use json or text", format)
+	}
+	return slog.New(contextHandler{handler}), nil
+}
+
+// redact replaces the value of sensitive attributes.
+func redact(_ []string, attr slog.Attr) slog.Attr {
+	if sensitiveKeys[strings.ToLower(attr.Key)] {
+		attr.Value = slog.StringValue(redacted)
+	}
+	return attr
+}
+
+// contextHandler adds the request ID and the trace of the context to every record.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		record.AddAttrs(slog.String("request_id", id))
+	}
+	if span := trace.SpanContextFromContext(ctx); span.IsValid() {
+		record.AddAttrs(slog.String("trace_id", span.TraceID().String()), slog.String("span_id", span.SpanID().String()))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,73 @@
+package infrastructure
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestLoggerAddsRequestIDAndRedacts(t *testing.T) {
+	var out bytes.Buffer
+	logger, err := New(&out, "json", slog.LevelInfo)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	ctx := WithRequestID(context.Background(), "req-1")
+	logger.With("component", "test").InfoContext(ctx, "login",
+		"email", "alice@example.com",
+		"Password", "hunter22",
+		slog.Group("request", "refresh_token", "abc"),
+	)
+	logger.DebugContext(ctx, "below the level")
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("logged %d records, want 1:\n%s", len(lines), out.String())
+	}
+	var record map[string]any
+	if err := json.Unmarshal([]byte(lines[0]), &record); err != nil {
+		t.Fatalf("record is not JSON: %v", err)
+	}
+	if record["request_id"] != "req-1" || record["component"] != "test" || record["email"] != "alice@example.com" {
+		t.Fatalf("record = %v", record)
+	}
+	if record["Password"] != redacted || record["request"].(map[string]any)["refresh_token"] != redacted {
+		t.Fatalf("sensitive values were not redacted: %v", record)
+	}
+	if strings.Contains(out.String(), "hunter22") {
+		t.Fatal("the password was written")
+	}
+}
+
+func TestLoggerAddsTrace(t *testing.T) {
+	var out bytes.Buffer
+	logger, err := New(&out, "json", slog.LevelInfo)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	span := trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID, SpanID: spanID})
+	logger.InfoContext(trace.ContextWithSpanContext(context.Background(), span), "traced")
+
+	var record map[string]any
+	if err := json.Unmarshal(out.Bytes(), &record); err != nil {
+		t.Fatalf("record is not JSON: %v", err)
+	}
+	if record["trace_id"] != traceID.String() || record["span_id"] != spanID.String() {
+		t.Fatalf("record = %v, want the trace and span IDs", record)
+	}
+}
+
+func TestNewRejectsUnknownFormat(t *testing.T) {
+	if _, err := New(&bytes.Buffer{}, "xml", slog.LevelInfo); err == nil {
+		t.Fatal("New with an unknown format succeeded")
+	}
+}
@@ -0,0 +1,125 @@
+package infrastructure
+
+import (
+	"context"
+	"slices"
+
+	"github.com/witchakornb/basic-ecommerce/domain/entity"
+	"github.com/witchakornb/basic-ecommerce/domain/errs"
+	"github.com/witchakornb/basic-ecommerce/domain/repository"
+)
+
+// MemoryCartRepository is an in-memory implementation of the CartRepository interface.
+type MemoryCartRepository struct {
+	h handle
+}
+
+// NewMemoryCartRepository creates a new MemoryCartRepository backed by the store.
+func NewMemoryCartRepository(store *Store) repository.CartRepository {
+	return &MemoryCartRepository{h: handle{store: store}}
+}
+
+// CreateCart stores a new cart and assigns its ID. A user has at most one cart.
+func (r *MemoryCartRepository) CreateCart(ctx context.Context, cart entity.Cart) (entity.Cart, error) {
+	cart = copyCart(cart)
+	err := r.h.write(func(s *state) error {
+		for _, c := range s.carts {
+			if c.UserID == cart.UserID {
+				return duplicate("cart")
+			}
+		}
+		s.lastCartID++
+		cart.ID = s.lastCartID
+		cart.CreatedAt, cart.UpdatedAt = now(), now()
+		for i := range cart.Items {
+			s.lastCartItemID++
+			cart.Items[i].ID = s.lastCartItemID
+			cart.Items[i].CartID = cart.ID
+		}
+		s.carts[cart.ID] = copyCart(cart)
+		return nil
+	})
+	if err != nil {
+		return entity.Cart{}, err
+	}
+	return cart, nil
+}
+
+// GetCartByUserID retrieves a user's cart and its items.
+func (r *MemoryCartRepository) GetCartByUserID(ctx context.Context, userID int) (cart entity.Cart, err error) {
+	err = errs.NotFound("cart")
+	r.h.read(func(s *state) {
+		for _, c := range s.carts {
+			if c.UserID == userID {
+				cart, err = copyCart(c), nil
+				return
+			}
+		}
+	})
+	return cart, err
+}
+
+// SaveCartItem updates a stored cart item, or inserts a new one and replaces
+// the quantity and unit price of an existing item for the same product.
+func (r *MemoryCartRepository) SaveCartItem(ctx context.Context, item entity.CartItem) (entity.CartItem, error) {
+	err := r.h.write(func(s *state) error {
+		cart, ok := s.carts[item.CartID]
+		if !ok {
+			return errs.NotFound("cart")
+		}
+		cart.Items = slices.Clone(cart.Items)
+
+		i := slices.IndexFunc(cart.Items, func(existing entity.CartItem) bool {
+			if item.ID != 0 {
+				return existing.ID == item.ID
+			}
+			return existing.ProductID == item.ProductID
+		})
+		switch {
+		case i >= 0 && item.ID == 0:
+			item.ID = cart.Items[i].ID
+			cart.Items[i].Quantity = item.Quantity
+			cart.Items[i].UnitPrice = item.UnitPrice
+		case i >= 0:
+			cart.Items[i] = item
+		default:
+			if item.ID == 0 {
+				s.lastCartItemID++
+				item.ID = s.lastCartItemID
+			}
+			s.lastCartItemID = max(s.lastCartItemID, item.ID)
+			cart.Items = append(cart.Items, item)
+			slices.SortFunc(cart.Items, func(a, b entity.CartItem) int { return a.ID - b.ID })
+		}
+		s.carts[cart.ID] = cart
+		return nil
+	})
+	if err != nil {
+		return entity.CartItem{}, err
+	}
+	return item, nil
+}
+
+// DeleteCartItem removes a product from a cart.
+func (r *MemoryCartRepository) DeleteCartItem(ctx context.Context, cartID, productID int) error {
+	return r.h.write(func(s *state) error {
+		if cart, ok := s.carts[cartID]; ok {
+			cart.Items = slices.DeleteFunc(slices.Clone(cart.Items), func(item entity.CartItem) bool {
+				return item.ProductID == productID
+			})
+			s.carts[cartID] = cart
+		}
+		return nil
+	})
+}
+
+// ClearCart removes every item from a cart.
+func (r *MemoryCartRepository) ClearCart(ctx context.Context, cartID int) error {
+	return r.h.write(func(s *state) error {
+		if cart, ok := s.carts[cartID]; ok {
+			cart.Items = nil
+			s.carts[cartID] = cart
+		}
+		return nil
+	})
+}
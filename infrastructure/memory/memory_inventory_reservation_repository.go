@@ -0,0 +1,94 @@
+package infrastructure
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"time"
+
+	"github.com/witchakornb/basic-ecommerce/domain/entity"
+	"github.com/witchakornb/basic-ecommerce/domain/errs"
+	"github.com/witchakornb/basic-ecommerce/domain/repository"
+)
+
+// MemoryInventoryReservationRepository is an in-memory implementation of the InventoryReservationRepository interface.
+type MemoryInventoryReservationRepository struct {
+	h handle
+}
+
+// NewMemoryInventoryReservationRepository creates a new MemoryInventoryReservationRepository backed by the store.
+func NewMemoryInventoryReservationRepository(store *Store) repository.InventoryReservationRepository {
+	return &MemoryInventoryReservationRepository{h: handle{store: store}}
+}
+
+// CreateReservation stores a new reservation and assigns its ID.
+func (r *MemoryInventoryReservationRepository) CreateReservation(ctx context.Context, reservation entity.InventoryReservation) (entity.InventoryReservation, error) {
+	if reservation.Status == "" {
+		reservation.Status = entity.ReservationHeld
+	}
+	r.h.write(func(s *state) error {
+		s.lastReservationID++
+		reservation.ID = s.lastReservationID
+		reservation.CreatedAt, reservation.UpdatedAt = now(), now()
+		s.reservations[reservation.ID] = reservation
+		return nil
+	})
+	return reservation, nil
+}
+
+// GetOrderReservations retrieves the reservations of an order.
+func (r *MemoryInventoryReservationRepository) GetOrderReservations(ctx context.Context, orderID int) ([]entity.InventoryReservation, error) {
+	reservations := []entity.InventoryReservation{}
+	r.h.read(func(s *state) {
+		for _, reservation := range s.reservations {
+			if reservation.OrderID == orderID {
+				reservations = append(reservations, reservation)
+			}
+		}
+	})
+	slices.SortFunc(reservations, func(a, b entity.InventoryReservation) int { return cmp.Compare(a.ID, b.ID) })
+	return reservations, nil
+}
+
+// GetExpiredReservations retrieves the held reservations that expired at or before now.
+func (r *MemoryInventoryReservationRepository) GetExpiredReservations(ctx context.Context, now time.Time, limit int) ([]entity.InventoryReservation, error) {
+	reservations := []entity.InventoryReservation{}
+	r.h.read(func(s *state) {
+		for _, reservation := range s.reservations {
+			if reservation.IsExpired(now) {
+				reservations = append(reservations, reservation)
+			}
+		}
+	})
+	slices.SortFunc(reservations, func(a, b entity.InventoryReservation) int {
+		return cmp.Or(a.ExpiresAt.Compare(b.ExpiresAt), cmp.Compare(a.ID, b.ID))
+	})
+	return reservations[:min(limit, len(reservations))], nil
+}
+
+// CommitReservation marks a held reservation as committed.
+func (r *MemoryInventoryReservationRepository) CommitReservation(ctx context.Context, id int) error {
+	return r.settle(id, entity.ReservationCommitted)
+}
+
+// ReleaseReservation marks a held reservation as released.
+func (r *MemoryInventoryReservationRepository) ReleaseReservation(ctx context.Context, id int) error {
+	return r.settle(id, entity.ReservationReleased)
+}
+
+// settle moves a held reservation to status.
+func (r *MemoryInventoryReservationRepository) settle(id int, status entity.ReservationStatus) error {
+	return r.h.write(func(s *state) error {
+		reservation, ok := s.reservations[id]
+		if !ok {
+			return errs.NotFound("reservation")
+		}
+		if reservation.Status != entity.ReservationHeld {
+			return repository.ErrReservationNotHeld
+		}
+		reservation.Status = status
+		reservation.UpdatedAt = now()
+		s.reservations[id] = reservation
+		return nil
+	})
+}
@@ -0,0 +1,143 @@
+package infrastructure
+
+import (
+	"context"
+	"time"
+
+	"github.com/witchakornb/basic-ecommerce/domain/entity"
+	"github.com/witchakornb/basic-ecommerce/domain/errs"
+	"github.com/witchakornb/basic-ecommerce/domain/repository"
+	"gorm.io/gorm"
+)
+
+// MemoryOrderRepository is an in-memory implementation of the OrderRepository interface.
+type MemoryOrderRepository struct {
+	h handle
+}
+
+// NewMemoryOrderRepository creates a new MemoryOrderRepository backed by the store.
+func NewMemoryOrderRepository(store *Store) repository.OrderRepository {
+	return &MemoryOrderRepository{h: handle{store: store}}
+}
+
+// CreateOrder stores a new order and its items and assigns their IDs.
+func (r *MemoryOrderRepository) CreateOrder(ctx context.Context, order entity.Order) (entity.Order, error) {
+	order = copyOrder(order)
+	if order.Status == "" {
+		order.Status = entity.OrderStatusPending
+	}
+	r.h.write(func(s *state) error {
+		s.lastOrderID++
+		order.ID = s.lastOrderID
+		order.CreatedAt, order.UpdatedAt = now(), now()
+		for i := range order.Items {
+			s.lastOrderItemID++
+			order.Items[i].ID = s.lastOrderItemID
+			order.Items[i].OrderID = order.ID
+		}
+		s.orders[order.ID] = copyOrder(order)
+		return nil
+	})
+	return order, nil
+}
+
+// GetOrderByID retrieves an order and its items by ID.
+func (r *MemoryOrderRepository) GetOrderByID(ctx context.Context, id int) (order entity.Order, err error) {
+	r.h.read(func(s *state) {
+		stored, ok := s.orders[id]
+		if !ok || stored.DeletedAt.Valid {
+			err = errs.NotFound("order")
+			return
+		}
+		order = copyOrder(stored)
+	})
+	return order, err
+}
+
+// orderSortFields are the keys orders can be sorted by.
+var orderSortFields = map[string]sortField[entity.Order]{
+	"id":          func(o entity.Order) any { return o.ID },
+	"status":      func(o entity.Order) any { return o.Status },
+	"total_price": func(o entity.Order) any { return o.TotalPrice.Amount },
+	"created_at":  func(o entity.Order) any { return o.CreatedAt },
+}
+
+// GetAllOrders retrieves one page of the orders matching the filter, and their items.
+func (r *MemoryOrderRepository) GetAllOrders(ctx context.Context, filter repository.OrderFilter, opts repository.ListOptions) (repository.Page[entity.Order], error) {
+	var orders []entity.Order
+	r.h.read(func(s *state) {
+		for _, order := range s.orders {
+			if order.DeletedAt.Valid && !filter.IncludeDeleted {
+				continue
+			}
+			if filter.CustomerID != 0 && order.CustomerID != filter.CustomerID {
+				continue
+			}
+			if filter.Status != "" && order.Status != filter.Status {
+				continue
+			}
+			if !createdWithin(order.CreatedAt, filter.CreatedFrom, filter.CreatedTo) {
+				continue
+			}
+			orders = append(orders, copyOrder(order))
+		}
+	})
+	return paginate(orders, opts, orderSortFields)
+}
+
+// UpdateOrderStatus moves an order from one status to another.
+func (r *MemoryOrderRepository) UpdateOrderStatus(ctx context.Context, id int, from, to entity.OrderStatus) error {
+	return r.h.write(func(s *state) error {
+		order, ok := s.orders[id]
+		if !ok || order.DeletedAt.Valid {
+			return errs.NotFound("order")
+		}
+		if order.Status != from {
+			return repository.ErrOrderStatusChanged
+		}
+		order.Status = to
+		order.UpdatedAt = now()
+		s.orders[id] = order
+		return nil
+	})
+}
+
+// DeleteOrder soft-deletes an order by ID if it still has the status; its items are kept.
+func (r *MemoryOrderRepository) DeleteOrder(ctx context.Context, id int, status entity.OrderStatus) error {
+	return r.h.write(func(s *state) error {
+		order, ok := s.orders[id]
+		if !ok || order.DeletedAt.Valid {
+			return errs.NotFound("order")
+		}
+		if order.Status != status {
+			return repository.ErrOrderStatusChanged
+		}
+		order.DeletedAt = softDelete()
+		s.orders[id] = order
+		return nil
+	})
+}
+
+// RestoreOrder undeletes a soft-deleted order.
+func (r *MemoryOrderRepository) RestoreOrder(ctx context.Context, id int) (order entity.Order, err error) {
+	err = r.h.write(func(s *state) error {
+		stored, ok := s.orders[id]
+		if !ok {
+			return errs.NotFound("order")
+		}
+		stored.DeletedAt = gorm.DeletedAt{}
+		stored.UpdatedAt = now()
+		s.orders[id] = stored
+		order = copyOrder(stored)
+		return nil
+	})
+	if err != nil {
+		return entity.Order{}, err
+	}
+	return order, nil
+}
+
+// createdWithin reports whether a creation time lies in the inclusive range.
+func createdWithin(created time.Time, from, to *time.Time) bool {
+	return (from == nil || !created.Before(*from)) && (to == nil || !created.After(*to))
+}
@@ -0,0 +1,150 @@
+package infrastructure
+
+import (
+	"context"
+
+	"github.com/witchakornb/basic-ecommerce/domain/entity"
+	"github.com/witchakornb/basic-ecommerce/domain/errs"
+	"github.com/witchakornb/basic-ecommerce/domain/repository"
+	"gorm.io/gorm"
+)
+
+// MemoryProductRepository is an in-memory implementation of the ProductRepository interface.
+type MemoryProductRepository struct {
+	h handle
+}
+
+// NewMemoryProductRepository creates a new MemoryProductRepository backed by the store.
+func NewMemoryProductRepository(store *Store) repository.ProductRepository {
+	return &MemoryProductRepository{h: handle{store: store}}
+}
+
+// CreateProduct stores a new product and assigns its ID.
+func (r *MemoryProductRepository) CreateProduct(ctx context.Context, product entity.Product) (entity.Product, error) {
+	r.h.write(func(s *state) error {
+		s.lastProductID++
+		product.ID = s.lastProductID
+		product.CreatedAt, product.UpdatedAt = now(), now()
+		s.products[product.ID] = product
+		return nil
+	})
+	return product, nil
+}
+
+// GetProductByID retrieves a product by ID.
+func (r *MemoryProductRepository) GetProductByID(ctx context.Context, id int) (product entity.Product, err error) {
+	r.h.read(func(s *state) {
+		var ok bool
+		if product, ok = s.products[id]; !ok || product.DeletedAt.Valid {
+			product, err = entity.Product{}, errs.NotFound("product")
+		}
+	})
+	return product, err
+}
+
+// productSortFields are the keys products can be sorted by.
+var productSortFields = map[string]sortField[entity.Product]{
+	"id":         func(p entity.Product) any { return p.ID },
+	"name":       func(p entity.Product) any { return p.Name },
+	"price":      func(p entity.Product) any { return p.Price.Amount },
+	"stock":      func(p entity.Product) any { return p.Stock },
+	"created_at": func(p entity.Product) any { return p.CreatedAt },
+}
+
+// GetAllProducts retrieves one page of the products matching the filter.
+func (r *MemoryProductRepository) GetAllProducts(ctx context.Context, filter repository.ProductFilter, opts repository.ListOptions) (repository.Page[entity.Product], error) {
+	var products []entity.Product
+	r.h.read(func(s *state) {
+		for _, product := range s.products {
+			if product.DeletedAt.Valid && !filter.IncludeDeleted {
+				continue
+			}
+			if filter.MinPrice != nil && (product.Price.Currency != filter.MinPrice.Currency || product.Price.Amount < filter.MinPrice.Amount) {
+				continue
+			}
+			if filter.MaxPrice != nil && (product.Price.Currency != filter.MaxPrice.Currency || product.Price.Amount > filter.MaxPrice.Amount) {
+				continue
+			}
+			if filter.InStock && product.Stock <= 0 {
+				continue
+			}
+			products = append(products, product)
+		}
+	})
+	return paginate(products, opts, productSortFields)
+}
+
+// UpdateProduct writes the fields of a stored product.
+func (r *MemoryProductRepository) UpdateProduct(ctx context.Context, product entity.Product, fields []repository.ProductField) (stored entity.Product, err error) {
+	err = r.h.write(func(s *state) error {
+		var ok bool
+		stored, ok = s.products[product.ID]
+		if !ok || stored.DeletedAt.Valid {
+			return errs.NotFound("product")
+		}
+		for _, field := range fields {
+			switch field {
+			case repository.ProductName:
+				stored.Name = product.Name
+			case repository.ProductDescription:
+				stored.Description = product.Description
+			case repository.ProductPrice:
+				stored.Price = product.Price
+			case repository.ProductStock:
+				stored.Stock = product.Stock
+			}
+		}
+		stored.UpdatedAt = now()
+		s.products[product.ID] = stored
+		return nil
+	})
+	if err != nil {
+		return entity.Product{}, err
+	}
+	return stored, nil
+}
+
+// AdjustStock atomically adds delta to the product's stock, even if it is soft-deleted.
+func (r *MemoryProductRepository) AdjustStock(ctx context.Context, id int, delta int) error {
+	return r.h.write(func(s *state) error {
+		product, ok := s.products[id]
+		if !ok {
+			return errs.NotFound("product")
+		}
+		if product.Stock+delta < 0 {
+			return repository.ErrInsufficientStock
+		}
+		product.Stock += delta
+		s.products[id] = product
+		return nil
+	})
+}
+
+// DeleteProduct soft-deletes a product by ID. Deleting an unknown product is not an error.
+func (r *MemoryProductRepository) DeleteProduct(ctx context.Context, id int) error {
+	return r.h.write(func(s *state) error {
+		if product, ok := s.products[id]; ok && !product.DeletedAt.Valid {
+			product.DeletedAt = softDelete()
+			s.products[id] = product
+		}
+		return nil
+	})
+}
+
+// RestoreProduct undeletes a soft-deleted product.
+func (r *MemoryProductRepository) RestoreProduct(ctx context.Context, id int) (product entity.Product, err error) {
+	err = r.h.write(func(s *state) error {
+		var ok bool
+		if product, ok = s.products[id]; !ok {
+			return errs.NotFound("product")
+		}
+		product.DeletedAt = gorm.DeletedAt{}
+		product.UpdatedAt = now()
+		s.products[id] = product
+		return nil
+	})
+	if err != nil {
+		return entity.Product{}, err
+	}
+	return product, nil
+}
@@ -0,0 +1,134 @@
+package infrastructure
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/witchakornb/basic-ecommerce/domain/repository"
+)
+
+// snippetWords is the number of description words kept around the first match.
+const snippetWords = 16
+
+// MemoryProductSearch is an in-memory implementation of the ProductSearch
+// interface. It scans the products on every query, which is fine for tests;
+// ranking weighs name matches like the FTS5 index does, but the scores
+// themselves are not comparable with bm25.
+type MemoryProductSearch struct {
+	h handle
+}
+
+// NewMemoryProductSearch creates a new MemoryProductSearch over the products of the store.
+func NewMemoryProductSearch(store *Store) repository.ProductSearch {
+	return &MemoryProductSearch{h: handle{store: store}}
+}
+
+// SearchProducts returns up to limit products whose name or description
+// contains a word starting with every term of the query.
+func (s *MemoryProductSearch) SearchProducts(ctx context.Context, query string, limit int) ([]repository.ProductSearchResult, error) {
+	terms := searchWords(strings.ToLower(query))
+	if len(terms) == 0 {
+		return []repository.ProductSearchResult{}, nil
+	}
+
+	results := []repository.ProductSearchResult{}
+	s.h.read(func(st *state) {
+		for _, product := range st.products {
+			if product.DeletedAt.Valid {
+				continue
+			}
+			name := searchWords(product.Name)
+			description := searchWords(product.Description)
+			score, ok := 0, true
+			for _, term := range terms {
+				nameHits, descriptionHits := countPrefixed(name, term), countPrefixed(description, term)
+				if nameHits+descriptionHits == 0 {
+					ok = false
+					break
+				}
+				score += 10*nameHits + descriptionHits
+			}
+			if !ok {
+				continue
+			}
+			results = append(results, repository.ProductSearchResult{
+				Product:            product,
+				Rank:               -float64(score),
+				NameHighlight:      highlight(product.Name, terms, 0),
+				DescriptionSnippet: highlight(product.Description, terms, snippetWords),
+			})
+		}
+	})
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Rank != results[j].Rank {
+			return results[i].Rank < results[j].Rank
+		}
+		return results[i].Product.ID < results[j].Product.ID
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+// searchWords splits text into words the way the FTS5 tokenizer does.
+func searchWords(text string) []string {
+	return strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func countPrefixed(words []string, term string) int {
+	n := 0
+	for _, word := range words {
+		if strings.HasPrefix(strings.ToLower(word), term) {
+			n++
+		}
+	}
+	return n
+}
+
+// highlight wraps the words of text that start with a term in <mark> tags.
+// With a positive window only that many words around the first match are
+// kept, and cut ends are marked with an ellipsis.
+func highlight(text string, terms []string, window int) string {
+	fields := strings.Fields(text)
+	first := -1
+	for i, field := range fields {
+		for _, word := range searchWords(field) {
+			if matchesAny(word, terms) {
+				fields[i] = strings.Replace(fields[i], word, "<mark>"+word+"</mark>", 1)
+				if first < 0 {
+					first = i
+				}
+			}
+		}
+	}
+	if window <= 0 || len(fields) <= window {
+		return strings.Join(fields, " ")
+	}
+
+	start := max(0, first-window/2)
+	end := min(len(fields), start+window)
+	start = max(0, end-window)
+	snippet := strings.Join(fields[start:end], " ")
+	if start > 0 {
+		snippet = "…" + snippet
+	}
+	if end < len(fields) {
+		snippet += "…"
+	}
+	return snippet
+}
+
+func matchesAny(word string, terms []string) bool {
+	for _, term := range terms {
+		if strings.HasPrefix(strings.ToLower(word), term) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,92 @@
+package infrastructure
+
+import (
+	"context"
+	"time"
+
+	"github.com/witchakornb/basic-ecommerce/domain/entity"
+	"github.com/witchakornb/basic-ecommerce/domain/errs"
+	"github.com/witchakornb/basic-ecommerce/domain/repository"
+)
+
+// MemoryRefreshTokenRepository is an in-memory implementation of the RefreshTokenRepository interface.
+type MemoryRefreshTokenRepository struct {
+	h handle
+}
+
+// NewMemoryRefreshTokenRepository creates a new MemoryRefreshTokenRepository backed by the store.
+func NewMemoryRefreshTokenRepository(store *Store) repository.RefreshTokenRepository {
+	return &MemoryRefreshTokenRepository{h: handle{store: store}}
+}
+
+// CreateRefreshToken stores a new refresh token and assigns its ID.
+func (r *MemoryRefreshTokenRepository) CreateRefreshToken(ctx context.Context, token entity.RefreshToken) (entity.RefreshToken, error) {
+	if token.CreatedAt.IsZero() {
+		token.CreatedAt = time.Now()
+	}
+	err := r.h.write(func(s *state) error {
+		for _, t := range s.refreshTokens {
+			if t.TokenHash == token.TokenHash {
+				return duplicate("refresh_token")
+			}
+		}
+		s.lastRefreshTokenID++
+		token.ID = s.lastRefreshTokenID
+		s.refreshTokens[token.ID] = copyRefreshToken(token)
+		return nil
+	})
+	if err != nil {
+		return entity.RefreshToken{}, err
+	}
+	return token, nil
+}
+
+// GetRefreshTokenByHash retrieves a refresh token by its hash.
+func (r *MemoryRefreshTokenRepository) GetRefreshTokenByHash(ctx context.Context, tokenHash string) (token entity.RefreshToken, err error) {
+	err = errs.NotFound("refresh_token")
+	r.h.read(func(s *state) {
+		for _, t := range s.refreshTokens {
+			if t.TokenHash == tokenHash {
+				token, err = copyRefreshToken(t), nil
+				return
+			}
+		}
+	})
+	return token, err
+}
+
+// RevokeRefreshToken marks a refresh token as revoked.
+func (r *MemoryRefreshTokenRepository) RevokeRefreshToken(ctx context.Context, id int) error {
+	return r.h.write(func(s *state) error {
+		token, ok := s.refreshTokens[id]
+		if !ok {
+			return errs.NotFound("refresh_token")
+		}
+		if token.RevokedAt != nil {
+			return repository.ErrRefreshTokenRevoked
+		}
+		s.refreshTokens[id] = revoke(token, time.Now())
+		return nil
+	})
+}
+
+// RevokeUserRefreshTokens marks every active refresh token of a user as revoked.
+func (r *MemoryRefreshTokenRepository) RevokeUserRefreshTokens(ctx context.Context, userID int) error {
+	return r.h.write(func(s *state) error {
+		now := time.Now()
+		for id, token := range s.refreshTokens {
+			if token.UserID == userID {
+				s.refreshTokens[id] = revoke(token, now)
+			}
+		}
+		return nil
+	})
+}
+
+// revoke sets the revocation time of a token that is not revoked yet.
+func revoke(token entity.RefreshToken, now time.Time) entity.RefreshToken {
+	if token.RevokedAt == nil {
+		token.RevokedAt = &now
+	}
+	return token
+}
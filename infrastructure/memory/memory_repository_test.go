@@ -0,0 +1,22 @@
+package infrastructure
+
+import (
+	"testing"
+
+	"github.com/witchakornb/basic-ecommerce/domain/repository/repositorytest"
+)
+
+func TestMemoryRepositoryContract(t *testing.T) {
+	repositorytest.Run(t, func(t *testing.T) repositorytest.Backend {
+		store := NewStore()
+		return repositorytest.Backend{
+			Users:         NewMemoryUserRepository(store),
+			Products:      NewMemoryProductRepository(store),
+			Orders:        NewMemoryOrderRepository(store),
+			RefreshTokens: NewMemoryRefreshTokenRepository(store),
+			Carts:         NewMemoryCartRepository(store),
+			Reservations:  NewMemoryInventoryReservationRepository(store),
+			UnitOfWork:    NewMemoryUnitOfWork(store),
+		}
+	})
+}
@@ -0,0 +1,87 @@
+package infrastructure
+
+import (
+	"context"
+
+	"github.com/witchakornb/basic-ecommerce/domain/repository"
+)
+
+// memoryUnitOfWork implements the UnitOfWork interface for the in-memory store.
+type memoryUnitOfWork struct {
+	store *Store
+}
+
+// memoryUnitOfWorkStore implements the UnitOfWorkStore interface.
+type memoryUnitOfWorkStore struct {
+	userRepo        repository.UserRepository
+	productRepo     repository.ProductRepository
+	orderRepo       repository.OrderRepository
+	tokenRepo       repository.RefreshTokenRepository
+	cartRepo        repository.CartRepository
+	reservationRepo repository.InventoryReservationRepository
+}
+
+func (s *memoryUnitOfWorkStore) Users() repository.UserRepository {
+	return s.userRepo
+}
+
+func (s *memoryUnitOfWorkStore) Products() repository.ProductRepository {
+	return s.productRepo
+}
+
+func (s *memoryUnitOfWorkStore) Orders() repository.OrderRepository {
+	return s.orderRepo
+}
+
+func (s *memoryUnitOfWorkStore) RefreshTokens() repository.RefreshTokenRepository {
+	return s.tokenRepo
+}
+
+func (s *memoryUnitOfWorkStore) Carts() repository.CartRepository {
+	return s.cartRepo
+}
+
+func (s *memoryUnitOfWorkStore) Reservations() repository.InventoryReservationRepository {
+	return s.reservationRepo
+}
+
+// NewMemoryUnitOfWork creates a new in-memory unit of work.
+//
+// Transactions are serialized: Execute holds the store lock until fn
+// returns, so fn must only use the repositories of the UnitOfWorkStore it
+// is given. Calling a repository created with the store directly from fn
+// deadlocks.
+func NewMemoryUnitOfWork(store *Store) repository.UnitOfWork {
+	return &memoryUnitOfWork{store: store}
+}
+
+// Execute runs a function against a private copy of the store. The copy
+// replaces the store content if fn succeeds and is dropped if fn returns an
+// error or panics, which rolls back every change made through the store.
+// It is also dropped if ctx is done before fn returns.
+func (uow *memoryUnitOfWork) Execute(ctx context.Context, fn func(ctx context.Context, store repository.UnitOfWorkStore) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	uow.store.mu.Lock()
+	defer uow.store.mu.Unlock()
+
+	tx := uow.store.state.clone()
+	h := handle{store: uow.store, tx: tx}
+	store := &memoryUnitOfWorkStore{
+		userRepo:        &MemoryUserRepository{h: h},
+		productRepo:     &MemoryProductRepository{h: h},
+		orderRepo:       &MemoryOrderRepository{h: h},
+		tokenRepo:       &MemoryRefreshTokenRepository{h: h},
+		cartRepo:        &MemoryCartRepository{h: h},
+		reservationRepo: &MemoryInventoryReservationRepository{h: h},
+	}
+	if err := fn(ctx, store); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	uow.store.state = tx
+	return nil
+}
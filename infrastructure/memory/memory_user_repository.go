@@ -0,0 +1,152 @@
+package infrastructure
+
+import (
+	"context"
+
+	"github.com/witchakornb/basic-ecommerce/domain/entity"
+	"github.com/witchakornb/basic-ecommerce/domain/errs"
+	"github.com/witchakornb/basic-ecommerce/domain/repository"
+	"gorm.io/gorm"
+)
+
+// MemoryUserRepository is an in-memory implementation of the UserRepository interface.
+type MemoryUserRepository struct {
+	h handle
+}
+
+// NewMemoryUserRepository creates a new MemoryUserRepository backed by the store.
+func NewMemoryUserRepository(store *Store) repository.UserRepository {
+	return &MemoryUserRepository{h: handle{store: store}}
+}
+
+// CreateUser stores a new user and assigns its ID.
+func (r *MemoryUserRepository) CreateUser(ctx context.Context, user entity.User) (entity.User, error) {
+	err := r.h.write(func(s *state) error {
+		if emailTaken(s, user.Email, 0) {
+			return duplicate("user")
+		}
+		s.lastUserID++
+		user.ID = s.lastUserID
+		user.CreatedAt, user.UpdatedAt = now(), now()
+		s.users[user.ID] = user
+		return nil
+	})
+	if err != nil {
+		return entity.User{}, err
+	}
+	return user, nil
+}
+
+// GetUserByID retrieves a user by ID.
+func (r *MemoryUserRepository) GetUserByID(ctx context.Context, id int) (user entity.User, err error) {
+	r.h.read(func(s *state) {
+		var ok bool
+		if user, ok = s.users[id]; !ok || user.DeletedAt.Valid {
+			user, err = entity.User{}, errs.NotFound("user")
+		}
+	})
+	return user, err
+}
+
+// GetUserByEmail retrieves a user by email address.
+func (r *MemoryUserRepository) GetUserByEmail(ctx context.Context, email string) (user entity.User, err error) {
+	err = errs.NotFound("user")
+	r.h.read(func(s *state) {
+		for _, u := range s.users {
+			if u.Email == email && !u.DeletedAt.Valid {
+				user, err = u, nil
+				return
+			}
+		}
+	})
+	return user, err
+}
+
+// userSortFields are the keys users can be sorted by.
+var userSortFields = map[string]sortField[entity.User]{
+	"id":         func(u entity.User) any { return u.ID },
+	"username":   func(u entity.User) any { return u.Username },
+	"email":      func(u entity.User) any { return u.Email },
+	"created_at": func(u entity.User) any { return u.CreatedAt },
+}
+
+// GetAllUsers retrieves one page of the users matching the filter.
+func (r *MemoryUserRepository) GetAllUsers(ctx context.Context, filter repository.UserFilter, opts repository.ListOptions) (repository.Page[entity.User], error) {
+	var users []entity.User
+	r.h.read(func(s *state) {
+		for _, user := range s.users {
+			if user.DeletedAt.Valid && !filter.IncludeDeleted {
+				continue
+			}
+			if filter.Role != "" && user.Role != filter.Role {
+				continue
+			}
+			if filter.Email != "" && user.Email != filter.Email {
+				continue
+			}
+			users = append(users, user)
+		}
+	})
+	return paginate(users, opts, userSortFields)
+}
+
+// UpdateUser replaces a stored user, or stores it if its ID is unknown.
+func (r *MemoryUserRepository) UpdateUser(ctx context.Context, user entity.User) (entity.User, error) {
+	err := r.h.write(func(s *state) error {
+		if emailTaken(s, user.Email, user.ID) {
+			return duplicate("user")
+		}
+		if user.ID == 0 {
+			s.lastUserID++
+			user.ID = s.lastUserID
+		}
+		s.lastUserID = max(s.lastUserID, user.ID)
+		user.UpdatedAt = now()
+		s.users[user.ID] = user
+		return nil
+	})
+	if err != nil {
+		return entity.User{}, err
+	}
+	return user, nil
+}
+
+// DeleteUser soft-deletes a user by ID. Deleting an unknown user is not an error.
+func (r *MemoryUserRepository) DeleteUser(ctx context.Context, id int) error {
+	return r.h.write(func(s *state) error {
+		if user, ok := s.users[id]; ok && !user.DeletedAt.Valid {
+			user.DeletedAt = softDelete()
+			s.users[id] = user
+		}
+		return nil
+	})
+}
+
+// RestoreUser undeletes a soft-deleted user.
+func (r *MemoryUserRepository) RestoreUser(ctx context.Context, id int) (user entity.User, err error) {
+	err = r.h.write(func(s *state) error {
+		var ok bool
+		if user, ok = s.users[id]; !ok {
+			return errs.NotFound("user")
+		}
+		user.DeletedAt = gorm.DeletedAt{}
+		user.UpdatedAt = now()
+		s.users[id] = user
+		return nil
+	})
+	if err != nil {
+		return entity.User{}, err
+	}
+	return user, nil
+}
+
+// emailTaken reports whether another user than exceptID already has the
+// email. Soft-deleted users keep their email, as in the unique index.
+func emailTaken(s *state, email string, exceptID int) bool {
+	for _, u := range s.users {
+		if u.Email == email && u.ID != exceptID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,162 @@
+package infrastructure
+
+import (
+	"cmp"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/witchakornb/basic-ecommerce/domain/repository"
+)
+
+// sortField returns the value a list is sorted by for a public sort key.
+type sortField[T any] func(T) any
+
+// paginate sorts the matching items and cuts one page out of them, with the
+// same semantics as the GORM repositories: sorting is completed with the id
+// and cursors carry the sort values of the last item of a page.
+func paginate[T any](items []T, opts repository.ListOptions, fields map[string]sortField[T]) (repository.Page[T], error) {
+	limit := opts.Limit
+	if limit == 0 {
+		limit = repository.DefaultListLimit
+	}
+	if limit < 0 || limit > repository.MaxListLimit || opts.Offset < 0 {
+		return repository.Page[T]{}, fmt.Errorf("%w: limit must be between 1 and %d", repository.ErrInvalidListOptions, repository.MaxListLimit)
+	}
+
+	keys := make([]repository.SortKey, 0, len(opts.Sort)+1)
+	hasID := false
+	for _, key := range opts.Sort {
+		if _, ok := fields[key.Field]; !ok {
+			return repository.Page[T]{}, fmt.Errorf("%w: unknown sort key %q", repository.ErrInvalidListOptions, key.Field)
+		}
+		hasID = hasID || key.Field == "id"
+		keys = append(keys, key)
+	}
+	if !hasID {
+		keys = append(keys, repository.SortKey{Field: "id"})
+	}
+
+	compare := func(a, b T) int {
+		for _, key := range keys {
+			c := compareValues(fields[key.Field](a), fields[key.Field](b))
+			if key.Desc {
+				c = -c
+			}
+			if c != 0 {
+				return c
+			}
+		}
+		return 0
+	}
+	sort.SliceStable(items, func(i, j int) bool { return compare(items[i], items[j]) < 0 })
+
+	total := int64(len(items))
+	start := opts.Offset
+	if opts.Cursor != "" {
+		after, err := decodeCursor(opts.Cursor, keys, fields)
+		if err != nil {
+			return repository.Page[T]{}, err
+		}
+		start = sort.Search(len(items), func(i int) bool {
+			return compareToValues(items[i], after, keys, fields) > 0
+		})
+	}
+	if start > len(items) {
+		start = len(items)
+	}
+	items = items[start:]
+
+	page := repository.Page[T]{Items: items, Total: total}
+	if len(items) > limit {
+		page.Items = items[:limit]
+		cursor, err := encodeCursor(page.Items[limit-1], keys, fields)
+		if err != nil {
+			return repository.Page[T]{}, err
+		}
+		page.NextCursor = cursor
+	}
+	return page, nil
+}
+
+// compareToValues compares an item with the sort values stored in a cursor.
+func compareToValues[T any](item T, values []any, keys []repository.SortKey, fields map[string]sortField[T]) int {
+	for i, key := range keys {
+		c := compareValues(fields[key.Field](item), values[i])
+		if key.Desc {
+			c = -c
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+func encodeCursor[T any](last T, keys []repository.SortKey, fields map[string]sortField[T]) (string, error) {
+	values := make([]any, len(keys))
+	for i, key := range keys {
+		values[i] = fields[key.Field](last)
+	}
+	raw, err := json.Marshal(values)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+func decodeCursor[T any](cursor string, keys []repository.SortKey, fields map[string]sortField[T]) ([]any, error) {
+	invalid := fmt.Errorf("%w: malformed cursor", repository.ErrInvalidListOptions)
+
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, invalid
+	}
+	var encoded []json.RawMessage
+	if err := json.Unmarshal(raw, &encoded); err != nil || len(encoded) != len(keys) {
+		return nil, invalid
+	}
+
+	var zero T
+	values := make([]any, len(keys))
+	for i, key := range keys {
+		value := reflect.New(reflect.TypeOf(fields[key.Field](zero)))
+		if err := json.Unmarshal(encoded[i], value.Interface()); err != nil {
+			return nil, invalid
+		}
+		values[i] = value.Elem().Interface()
+	}
+	return values, nil
+}
+
+// compareValues orders two values of the same sortable type.
+func compareValues(a, b any) int {
+	if ta, ok := a.(time.Time); ok {
+		return ta.Compare(b.(time.Time))
+	}
+
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	switch va.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return cmp.Compare(va.Int(), vb.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return cmp.Compare(va.Uint(), vb.Uint())
+	case reflect.Float32, reflect.Float64:
+		return cmp.Compare(va.Float(), vb.Float())
+	case reflect.String:
+		return cmp.Compare(va.String(), vb.String())
+	case reflect.Bool:
+		return cmp.Compare(boolToInt(va.Bool()), boolToInt(vb.Bool()))
+	}
+	panic(fmt.Sprintf("memory: cannot sort by %T", a))
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,147 @@
+package infrastructure
+
+import (
+	"sync"
+	"time"
+
+	"github.com/witchakornb/basic-ecommerce/domain/entity"
+	"github.com/witchakornb/basic-ecommerce/domain/errs"
+	"gorm.io/gorm"
+)
+
+// Store holds the data shared by the in-memory repositories. It is safe for
+// concurrent use: every repository call locks the store, and a UnitOfWork
+// holds the lock for the whole transaction so transactions are serialized.
+type Store struct {
+	mu    sync.RWMutex
+	state *state
+}
+
+// NewStore creates an empty in-memory store.
+func NewStore() *Store {
+	return &Store{state: newState()}
+}
+
+// state is the full content of a Store. Transactions work on a deep copy
+// and swap it in on commit, so a rollback is simply dropping the copy.
+type state struct {
+	users         map[int]entity.User
+	products      map[int]entity.Product
+	orders        map[int]entity.Order
+	refreshTokens map[int]entity.RefreshToken
+	carts         map[int]entity.Cart
+	reservations  map[int]entity.InventoryReservation
+
+	lastUserID         int
+	lastProductID      int
+	lastOrderID        int
+	lastOrderItemID    int
+	lastRefreshTokenID int
+	lastCartID         int
+	lastCartItemID     int
+	lastReservationID  int
+}
+
+func newState() *state {
+	return &state{
+		users:         make(map[int]entity.User),
+		products:      make(map[int]entity.Product),
+		orders:        make(map[int]entity.Order),
+		refreshTokens: make(map[int]entity.RefreshToken),
+		carts:         make(map[int]entity.Cart),
+		reservations:  make(map[int]entity.InventoryReservation),
+	}
+}
+
+// clone returns a deep copy of the state.
+func (s *state) clone() *state {
+	c := *s
+	c.users = make(map[int]entity.User, len(s.users))
+	for id, user := range s.users {
+		c.users[id] = user
+	}
+	c.products = make(map[int]entity.Product, len(s.products))
+	for id, product := range s.products {
+		c.products[id] = product
+	}
+	c.orders = make(map[int]entity.Order, len(s.orders))
+	for id, order := range s.orders {
+		c.orders[id] = copyOrder(order)
+	}
+	c.refreshTokens = make(map[int]entity.RefreshToken, len(s.refreshTokens))
+	for id, token := range s.refreshTokens {
+		c.refreshTokens[id] = copyRefreshToken(token)
+	}
+	c.carts = make(map[int]entity.Cart, len(s.carts))
+	for id, cart := range s.carts {
+		c.carts[id] = copyCart(cart)
+	}
+	c.reservations = make(map[int]entity.InventoryReservation, len(s.reservations))
+	for id, reservation := range s.reservations {
+		c.reservations[id] = reservation
+	}
+	return &c
+}
+
+// handle gives a repository access to the state: the live state of the
+// store guarded by its lock, or the private copy of a transaction.
+type handle struct {
+	store *Store
+	tx    *state
+}
+
+// read runs fn with the state locked for reading.
+func (h handle) read(fn func(s *state)) {
+	if h.tx != nil {
+		fn(h.tx)
+		return
+	}
+	h.store.mu.RLock()
+	defer h.store.mu.RUnlock()
+	fn(h.store.state)
+}
+
+// write runs fn with the state locked for writing. fn must validate
+// before it mutates, since outside a transaction nothing is rolled back.
+func (h handle) write(fn func(s *state) error) error {
+	if h.tx != nil {
+		return fn(h.tx)
+	}
+	h.store.mu.Lock()
+	defer h.store.mu.Unlock()
+	return fn(h.store.state)
+}
+
+func copyOrder(order entity.Order) entity.Order {
+	order.Items = append([]entity.OrderItem(nil), order.Items...)
+	return order
+}
+
+func copyCart(cart entity.Cart) entity.Cart {
+	cart.Items = append([]entity.CartItem(nil), cart.Items...)
+	return cart
+}
+
+func copyRefreshToken(token entity.RefreshToken) entity.RefreshToken {
+	if token.RevokedAt != nil {
+		revokedAt := *token.RevokedAt
+		token.RevokedAt = &revokedAt
+	}
+	return token
+}
+
+// duplicate returns the error for a record that would violate a unique
+// constraint, matching the one the GORM repositories report.
+func duplicate(resource string) error {
+	return errs.New(errs.ErrConflict, resource+"_exists", resource+" already exists")
+}
+
+// now returns the time stored in timestamps, in UTC like the GORM backend.
+func now() time.Time {
+	return time.Now().UTC()
+}
+
+// softDelete returns the DeletedAt of a record deleted now.
+func softDelete() gorm.DeletedAt {
+	return gorm.DeletedAt{Time: now(), Valid: true}
+}
@@ -0,0 +1,124 @@
+package infrastructure
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/witchakornb/basic-ecommerce/domain/entity"
+)
+
+// namespace prefixes the name of every metric.
+const namespace = "shop"
+
+// Metrics holds the collectors of the service in their own registry, so
+// tests and multiple instances never clash over the global one.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	httpRequests  *prometheus.HistogramVec
+	dbQueries     *prometheus.HistogramVec
+	transactions  *prometheus.CounterVec
+	ordersCreated prometheus.Counter
+	outOfStock    prometheus.Counter
+	revenue       *prometheus.CounterVec
+}
+
+// New creates the collectors and registers them, together with the Go
+// runtime and process collectors.
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Time to answer HTTP requests, by route group, method and status code.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"group", "method", "status"}),
+		dbQueries: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "db_query_duration_seconds",
+			Help:      "Time to run SQL statements, by operation and whether they failed.",
+			Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+		}, []string{"operation", "error"}),
+		transactions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "db_transactions_total",
+			Help:      "Units of work run, by whether they were committed or rolled back.",
+		}, []string{"result"}),
+		ordersCreated: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "orders_created_total",
+			Help:      "Orders placed, directly or by checking out a cart.",
+		}),
+		outOfStock: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "orders_out_of_stock_total",
+			Help:      "Orders rejected because a product did not have enough stock.",
+		}),
+		revenue: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "revenue_total",
+			Help:      "Total price of the orders paid, in major units of their currency.",
+		}, []string{"currency"}),
+	}
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequests,
+		m.dbQueries,
+		m.transactions,
+		m.ordersCreated,
+		m.outOfStock,
+		m.revenue,
+	)
+	return m
+}
+
+// Handler serves the metrics in the Prometheus exposition format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// ObserveHTTPRequest records a request answered with the status after the duration.
+func (m *Metrics) ObserveHTTPRequest(group, method string, status int, duration time.Duration) {
+	m.httpRequests.WithLabelValues(group, method, strconv.Itoa(status)).Observe(duration.Seconds())
+}
+
+// ObserveQuery records an SQL statement of the operation, such as "query"
+// or "create", that ran for the duration.
+func (m *Metrics) ObserveQuery(operation string, duration time.Duration, failed bool) {
+	m.dbQueries.WithLabelValues(operation, strconv.FormatBool(failed)).Observe(duration.Seconds())
+}
+
+// ObserveTransaction records a unit of work that was committed or rolled back.
+func (m *Metrics) ObserveTransaction(committed bool) {
+	result := "rollback"
+	if committed {
+		result = "commit"
+	}
+	m.transactions.WithLabelValues(result).Inc()
+}
+
+// OrderCreated records a placed order.
+func (m *Metrics) OrderCreated(entity.Order) {
+	m.ordersCreated.Inc()
+}
+
+// OrderOutOfStock records an order rejected for lack of stock.
+func (m *Metrics) OrderOutOfStock() {
+	m.outOfStock.Inc()
+}
+
+// OrderPaid adds the total price of the order to the revenue in its currency.
+func (m *Metrics) OrderPaid(order entity.Order) {
+	total := order.TotalPrice
+	amount, err := strconv.ParseFloat(total.Decimal(), 64)
+	if err != nil {
+		return
+	}
+	m.revenue.WithLabelValues(total.Currency).Add(amount)
+}
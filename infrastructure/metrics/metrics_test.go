@@ -0,0 +1,43 @@
+package infrastructure
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/witchakornb/basic-ecommerce/domain/entity"
+)
+
+func TestMetricsExposition(t *testing.T) {
+	m := New()
+	m.ObserveHTTPRequest("orders", "POST", 201, 30*time.Millisecond)
+	m.ObserveQuery("create", 2*time.Millisecond, false)
+	m.ObserveTransaction(true)
+	m.ObserveTransaction(false)
+	m.OrderCreated(entity.Order{ID: 1})
+	m.OrderOutOfStock()
+	m.OrderPaid(entity.Order{TotalPrice: entity.Money{Amount: 1999, Currency: "USD"}})
+	m.OrderPaid(entity.Order{TotalPrice: entity.Money{Amount: 500, Currency: "JPY"}})
+
+	recorder := httptest.NewRecorder()
+	m.Handler().ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	body, _ := io.ReadAll(recorder.Body)
+
+	for _, want := range []string{
+		`shop_http_request_duration_seconds_count{group="orders",method="POST",status="201"} 1`,
+		`shop_db_query_duration_seconds_count{error="false",operation="create"} 1`,
+		`shop_db_transactions_total{result="commit"} 1`,
+		`shop_db_transactions_total{result="rollback"} 1`,
+		`shop_orders_created_total 1`,
+		`shop_orders_out_of_stock_total 1`,
+		`shop_revenue_total{currency="USD"} 19.99`,
+		`shop_revenue_total{currency="JPY"} 500`,
+		`go_goroutines`,
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("metrics do not contain %q", want)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package infrastructure
+
+import (
+	"fmt"
+
+	"github.com/witchakornb/basic-ecommerce/usecase"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// BcryptPasswordHasher is a bcrypt implementation of the PasswordHasher interface.
+type BcryptPasswordHasher struct {
+	cost int
+}
+
+// NewBcryptPasswordHasher creates a new BcryptPasswordHasher with the given cost.
+func NewBcryptPasswordHasher(cost int) (usecase.PasswordHasher, error) {
+	if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		return nil, fmt.Errorf("bcrypt cost must be between %d and %d, got %d", bcrypt.MinCost, bcrypt.MaxCost, cost)
+	}
+	return &BcryptPasswordHasher{cost: cost}, nil
+}
+
+// Hash hashes the password with the configured cost.
+func (h *BcryptPasswordHasher) Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), h.cost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// Compare reports whether the password matches the bcrypt hash.
+func (h *BcryptPasswordHasher) Compare(hash, password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
+
+// NeedsRehash reports whether the hash was generated with a different cost.
+func (h *BcryptPasswordHasher) NeedsRehash(hash string) bool {
+	cost, err := bcrypt.Cost([]byte(hash))
+	if err != nil {
+		return true
+	}
+	return cost != h.cost
+}
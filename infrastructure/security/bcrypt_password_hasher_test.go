@@ -0,0 +1,52 @@
+package infrastructure
+
+import (
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestNewBcryptPasswordHasher(t *testing.T) {
+	for _, cost := range []int{bcrypt.MinCost - 1, bcrypt.MaxCost + 1} {
+		if _, err := NewBcryptPasswordHasher(cost); err == nil {
+			t.Fatalf("NewBcryptPasswordHasher accepted cost %d", cost)
+		}
+	}
+}
+
+func TestBcryptPasswordHasher(t *testing.T) {
+	hasher, err := NewBcryptPasswordHasher(bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("NewBcryptPasswordHasher: %v", err)
+	}
+	hash, err := hasher.Hash("correct horse")
+	if err != nil {
+		t.Fatalf("Hash: %v", err)
+	}
+	if hash == "correct horse" {
+		t.Fatal("Hash returned the password")
+	}
+	if !hasher.Compare(hash, "correct horse") {
+		t.Fatal("Compare rejected the password")
+	}
+	if hasher.Compare(hash, "wrong horse") || hasher.Compare(hash, "") {
+		t.Fatal("Compare accepted a wrong password")
+	}
+	if hasher.Compare("not a hash", "correct horse") {
+		t.Fatal("Compare accepted a garbled hash")
+	}
+
+	if hasher.NeedsRehash(hash) {
+		t.Fatal("NeedsRehash of a hash with the current cost")
+	}
+	stronger, err := NewBcryptPasswordHasher(bcrypt.MinCost + 1)
+	if err != nil {
+		t.Fatalf("NewBcryptPasswordHasher: %v", err)
+	}
+	if !stronger.NeedsRehash(hash) {
+		t.Fatal("NeedsRehash of a hash with another cost returned false")
+	}
+	if !hasher.NeedsRehash("not a hash") {
+		t.Fatal("NeedsRehash of a garbled hash returned false")
+	}
+}
@@ -0,0 +1,78 @@
+package infrastructure
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/witchakornb/basic-ecommerce/domain/entity"
+	"github.com/witchakornb/basic-ecommerce/usecase"
+)
+
+// JWTAccessTokenManager issues and verifies HS256 signed JWT access tokens.
+type JWTAccessTokenManager struct {
+	secret []byte
+	issuer string
+	ttl    time.Duration
+}
+
+// accessTokenClaims are the claims carried by an access token.
+type accessTokenClaims struct {
+	Role entity.Role `json:"role"`
+	jwt.RegisteredClaims
+}
+
+// NewJWTAccessTokenManager creates a new JWTAccessTokenManager.
+func NewJWTAccessTokenManager(secret []byte, issuer string, ttl time.Duration) (usecase.AccessTokenManager, error) {
+	if len(secret) < 32 {
+		return nil, errors.New("jwt secret must be at least 32 bytes")
+	}
+	return &JWTAccessTokenManager{secret: secret, issuer: issuer, ttl: ttl}, nil
+}
+
+// Issue returns a signed access token whose subject is the user ID.
+// The user's role is carried as a claim.
+func (m *JWTAccessTokenManager) Issue(user entity.User) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(m.ttl)
+	claims := accessTokenClaims{
+		Role: user.Role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    m.issuer,
+			Subject:   strconv.Itoa(user.ID),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(m.secret)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return token, expiresAt, nil
+}
+
+// Verify parses the token, checks its signature, issuer and expiry and returns the principal.
+func (m *JWTAccessTokenManager) Verify(token string) (entity.Principal, error) {
+	var claims accessTokenClaims
+	_, err := jwt.ParseWithClaims(token, &claims, func(*jwt.Token) (any, error) {
+		return m.secret, nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithIssuer(m.issuer),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return entity.Principal{}, err
+	}
+
+	userID, err := strconv.Atoi(claims.Subject)
+	if err != nil {
+		return entity.Principal{}, errors.New("invalid token subject")
+	}
+	if !claims.Role.IsValid() {
+		return entity.Principal{}, errors.New("invalid token role")
+	}
+	return entity.Principal{UserID: userID, Role: claims.Role}, nil
+}
@@ -0,0 +1,124 @@
+package infrastructure
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/witchakornb/basic-ecommerce/domain/entity"
+)
+
+var (
+	testSecret = []byte("0123456789abcdef0123456789abcdef")
+	testUser   = entity.User{ID: 7, Role: entity.RoleStaff}
+)
+
+// sign returns a token with the claims signed by the method and key.
+func sign(t *testing.T, method jwt.SigningMethod, claims jwt.Claims, key any) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(method, claims).SignedString(key)
+	if err != nil {
+		t.Fatalf("sign token: %v", err)
+	}
+	return token
+}
+
+// validClaims returns the claims the manager issues for testUser.
+func validClaims() accessTokenClaims {
+	now := time.Now()
+	return accessTokenClaims{
+		Role: testUser.Role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    "shop",
+			Subject:   "7",
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Minute)),
+		},
+	}
+}
+
+func TestNewJWTAccessTokenManager(t *testing.T) {
+	if _, err := NewJWTAccessTokenManager(testSecret[:31], "shop", time.Minute); err == nil {
+		t.Fatal("NewJWTAccessTokenManager accepted a secret shorter than 32 bytes")
+	}
+	if _, err := NewJWTAccessTokenManager(testSecret, "shop", time.Minute); err != nil {
+		t.Fatalf("NewJWTAccessTokenManager: %v", err)
+	}
+}
+
+func TestJWTAccessTokenManagerVerify(t *testing.T) {
+	manager, err := NewJWTAccessTokenManager(testSecret, "shop", time.Minute)
+	if err != nil {
+		t.Fatalf("NewJWTAccessTokenManager: %v", err)
+	}
+	issued, _, err := manager.Issue(testUser)
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+
+	expiredManager, err := NewJWTAccessTokenManager(testSecret, "shop", -time.Minute)
+	if err != nil {
+		t.Fatalf("NewJWTAccessTokenManager: %v", err)
+	}
+	expired, _, err := expiredManager.Issue(testUser)
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+
+	// The payload is swapped for one granting the admin role, keeping the signature
+	parts := strings.Split(issued, ".")
+	adminClaims := validClaims()
+	adminClaims.Role = entity.RoleAdmin
+	forged := strings.Split(sign(t, jwt.SigningMethodHS256, adminClaims, []byte("another secret of at least 32 bytes")), ".")
+	tamperedPayload := parts[0] + "." + forged[1] + "." + parts[2]
+	tamperedSignature := parts[0] + "." + parts[1] + "." + forged[2]
+
+	noExpiry := validClaims()
+	noExpiry.ExpiresAt = nil
+	otherIssuer := validClaims()
+	otherIssuer.Issuer = "elsewhere"
+	badRole := validClaims()
+	badRole.Role = "root"
+	badSubject := validClaims()
+	badSubject.Subject = "alice"
+
+	tests := []struct {
+		name  string
+		token string
+		ok    bool
+	}{
+		{name: "issued", token: issued, ok: true},
+		{name: "expired", token: expired},
+		{name: "without expiry", token: sign(t, jwt.SigningMethodHS256, noExpiry, testSecret)},
+		{name: "other issuer", token: sign(t, jwt.SigningMethodHS256, otherIssuer, testSecret)},
+		{name: "other secret", token: sign(t, jwt.SigningMethodHS256, validClaims(), []byte("another secret of at least 32 bytes"))},
+		{name: "HS512 with the secret", token: sign(t, jwt.SigningMethodHS512, validClaims(), testSecret)},
+		{name: "alg none", token: sign(t, jwt.SigningMethodNone, validClaims(), jwt.UnsafeAllowNoneSignatureType)},
+		{name: "tampered payload", token: tamperedPayload},
+		{name: "tampered signature", token: tamperedSignature},
+		{name: "unknown role", token: sign(t, jwt.SigningMethodHS256, badRole, testSecret)},
+		{name: "non-numeric subject", token: sign(t, jwt.SigningMethodHS256, badSubject, testSecret)},
+		{name: "refresh token", token: base64.RawURLEncoding.EncodeToString(testSecret)},
+		{name: "garbled", token: "not.a.token"},
+		{name: "empty", token: ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			principal, err := manager.Verify(test.token)
+			if !test.ok {
+				if err == nil {
+					t.Fatalf("Verify accepted the token as %+v", principal)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Verify: %v", err)
+			}
+			if want := (entity.Principal{UserID: testUser.ID, Role: testUser.Role}); principal != want {
+				t.Fatalf("Verify returned %+v, want %+v", principal, want)
+			}
+		})
+	}
+}
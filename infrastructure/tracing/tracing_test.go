@@ -0,0 +1,37 @@
+package infrastructure
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+)
+
+func TestSetupStdout(t *testing.T) {
+	previous := otel.GetTracerProvider()
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	var out bytes.Buffer
+	shutdown, err := Setup(context.Background(), Config{ServiceName: "shop-test", Exporter: ExporterStdout, Output: &out})
+	if err != nil {
+		t.Fatalf("Setup: %v", err)
+	}
+	_, span := otel.Tracer("test").Start(context.Background(), "checkout")
+	span.End()
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown: %v", err)
+	}
+	for _, want := range []string{`"Name":"checkout"`, `"shop-test"`} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("exported spans do not contain %s:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestSetupRejectsUnknownExporter(t *testing.T) {
+	if _, err := Setup(context.Background(), Config{Exporter: "jaeger"}); err == nil {
+		t.Fatal("Setup with an unknown exporter succeeded")
+	}
+}
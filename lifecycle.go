@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	infrahealth "github.com/witchakornb/basic-ecommerce/infrastructure/health"
+	"github.com/witchakornb/basic-ecommerce/usecase"
+	"gorm.io/gorm"
+)
+
+// workerGroup runs background workers until the server shuts down.
+type workerGroup struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu      sync.Mutex
+	stopped []string // workers that returned before Stop
+}
+
+func newWorkerGroup() *workerGroup {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &workerGroup{ctx: ctx, cancel: cancel}
+}
+
+// Go runs the worker in the background. It must return soon after its
+// context is cancelled, finishing or abandoning the work in hand.
+func (g *workerGroup) Go(name string, worker func(ctx context.Context)) {
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		worker(g.ctx)
+		slog.Info("worker stopped", "worker", name)
+		if g.ctx.Err() == nil {
+			g.mu.Lock()
+			g.stopped = append(g.stopped, name)
+			g.mu.Unlock()
+		}
+	}()
+}
+
+// Check is a health check that fails once a worker has returned on its own.
+func (g *workerGroup) Check(ctx context.Context) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if len(g.stopped) > 0 {
+		return fmt.Errorf("workers stopped unexpectedly: %s", strings.Join(g.stopped, ", "))
+	}
+	return nil
+}
+
+// Stop cancels the workers and waits until they return or ctx is done.
+func (g *workerGroup) Stop(ctx context.Context) error {
+	g.cancel()
+	done := make(chan struct{})
+	go func() {
+		g.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("stop workers: %w", ctx.Err())
+	}
+}
+
+// sweepReservations cancels the orders left unpaid past their stock
+// reservation every interval, until ctx is cancelled. A failed sweep is
+// logged and retried at the next tick.
+func sweepReservations(ctx context.Context, orders usecase.OrderUseCase, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		cancelled, err := orders.ExpireReservations(ctx)
+		if err != nil && ctx.Err() == nil {
+			slog.ErrorContext(ctx, "failed to release expired reservations", "error", err)
+		}
+		if cancelled > 0 {
+			slog.InfoContext(ctx, "expired reservations released", "orders_cancelled", cancelled)
+		}
+	}
+}
+
+// serve runs the server until SIGINT or SIGTERM. It then reports not ready
+// and keeps serving for shutdownDelay, so load balancers stop sending
+// requests. Then it stops taking new connections, waits for in-flight
+// requests (and with them their transactions) to finish, stops the workers
+// and closes the database, all within shutdownTimeout. A second signal
+// kills the process immediately.
+func serve(server *http.Server, health *infrahealth.Registry, workers *workerGroup, db *gorm.DB, shutdownDelay, shutdownTimeout time.Duration) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	listener, err := net.Listen("tcp", server.Addr)
+	if err != nil {
+		return err
+	}
+	served := make(chan error, 1)
+	go func() { served <- server.Serve(listener) }()
+	health.SetReady(true)
+	slog.Info("server started", "addr", listener.Addr().String())
+
+	select {
+	case err := <-served:
+		return err
+	case <-ctx.Done():
+	}
+	stop()
+	slog.Info("shutting down")
+	health.SetReady(false)
+	time.Sleep(shutdownDelay)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	var problems []error
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		problems = append(problems, fmt.Errorf("drain connections: %w", err))
+		server.Close()
+	}
+	if err := workers.Stop(shutdownCtx); err != nil {
+		problems = append(problems, err)
+	}
+	if sqlDB, err := db.DB(); err == nil {
+		if err := sqlDB.Close(); err != nil {
+			problems = append(problems, fmt.Errorf("close database: %w", err))
+		}
+	}
+	if len(problems) == 0 {
+		slog.Info("server stopped")
+	}
+	return errors.Join(problems...)
+}
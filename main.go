@@ -1,89 +1,328 @@
 package main
 
 import (
-	"log"
+	"context"
+	"crypto/rand"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/witchakornb/basic-ecommerce/domain/entity"
+	"github.com/witchakornb/basic-ecommerce/domain/policy"
+	"github.com/witchakornb/basic-ecommerce/domain/repository"
+	infraconfig "github.com/witchakornb/basic-ecommerce/infrastructure/config"
 	infradb "github.com/witchakornb/basic-ecommerce/infrastructure/db" // Alias for infrastructure/db
+	infrahealth "github.com/witchakornb/basic-ecommerce/infrastructure/health"
 	infrahttp "github.com/witchakornb/basic-ecommerce/infrastructure/http"
+	infralogging "github.com/witchakornb/basic-ecommerce/infrastructure/logging"
+	inframetrics "github.com/witchakornb/basic-ecommerce/infrastructure/metrics"
+	infrasecurity "github.com/witchakornb/basic-ecommerce/infrastructure/security"
+	infratracing "github.com/witchakornb/basic-ecommerce/infrastructure/tracing"
 	"github.com/witchakornb/basic-ecommerce/usecase"
-	"gorm.io/driver/sqlite"
-	"gorm.io/gorm"
 )
 
 func main() {
-	// Database connection and migration
-	db, err := gorm.Open(sqlite.Open("test.db"), &gorm.Config{})
+	// Configuration from defaults, the config file, the environment and flags
+	cfg, args, err := infraconfig.Load(os.Args[1:], os.LookupEnv)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		fatal("failed to load configuration", err)
+	}
+
+	// Structured logging; the log package and gin's debug output go through it too
+	logger, err := infralogging.New(os.Stderr, cfg.Log.Format, cfg.Log.Level)
+	if err != nil {
+		fatal("failed to create logger", err)
+	}
+	slog.SetDefault(logger)
+	gin.DebugPrintFunc = func(format string, values ...any) {
+		slog.Debug(strings.TrimSpace(fmt.Sprintf(format, values...)))
+	}
+	gin.DebugPrintRouteFunc = func(method, path, handler string, handlers int) {
+		slog.Debug("route", "method", method, "path", path, "handler", handler)
+	}
+
+	// Database connection; the DSN selects SQLite, PostgreSQL or MySQL
+	db, err := infradb.Open(infradb.Config{
+		DSN:             cfg.Database.DSN,
+		MaxOpenConns:    cfg.Database.MaxOpenConns,
+		MaxIdleConns:    cfg.Database.MaxIdleConns,
+		ConnMaxLifetime: cfg.Database.ConnMaxLifetime,
+		ConnMaxIdleTime: cfg.Database.ConnMaxIdleTime,
+		ConnectTimeout:  cfg.Database.ConnectTimeout,
+		Logger:          infradb.NewSlogLogger(logger, cfg.Log.SlowQueryThreshold),
+	})
+	if err != nil {
+		fatal("failed to connect to database", err)
+	}
+
+	// "migrate ..." manages the schema instead of starting the server
+	if len(args) > 0 && args[0] == "migrate" {
+		if err := runMigrate(db, args[1:], os.Stdout); err != nil {
+			fatal("migrate failed", err)
+		}
+		return
+	}
+
+	// Refuse to serve against a schema the code does not match
+	migrator, err := infradb.NewSchemaMigrator(db)
 	if err != nil {
-		log.Fatalf("failed to connect to database: %v", err)
+		fatal("failed to load migrations", err)
+	}
+	if err := migrator.EnsureCurrent(); err != nil {
+		fatal(fmt.Sprintf("run \"%s migrate up\" first", os.Args[0]), err)
+	}
+
+	// Prometheus metrics of requests, SQL statements, transactions and sales
+	metrics := inframetrics.New()
+	if err := db.Use(infradb.NewMetricsPlugin(metrics)); err != nil {
+		fatal("failed to instrument database", err)
 	}
 
-	err = db.AutoMigrate(&entity.User{}, &entity.Product{}, &entity.Order{})
+	// Traces of requests, use cases, transactions and SQL statements
+	shutdownTracing, err := infratracing.Setup(context.Background(), infratracing.Config{
+		ServiceName: "basic-ecommerce",
+		Exporter:    cfg.Tracing.Exporter,
+		Endpoint:    cfg.Tracing.Endpoint,
+		Output:      os.Stdout,
+	})
 	if err != nil {
-		log.Fatalf("failed to migrate database: %v", err)
+		fatal("failed to set up tracing", err)
+	}
+	if err := db.Use(infradb.NewTracingPlugin()); err != nil {
+		fatal("failed to instrument database", err)
 	}
 
-	// Initialize the Gin router
-	router := gin.Default()
+	// Initialize the Gin router; handler errors are rendered as problem+json
+	if err := infrahttp.RegisterValidators(); err != nil {
+		fatal("failed to register validators", err)
+	}
+	gin.SetMode(cfg.Server.Mode)
+	router := gin.New()
+	router.Use(
+		infrahttp.RequestIDMiddleware(),
+		infrahttp.TracingMiddleware(),
+		infrahttp.AccessLogMiddleware(),
+		infrahttp.MetricsMiddleware(metrics),
+		infrahttp.RecoveryMiddleware(),
+		infrahttp.ErrorMiddleware(),
+		infrahttp.TimeoutMiddleware(cfg.Server.RequestTimeout),
+	)
 
 	// Initialize Unit of Work
-	uow := infradb.NewGormUnitOfWork(db) // Corrected package alias
+	uow := infradb.NewGormUnitOfWork(db, metrics) // Corrected package alias
 
 	// Initialize repositories (still needed for handlers/usecases that do simple reads)
-	userRepo := infradb.NewGormUserRepository(db) // Corrected package alias
+	userRepo := infradb.NewGormUserRepository(db)       // Corrected package alias
 	productRepo := infradb.NewGormProductRepository(db) // Corrected package alias
 
+	// Initialize the full-text product search; the API runs without it if it is
+	// turned off or the database has no search index
+	productSearch := infradb.NewDisabledProductSearch()
+	if cfg.Features.Search {
+		productSearch, err = infradb.NewGormProductSearch(db)
+		if err != nil {
+			slog.Warn("product search disabled", "error", err)
+		}
+	} else {
+		slog.Info("product search disabled by configuration")
+	}
+
+	// Initialize the password hasher
+	passwordHasher, err := infrasecurity.NewBcryptPasswordHasher(cfg.Auth.BcryptCost)
+	if err != nil {
+		fatal("failed to create password hasher", err)
+	}
+
+	// Initialize the access token manager
+	accessTokens, err := infrasecurity.NewJWTAccessTokenManager(jwtSecret(cfg.Auth.JWTSecret), "basic-ecommerce", cfg.Auth.AccessTokenTTL)
+	if err != nil {
+		fatal("failed to create access token manager", err)
+	}
+
 	// Initialize the use cases
-	userUseCase := usecase.NewUserUseCase(userRepo)
-	productUseCase := usecase.NewProductUseCase(productRepo)
+	userUseCase := usecase.NewUserUseCase(userRepo, passwordHasher)
+	productUseCase := usecase.NewProductUseCase(productRepo, productSearch)
 	// Pass the Unit of Work to the OrderUseCase
-	orderUseCase := usecase.NewOrderUseCase(uow)
+	orderUseCase := usecase.NewOrderUseCase(uow, metrics, cfg.Inventory.ReservationTTL)
+	cartUseCase := usecase.NewCartUseCase(uow, metrics, cfg.Inventory.ReservationTTL)
+	authUseCase := usecase.NewAuthUseCase(uow, userUseCase, accessTokens, cfg.Auth.RefreshTokenTTL)
+
+	// Background workers run until the server shuts down
+	workers := newWorkerGroup()
+	workers.Go("reservation-sweeper", func(ctx context.Context) {
+		sweepReservations(ctx, orderUseCase, cfg.Inventory.SweepInterval)
+	})
+
+	// Health checks: the process is live while its workers run, and ready
+	// while the database answers and its schema is current
+	healthRegistry := infrahealth.NewRegistry(cfg.Server.HealthCheckTimeout)
+	healthRegistry.AddLiveness("workers", workers.Check)
+	healthRegistry.AddReadiness("database", infradb.PingCheck(db))
+	healthRegistry.AddReadiness("migrations", infradb.SchemaCheck(migrator))
+
+	// Make sure there is an admin who can assign roles to everyone else
+	bootstrapAdmin(userRepo, userUseCase, cfg.Auth.AdminEmail, cfg.Auth.AdminPassword)
 
 	// Initialize the Handlers
 	userHandler := infrahttp.NewUserHandler(userUseCase)
 	productHandler := infrahttp.NewProductHandler(productUseCase)
-	orderHandler := infrahttp.NewOrderHandler(orderUseCase, productUseCase, userUseCase)
+	orderHandler := infrahttp.NewOrderHandler(orderUseCase)
+	cartHandler := infrahttp.NewCartHandler(cartUseCase)
+	authHandler := infrahttp.NewAuthHandler(authUseCase)
+	healthHandler := infrahttp.NewHealthHandler(healthRegistry)
+	requireAuth := infrahttp.AuthMiddleware(authUseCase)
+	optionalAuth := infrahttp.OptionalAuthMiddleware(authUseCase)
+	manageDeleted := infrahttp.RequirePermission(policy.ManageDeleted)
 
-	// Routes and server startup
-	router.GET("/health", func(c *gin.Context) {
-		c.JSON(200, gin.H{"status": "ok"})
-	})
+	// Routes and server startup; /health is kept as an alias of the liveness probe.
+	// Requests are measured per route group, named by RouteGroup
+	healthRoutes := router.Group("/health", infrahttp.RouteGroup("health"))
+	{
+		healthRoutes.GET("", healthHandler.Live)
+		healthRoutes.GET("/live", healthHandler.Live)
+		healthRoutes.GET("/ready", healthHandler.Ready)
+	}
+	router.GET("/metrics", infrahttp.RouteGroup("metrics"), gin.WrapH(metrics.Handler()))
 
 	api := router.Group("/api")
 	{
+		// Auth routes
+		authRoutes := api.Group("/auth", infrahttp.RouteGroup("auth"))
+		{
+			authRoutes.POST("/login", authHandler.Login)
+			authRoutes.POST("/refresh", authHandler.Refresh)
+			authRoutes.POST("/logout", authHandler.Logout)
+		}
+
 		// User routes
-		userRoutes := api.Group("/users")
+		userRoutes := api.Group("/users", infrahttp.RouteGroup("users"))
 		{
 			userRoutes.POST("/", userHandler.CreateUser)
-			userRoutes.GET("/:id", userHandler.GetUser)
-			userRoutes.GET("/", userHandler.GetAllUsers)
-			userRoutes.PUT("/:id", userHandler.UpdateUser)
-			userRoutes.DELETE("/:id", userHandler.DeleteUser)
+			userRoutes.GET("/:id", requireAuth, userHandler.GetUserByID)
+			userRoutes.GET("/", requireAuth, infrahttp.RequirePermission(policy.ReadUsers), userHandler.GetAllUsers)
+			userRoutes.PUT("/:id", requireAuth, userHandler.UpdateUser)
+			userRoutes.PATCH("/:id", requireAuth, userHandler.PatchUser)
+			userRoutes.DELETE("/:id", requireAuth, userHandler.DeleteUser)
+			userRoutes.POST("/:id/restore", requireAuth, manageDeleted, userHandler.RestoreUser)
 		}
 
 		// Product routes
-		productRoutes := api.Group("/products")
+		productRoutes := api.Group("/products", infrahttp.RouteGroup("products"))
+		{
+			manageCatalog := infrahttp.RequirePermission(policy.ManageCatalog)
+			productRoutes.POST("/", requireAuth, manageCatalog, productHandler.CreateProduct)
+			productRoutes.GET("/search", productHandler.SearchProducts)
+			productRoutes.GET("/:id", productHandler.GetProductByID)
+			productRoutes.GET("/", optionalAuth, productHandler.GetAllProducts)
+			productRoutes.PUT("/:id", requireAuth, manageCatalog, productHandler.UpdateProduct)
+			productRoutes.PATCH("/:id", requireAuth, manageCatalog, productHandler.PatchProduct)
+			productRoutes.DELETE("/:id", requireAuth, manageCatalog, productHandler.DeleteProduct)
+			productRoutes.POST("/:id/restore", requireAuth, manageDeleted, productHandler.RestoreProduct)
+		}
+
+		// Cart routes
+		cartRoutes := api.Group("/cart", infrahttp.RouteGroup("cart"), requireAuth)
 		{
-			productRoutes.POST("/", productHandler.CreateProduct)
-			productRoutes.GET("/:id", productHandler.GetProduct)
-			productRoutes.GET("/", productHandler.GetAllProducts)
-			productRoutes.PUT("/:id", productHandler.UpdateProduct)
-			productRoutes.DELETE("/:id", productHandler.DeleteProduct)
+			cartRoutes.GET("/", cartHandler.GetCart)
+			cartRoutes.POST("/items", cartHandler.AddItem)
+			cartRoutes.PUT("/items/:productId", cartHandler.UpdateItem)
+			cartRoutes.DELETE("/items/:productId", cartHandler.RemoveItem)
+			cartRoutes.POST("/checkout", cartHandler.Checkout)
 		}
 
 		// Order routes
-		orderRoutes := api.Group("/orders")
+		orderRoutes := api.Group("/orders", infrahttp.RouteGroup("orders"), requireAuth)
 		{
 			orderRoutes.POST("/", orderHandler.CreateOrder)
-			orderRoutes.GET("/:id", orderHandler.GetOrder)
+			orderRoutes.GET("/:id", orderHandler.GetOrderByID)
 			orderRoutes.GET("/", orderHandler.GetAllOrders)
 			orderRoutes.DELETE("/:id", orderHandler.DeleteOrder)
+			orderRoutes.POST("/:id/restore", orderHandler.RestoreOrder)
+			orderRoutes.POST("/:id/pay", orderHandler.TransitionOrder(entity.OrderStatusPaid))
+			orderRoutes.POST("/:id/fulfill", orderHandler.TransitionOrder(entity.OrderStatusFulfilled))
+			orderRoutes.POST("/:id/ship", orderHandler.TransitionOrder(entity.OrderStatusShipped))
+			orderRoutes.POST("/:id/deliver", orderHandler.TransitionOrder(entity.OrderStatusDelivered))
+			orderRoutes.POST("/:id/cancel", orderHandler.TransitionOrder(entity.OrderStatusCancelled))
+			orderRoutes.POST("/:id/refund", orderHandler.TransitionOrder(entity.OrderStatusRefunded))
+		}
+	}
+
+	server := &http.Server{
+		Addr:              cfg.Server.Addr,
+		Handler:           router,
+		ReadTimeout:       cfg.Server.ReadTimeout,
+		ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout,
+		WriteTimeout:      cfg.Server.WriteTimeout,
+		IdleTimeout:       cfg.Server.IdleTimeout,
+	}
+	if err := serve(server, healthRegistry, workers, db, cfg.Server.ShutdownDelay, cfg.Server.ShutdownTimeout); err != nil {
+		fatal("failed to run server", err)
+	}
+
+	// Flush the spans still buffered
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+	if err := shutdownTracing(ctx); err != nil {
+		slog.Warn("failed to export the remaining spans", "error", err)
+	}
+}
+
+// jwtSecret returns the configured access token signing secret. Without one
+// a random secret is generated, so tokens do not survive a restart.
+func jwtSecret(secret string) []byte {
+	if secret != "" {
+		return []byte(secret)
+	}
+	slog.Warn("auth.jwt_secret is not set, using a random secret")
+	random := make([]byte, 32)
+	if _, err := rand.Read(random); err != nil {
+		fatal("failed to generate jwt secret", err)
+	}
+	return random
+}
+
+// bootstrapAdmin creates an admin with the email and password unless they are
+// empty or a user with that email already exists. A deleted user with the
+// email is only reported, since it is not ours to restore.
+func bootstrapAdmin(userRepo repository.UserRepository, userUseCase usecase.UserUseCase, email, password string) {
+	if email == "" || password == "" {
+		return
+	}
+	ctx := context.Background()
+	// Deleted users keep their email, so they are looked up too
+	existing, err := userRepo.GetAllUsers(ctx, repository.UserFilter{Email: email, IncludeDeleted: true}, repository.ListOptions{})
+	if err != nil {
+		fatal("failed to look up admin user", err)
+	}
+	if len(existing.Items) > 0 {
+		if user := existing.Items[0]; user.DeletedAt.Valid {
+			slog.Warn("admin user not created: its email belongs to a deleted user, restore that user to sign in with it", "email", email, "user_id", user.ID)
 		}
+		return
 	}
 
-	if err := router.Run(":8080"); err != nil {
-		log.Fatalf("failed to run server: %v", err)
+	_, err = userUseCase.CreateUser(ctx, entity.User{
+		Username: "admin",
+		Email:    email,
+		Password: password,
+		Role:     entity.RoleAdmin,
+	})
+	if err != nil {
+		fatal("failed to create admin user", err)
 	}
-	log.Println("Server started on :8080")
+	slog.Info("created admin user", "email", email)
+}
+
+// fatal logs the error and exits.
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
 }
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+
+	infradb "github.com/witchakornb/basic-ecommerce/infrastructure/db"
+	"gorm.io/gorm"
+)
+
+const migrateUsage = `usage: basic-ecommerce migrate <command>
+
+commands:
+  up            apply every pending migration
+  down          revert the most recently applied migration
+  status        list the migrations and whether they are applied
+  to <version>  apply or revert migrations until the version is the latest applied (0 reverts all)`
+
+// runMigrate executes the migrate subcommand with its arguments, writing
+// progress to out.
+func runMigrate(db *gorm.DB, args []string, out io.Writer) error {
+	migrator, err := infradb.NewSchemaMigrator(db)
+	if err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		return fmt.Errorf("missing command\n%s", migrateUsage)
+	}
+
+	var done []infradb.Migration
+	switch command := args[0]; {
+	case command == "up" && len(args) == 1:
+		done, err = migrator.Up()
+	case command == "down" && len(args) == 1:
+		done, err = migrator.Down()
+	case command == "to" && len(args) == 2:
+		version, convErr := strconv.Atoi(args[1])
+		if convErr != nil {
+			return fmt.Errorf("invalid version %q\n%s", args[1], migrateUsage)
+		}
+		done, err = migrator.To(version)
+	case command == "status" && len(args) == 1:
+		return printMigrationStatus(migrator, out)
+	default:
+		return fmt.Errorf("invalid command %q\n%s", args, migrateUsage)
+	}
+
+	for _, migration := range done {
+		fmt.Fprintf(out, "migrated %d %s\n", migration.Version, migration.Name)
+	}
+	if err == nil && len(done) == 0 {
+		fmt.Fprintln(out, "nothing to migrate")
+	}
+	return err
+}
+
+// printMigrationStatus writes a table of the migrations and their state.
+func printMigrationStatus(migrator *infradb.Migrator, out io.Writer) error {
+	statuses, err := migrator.Status()
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+	for _, status := range statuses {
+		appliedAt := "pending"
+		if status.Applied {
+			appliedAt = status.AppliedAt.Format("2006-01-02 15:04:05 MST")
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\n", status.Version, status.Name, appliedAt)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,15 @@
+package usecase
+
+import (
+	"time"
+
+	"github.com/witchakornb/basic-ecommerce/domain/entity"
+)
+
+// AccessTokenManager issues and verifies short-lived signed access tokens.
+type AccessTokenManager interface {
+	// Issue returns a signed access token for the user and its expiry time.
+	Issue(user entity.User) (string, time.Time, error)
+	// Verify checks the signature and expiry of the token and returns the principal it was issued for.
+	Verify(token string) (entity.Principal, error)
+}
@@ -0,0 +1,204 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/witchakornb/basic-ecommerce/domain/entity"
+	"github.com/witchakornb/basic-ecommerce/domain/errs"
+	"github.com/witchakornb/basic-ecommerce/domain/repository"
+)
+
+// ErrInvalidToken is returned when an access or refresh token is malformed, expired or revoked.
+var ErrInvalidToken = errs.New(errs.ErrUnauthorized, "invalid_token", "invalid or expired token")
+
+// TokenPair is the set of tokens handed to a client after login or refresh.
+type TokenPair struct {
+	AccessToken           string    `json:"access_token"`
+	AccessTokenExpiresAt  time.Time `json:"access_token_expires_at"`
+	RefreshToken          string    `json:"refresh_token"`
+	RefreshTokenExpiresAt time.Time `json:"refresh_token_expires_at"`
+}
+
+type AuthUseCase interface {
+	Login(ctx context.Context, email, password string) (TokenPair, error)
+	Refresh(ctx context.Context, refreshToken string) (TokenPair, error)
+	Logout(ctx context.Context, refreshToken string) error
+	Authenticate(ctx context.Context, accessToken string) (entity.Principal, error)
+}
+
+// AuthUseCaseImpl is the implementation of AuthUseCase
+type AuthUseCaseImpl struct {
+	uow             repository.UnitOfWork
+	userUseCase     UserUseCase
+	tokens          AccessTokenManager
+	refreshTokenTTL time.Duration
+}
+
+// NewAuthUseCase creates a new AuthUseCase
+func NewAuthUseCase(uow repository.UnitOfWork, userUseCase UserUseCase, tokens AccessTokenManager, refreshTokenTTL time.Duration) AuthUseCase {
+	return &AuthUseCaseImpl{
+		uow:             uow,
+		userUseCase:     userUseCase,
+		tokens:          tokens,
+		refreshTokenTTL: refreshTokenTTL,
+	}
+}
+
+// Login verifies the credentials and starts a new session.
+func (a *AuthUseCaseImpl) Login(ctx context.Context, email, password string) (pair TokenPair, err error) {
+	ctx, span := startSpan(ctx, "AuthUseCase.Login")
+	defer func() { endSpan(span, err) }()
+
+	user, err := a.userUseCase.VerifyCredentials(ctx, email, password)
+	if err != nil {
+		return TokenPair{}, err
+	}
+
+	err = a.uow.Execute(ctx, func(ctx context.Context, store repository.UnitOfWorkStore) error {
+		var err error
+		pair, err = a.issueTokenPair(ctx, store, user)
+		return err
+	})
+	return pair, err
+}
+
+// Refresh exchanges a refresh token for a new token pair. The presented
+// refresh token is revoked, so every refresh token can be used only once.
+// Presenting an already revoked token is treated as theft and revokes every
+// session of the user.
+func (a *AuthUseCaseImpl) Refresh(ctx context.Context, refreshToken string) (pair TokenPair, err error) {
+	ctx, span := startSpan(ctx, "AuthUseCase.Refresh")
+	defer func() { endSpan(span, err) }()
+
+	reusedBy := 0
+	err = a.uow.Execute(ctx, func(ctx context.Context, store repository.UnitOfWorkStore) error {
+		tokenRepo := store.RefreshTokens()
+
+		token, err := tokenRepo.GetRefreshTokenByHash(ctx, hashRefreshToken(refreshToken))
+		if errors.Is(err, errs.ErrNotFound) {
+			return ErrInvalidToken
+		}
+		if err != nil {
+			return err
+		}
+
+		if token.RevokedAt != nil {
+			reusedBy = token.UserID
+			return tokenRepo.RevokeUserRefreshTokens(ctx, token.UserID)
+		}
+		if !token.IsActive(time.Now()) {
+			return ErrInvalidToken
+		}
+
+		user, err := store.Users().GetUserByID(ctx, token.UserID)
+		if errors.Is(err, errs.ErrNotFound) {
+			return ErrInvalidToken
+		}
+		if err != nil {
+			return err
+		}
+
+		// A concurrent refresh with the same token may have revoked it
+		// since it was read, which is reuse just the same
+		err = tokenRepo.RevokeRefreshToken(ctx, token.ID)
+		if errors.Is(err, repository.ErrRefreshTokenRevoked) {
+			reusedBy = token.UserID
+			return tokenRepo.RevokeUserRefreshTokens(ctx, token.UserID)
+		}
+		if err != nil {
+			return err
+		}
+		pair, err = a.issueTokenPair(ctx, store, user)
+		return err
+	})
+	if err == nil && reusedBy != 0 {
+		slog.WarnContext(ctx, "refresh token reused, revoked every session of the user", "user_id", reusedBy)
+		return TokenPair{}, ErrInvalidToken
+	}
+	return pair, err
+}
+
+// Logout revokes the refresh token. Unknown or already revoked tokens are ignored.
+func (a *AuthUseCaseImpl) Logout(ctx context.Context, refreshToken string) (err error) {
+	ctx, span := startSpan(ctx, "AuthUseCase.Logout")
+	defer func() { endSpan(span, err) }()
+
+	return a.uow.Execute(ctx, func(ctx context.Context, store repository.UnitOfWorkStore) error {
+		tokenRepo := store.RefreshTokens()
+		token, err := tokenRepo.GetRefreshTokenByHash(ctx, hashRefreshToken(refreshToken))
+		if errors.Is(err, errs.ErrNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		err = tokenRepo.RevokeRefreshToken(ctx, token.ID)
+		if errors.Is(err, repository.ErrRefreshTokenRevoked) {
+			return nil
+		}
+		return err
+	})
+}
+
+// Authenticate verifies an access token and returns the principal it was issued for.
+func (a *AuthUseCaseImpl) Authenticate(ctx context.Context, accessToken string) (_ entity.Principal, err error) {
+	ctx, span := startSpan(ctx, "AuthUseCase.Authenticate")
+	defer func() { endSpan(span, err) }()
+
+	principal, err := a.tokens.Verify(accessToken)
+	if err != nil {
+		return entity.Principal{}, ErrInvalidToken
+	}
+	return principal, nil
+}
+
+func (a *AuthUseCaseImpl) issueTokenPair(ctx context.Context, store repository.UnitOfWorkStore, user entity.User) (TokenPair, error) {
+	accessToken, accessExpiresAt, err := a.tokens.Issue(user)
+	if err != nil {
+		return TokenPair{}, err
+	}
+
+	refreshToken, err := generateRefreshToken()
+	if err != nil {
+		return TokenPair{}, err
+	}
+	now := time.Now()
+	stored, err := store.RefreshTokens().CreateRefreshToken(ctx, entity.RefreshToken{
+		UserID:    user.ID,
+		TokenHash: hashRefreshToken(refreshToken),
+		ExpiresAt: now.Add(a.refreshTokenTTL),
+		CreatedAt: now,
+	})
+	if err != nil {
+		return TokenPair{}, err
+	}
+
+	return TokenPair{
+		AccessToken:           accessToken,
+		AccessTokenExpiresAt:  accessExpiresAt,
+		RefreshToken:          refreshToken,
+		RefreshTokenExpiresAt: stored.ExpiresAt,
+	}, nil
+}
+
+// generateRefreshToken returns a random, URL-safe refresh token.
+func generateRefreshToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashRefreshToken returns the value stored in place of the refresh token.
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
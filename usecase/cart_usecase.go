@@ -0,0 +1,234 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/witchakornb/basic-ecommerce/domain/entity"
+	"github.com/witchakornb/basic-ecommerce/domain/errs"
+	"github.com/witchakornb/basic-ecommerce/domain/repository"
+)
+
+var (
+	// ErrCartEmpty is returned when checking out a cart without items.
+	ErrCartEmpty = errs.New(errs.ErrConflict, "cart_empty", "cart is empty")
+	// ErrInvalidQuantity is returned when a cart line quantity is not positive.
+	ErrInvalidQuantity = errs.Invalid(errs.FieldError{Field: "quantity", Reason: "must be greater than zero"})
+	// ErrQuantityTooLarge is returned when a cart line would hold more than entity.MaxQuantity units.
+	ErrQuantityTooLarge = errs.Invalid(errs.FieldError{Field: "quantity", Reason: fmt.Sprintf("must be at most %d in total", entity.MaxQuantity)})
+)
+
+type CartUseCase interface {
+	GetCart(ctx context.Context, userID int) (entity.Cart, error)
+	AddItem(ctx context.Context, userID, productID, quantity int) (entity.Cart, error)
+	UpdateItem(ctx context.Context, userID, productID, quantity int) (entity.Cart, error)
+	RemoveItem(ctx context.Context, userID, productID int) (entity.Cart, error)
+	Checkout(ctx context.Context, userID int) (entity.Order, error)
+}
+
+// CartUseCaseImpl is the implementation of CartUseCase
+type CartUseCaseImpl struct {
+	uow            repository.UnitOfWork
+	metrics        SalesMetrics
+	reservationTTL time.Duration
+}
+
+// NewCartUseCase creates a new CartUseCase. Orders checked out of a cart
+// have their stock reserved for reservationTTL, like those placed directly.
+func NewCartUseCase(uow repository.UnitOfWork, metrics SalesMetrics, reservationTTL time.Duration) CartUseCase {
+	return &CartUseCaseImpl{
+		uow:            uow,
+		metrics:        metrics,
+		reservationTTL: reservationTTL,
+	}
+}
+
+// GetCart returns the user's cart priced at the current product prices.
+func (u *CartUseCaseImpl) GetCart(ctx context.Context, userID int) (cart entity.Cart, err error) {
+	ctx, span := startSpan(ctx, "CartUseCase.GetCart")
+	defer func() { endSpan(span, err) }()
+
+	err = u.uow.Execute(ctx, func(ctx context.Context, store repository.UnitOfWorkStore) error {
+		var err error
+		cart, err = u.loadCart(ctx, store, userID)
+		return err
+	})
+	return cart, err
+}
+
+// AddItem adds quantity units of the product to the user's cart.
+func (u *CartUseCaseImpl) AddItem(ctx context.Context, userID, productID, quantity int) (_ entity.Cart, err error) {
+	ctx, span := startSpan(ctx, "CartUseCase.AddItem")
+	defer func() { endSpan(span, err) }()
+
+	return u.updateLine(ctx, userID, productID, func(current int) (int, error) {
+		if quantity <= 0 {
+			return 0, ErrInvalidQuantity
+		}
+		// Compared before adding, so that the sum cannot overflow
+		if quantity > entity.MaxQuantity-current {
+			return 0, ErrQuantityTooLarge
+		}
+		return current + quantity, nil
+	})
+}
+
+// UpdateItem sets the quantity of the product in the user's cart.
+func (u *CartUseCaseImpl) UpdateItem(ctx context.Context, userID, productID, quantity int) (_ entity.Cart, err error) {
+	ctx, span := startSpan(ctx, "CartUseCase.UpdateItem")
+	defer func() { endSpan(span, err) }()
+
+	return u.updateLine(ctx, userID, productID, func(int) (int, error) {
+		if quantity <= 0 {
+			return 0, ErrInvalidQuantity
+		}
+		if quantity > entity.MaxQuantity {
+			return 0, ErrQuantityTooLarge
+		}
+		return quantity, nil
+	})
+}
+
+// RemoveItem removes the product from the user's cart.
+func (u *CartUseCaseImpl) RemoveItem(ctx context.Context, userID, productID int) (cart entity.Cart, err error) {
+	ctx, span := startSpan(ctx, "CartUseCase.RemoveItem")
+	defer func() { endSpan(span, err) }()
+
+	err = u.uow.Execute(ctx, func(ctx context.Context, store repository.UnitOfWorkStore) error {
+		current, err := u.loadCart(ctx, store, userID)
+		if err != nil {
+			return err
+		}
+		return store.Carts().DeleteCartItem(ctx, current.ID, productID)
+	})
+	if err != nil {
+		return entity.Cart{}, err
+	}
+	return u.GetCart(ctx, userID)
+}
+
+// Checkout turns the user's cart into an order and empties the cart.
+// Stock for all lines is reserved in the same transaction, so either every
+// line is ordered or none is, and held until the order is paid or the
+// reservation TTL passes.
+func (u *CartUseCaseImpl) Checkout(ctx context.Context, userID int) (order entity.Order, err error) {
+	ctx, span := startSpan(ctx, "CartUseCase.Checkout")
+	defer func() { endSpan(span, err) }()
+
+	err = u.uow.Execute(ctx, func(ctx context.Context, store repository.UnitOfWorkStore) error {
+		cart, err := u.loadCart(ctx, store, userID)
+		if err != nil {
+			return err
+		}
+		if len(cart.Items) == 0 {
+			return ErrCartEmpty
+		}
+
+		lines := make([]entity.OrderItem, 0, len(cart.Items))
+		for _, item := range cart.Items {
+			lines = append(lines, entity.OrderItem{ProductID: item.ProductID, Quantity: item.Quantity})
+		}
+		order, err = placeOrder(ctx, store, userID, lines, time.Now().Add(u.reservationTTL))
+		if err != nil {
+			return err
+		}
+
+		return store.Carts().ClearCart(ctx, cart.ID)
+	})
+	recordOrderPlaced(ctx, u.metrics, userID, order, err)
+	if err != nil {
+		return entity.Order{}, err
+	}
+	return order, nil
+}
+
+// updateLine sets a cart line to the quantity returned by next, which receives the current quantity.
+func (u *CartUseCaseImpl) updateLine(ctx context.Context, userID, productID int, next func(current int) (int, error)) (cart entity.Cart, err error) {
+	err = u.uow.Execute(ctx, func(ctx context.Context, store repository.UnitOfWorkStore) error {
+		product, err := store.Products().GetProductByID(ctx, productID)
+		if err != nil {
+			return err
+		}
+
+		cart, err = u.loadCart(ctx, store, userID)
+		if err != nil {
+			return err
+		}
+
+		current := 0
+		for _, item := range cart.Items {
+			if item.ProductID == productID {
+				current = item.Quantity
+			}
+			if item.UnitPrice.Currency != product.Price.Currency {
+				return entity.ErrCurrencyMismatch
+			}
+		}
+		quantity, err := next(current)
+		if err != nil {
+			return err
+		}
+		if product.Stock < quantity {
+			return repository.ErrInsufficientStock
+		}
+
+		_, err = store.Carts().SaveCartItem(ctx, entity.CartItem{
+			CartID:    cart.ID,
+			ProductID: productID,
+			Quantity:  quantity,
+			UnitPrice: product.Price,
+		})
+		return err
+	})
+	if err != nil {
+		return entity.Cart{}, err
+	}
+	return u.GetCart(ctx, userID)
+}
+
+// loadCart returns the user's cart, creating it on first use, with every
+// line repriced at the product's current price. Lines whose product no
+// longer exists are dropped, and so are lines whose product is now priced
+// in another currency than the cart's first line was, since a cart cannot
+// be totalled across currencies.
+func (u *CartUseCaseImpl) loadCart(ctx context.Context, store repository.UnitOfWorkStore, userID int) (entity.Cart, error) {
+	cart, err := store.Carts().GetCartByUserID(ctx, userID)
+	if errors.Is(err, errs.ErrNotFound) {
+		cart, err = store.Carts().CreateCart(ctx, entity.Cart{UserID: userID})
+	}
+	if err != nil {
+		return entity.Cart{}, err
+	}
+
+	var currency string
+	if len(cart.Items) > 0 {
+		currency = cart.Items[0].UnitPrice.Currency
+	}
+	items := cart.Items[:0]
+	for _, item := range cart.Items {
+		product, err := store.Products().GetProductByID(ctx, item.ProductID)
+		if errors.Is(err, errs.ErrNotFound) || err == nil && product.Price.Currency != currency {
+			if err := store.Carts().DeleteCartItem(ctx, cart.ID, item.ProductID); err != nil {
+				return entity.Cart{}, err
+			}
+			continue
+		}
+		if err != nil {
+			return entity.Cart{}, err
+		}
+		if product.Price != item.UnitPrice {
+			item.UnitPrice = product.Price
+			if _, err := store.Carts().SaveCartItem(ctx, item); err != nil {
+				return entity.Cart{}, err
+			}
+		}
+		items = append(items, item)
+	}
+	cart.Items = items
+	if err := cart.Recalculate(); err != nil {
+		return entity.Cart{}, err
+	}
+	return cart, nil
+}
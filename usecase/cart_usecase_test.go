@@ -0,0 +1,78 @@
+package usecase
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/witchakornb/basic-ecommerce/domain/entity"
+	"github.com/witchakornb/basic-ecommerce/domain/repository"
+	memory "github.com/witchakornb/basic-ecommerce/infrastructure/memory"
+)
+
+func TestCartDropsLinesPricedInAnotherCurrency(t *testing.T) {
+	s := newShop(t, 5, time.Hour)
+	products := memory.NewMemoryProductRepository(s.store)
+	plate, err := products.CreateProduct(t.Context(), entity.Product{Name: "Plate", Price: entity.Money{Amount: 500, Currency: "USD"}, Stock: 5})
+	if err != nil {
+		t.Fatalf("CreateProduct: %v", err)
+	}
+	carts := NewCartUseCase(memory.NewMemoryUnitOfWork(s.store), discardMetrics{}, time.Hour)
+	for _, productID := range []int{s.product.ID, plate.ID} {
+		if _, err := carts.AddItem(t.Context(), s.customer.ID, productID, 1); err != nil {
+			t.Fatalf("AddItem: %v", err)
+		}
+	}
+
+	plate.Price = entity.Money{Amount: 450, Currency: "EUR"}
+	if _, err := products.UpdateProduct(t.Context(), plate, []repository.ProductField{repository.ProductPrice}); err != nil {
+		t.Fatalf("UpdateProduct: %v", err)
+	}
+
+	cart, err := carts.GetCart(t.Context(), s.customer.ID)
+	if err != nil {
+		t.Fatalf("GetCart after repricing a line in another currency: %v", err)
+	}
+	if len(cart.Items) != 1 || cart.Items[0].ProductID != s.product.ID {
+		t.Fatalf("cart items = %+v, want only the product still priced in USD", cart.Items)
+	}
+	if want := (entity.Money{Amount: 1000, Currency: "USD"}); cart.Total != want {
+		t.Fatalf("cart total = %v, want %v", cart.Total, want)
+	}
+
+	if _, err := carts.AddItem(t.Context(), s.customer.ID, plate.ID, 1); !errors.Is(err, entity.ErrCurrencyMismatch) {
+		t.Fatalf("AddItem of a product in another currency returned %v, want ErrCurrencyMismatch", err)
+	}
+	if _, err := carts.Checkout(t.Context(), s.customer.ID); err != nil {
+		t.Fatalf("Checkout: %v", err)
+	}
+}
+
+func TestCartQuantities(t *testing.T) {
+	s := newShop(t, 2*entity.MaxQuantity, time.Hour)
+	carts := NewCartUseCase(memory.NewMemoryUnitOfWork(s.store), discardMetrics{}, time.Hour)
+
+	if _, err := carts.AddItem(t.Context(), s.customer.ID, s.product.ID, entity.MaxQuantity-1); err != nil {
+		t.Fatalf("AddItem: %v", err)
+	}
+	for _, quantity := range []int{2, math.MaxInt} {
+		if _, err := carts.AddItem(t.Context(), s.customer.ID, s.product.ID, quantity); !errors.Is(err, ErrQuantityTooLarge) {
+			t.Fatalf("AddItem of %d more returned %v, want ErrQuantityTooLarge", quantity, err)
+		}
+	}
+	cart, err := carts.AddItem(t.Context(), s.customer.ID, s.product.ID, 1)
+	if err != nil {
+		t.Fatalf("AddItem up to the maximum: %v", err)
+	}
+	if cart.Items[0].Quantity != entity.MaxQuantity {
+		t.Fatalf("quantity = %d, want %d", cart.Items[0].Quantity, entity.MaxQuantity)
+	}
+
+	if _, err := carts.UpdateItem(t.Context(), s.customer.ID, s.product.ID, entity.MaxQuantity+1); !errors.Is(err, ErrQuantityTooLarge) {
+		t.Fatalf("UpdateItem above the maximum returned %v, want ErrQuantityTooLarge", err)
+	}
+	if _, err := carts.UpdateItem(t.Context(), s.customer.ID, s.product.ID, 0); !errors.Is(err, ErrInvalidQuantity) {
+		t.Fatalf("UpdateItem to 0 returned %v, want ErrInvalidQuantity", err)
+	}
+}
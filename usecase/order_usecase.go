@@ -1,103 +1,507 @@
 package usecase
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
 
 	"github.com/witchakornb/basic-ecommerce/domain/entity"
+	"github.com/witchakornb/basic-ecommerce/domain/errs"
+	"github.com/witchakornb/basic-ecommerce/domain/policy"
 	"github.com/witchakornb/basic-ecommerce/domain/repository"
 )
 
+var (
+	// ErrOrderEmpty is returned when placing an order without items.
+	ErrOrderEmpty = errs.New(errs.ErrValidation, "order_empty", "order has no items")
+	// ErrReservationExpired is returned when paying for an order whose
+	// stock reservations expired; the order is cancelled by the next sweep.
+	ErrReservationExpired = errs.New(errs.ErrConflict, "reservation_expired", "the stock reserved for the order has expired")
+)
+
+// expiredReservationBatch is how many expired reservations ExpireReservations handles per call.
+const expiredReservationBatch = 100
+
 type OrderUseCase interface {
-	CreateOrder(order entity.Order) (entity.Order, error)
-	GetOrderByID(id int) (entity.Order, error)
-	GetAllOrders() ([]entity.Order, error)
-	DeleteOrder(id int) error
+	CreateOrder(ctx context.Context, order entity.Order) (entity.Order, error)
+	GetOrderByID(ctx context.Context, principal entity.Principal, id int) (entity.Order, error)
+	GetAllOrders(ctx context.Context, principal entity.Principal, filter repository.OrderFilter, opts repository.ListOptions) (repository.Page[entity.Order], error)
+	TransitionOrder(ctx context.Context, principal entity.Principal, id int, next entity.OrderStatus) (entity.Order, error)
+	DeleteOrder(ctx context.Context, principal entity.Principal, id int) error
+	RestoreOrder(ctx context.Context, principal entity.Principal, id int) (entity.Order, error)
+	ExpireReservations(ctx context.Context) (int, error)
+}
+
+// customerTransitions are the statuses a customer may move their own order to.
+// Every other transition requires the ManageOrders permission; in particular
+// only staff may mark an order paid, once they have verified the payment.
+var customerTransitions = map[entity.OrderStatus]bool{
+	entity.OrderStatusCancelled: true,
 }
 
 // OrderUseCaseImpl is the implementation of OrderUseCase
 type OrderUseCaseImpl struct {
-	uow repository.UnitOfWork // เปลี่ยนจาก repo แต่ละตัวมาเป็น UoW
+	uow            repository.UnitOfWork // เปลี่ยนจาก repo แต่ละตัวมาเป็น UoW
+	metrics        SalesMetrics
+	reservationTTL time.Duration
 }
 
-// NewOrderUseCase creates a new OrderUseCase
-func NewOrderUseCase(uow repository.UnitOfWork) OrderUseCase {
+// NewOrderUseCase creates a new OrderUseCase. The stock of a new order is
+// reserved for reservationTTL; the order is cancelled if it is not paid by then.
+func NewOrderUseCase(uow repository.UnitOfWork, metrics SalesMetrics, reservationTTL time.Duration) OrderUseCase {
 	return &OrderUseCaseImpl{
-		uow: uow,
+		uow:            uow,
+		metrics:        metrics,
+		reservationTTL: reservationTTL,
+	}
+}
+
+// CreateOrder places an order for every line in order.Items. Only the
+// product IDs and quantities of the lines are used; names, prices and
+// totals are taken from the catalog. The stock of the order is reserved
+// until it is paid or the reservation expires.
+func (o *OrderUseCaseImpl) CreateOrder(ctx context.Context, order entity.Order) (createdOrder entity.Order, err error) { // Modified return to named
+	ctx, span := startSpan(ctx, "OrderUseCase.CreateOrder")
+	defer func() { endSpan(span, err) }()
+
+	err = o.uow.Execute(ctx, func(ctx context.Context, store repository.UnitOfWorkStore) error {
+		var err error
+		createdOrder, err = placeOrder(ctx, store, order.CustomerID, order.Items, time.Now().Add(o.reservationTTL))
+		return err
+	})
+	recordOrderPlaced(ctx, o.metrics, order.CustomerID, createdOrder, err)
+
+	return createdOrder, err
+}
+
+// recordOrderPlaced logs and counts the outcome of placing an order.
+// Rejections for lack of stock are warnings, since they usually mean the
+// catalog is out of date.
+func recordOrderPlaced(ctx context.Context, metrics SalesMetrics, customerID int, order entity.Order, err error) {
+	switch {
+	case err == nil:
+		slog.InfoContext(ctx, "order placed", "order_id", order.ID, "customer_id", customerID, "items", len(order.Items), "total", order.TotalPrice.String())
+		metrics.OrderCreated(order)
+	case errors.Is(err, errs.ErrInsufficientStock):
+		slog.WarnContext(ctx, "order rejected", "customer_id", customerID, "error", err)
+		metrics.OrderOutOfStock()
 	}
 }
 
-func (o *OrderUseCaseImpl) CreateOrder(order entity.Order) (createdOrder entity.Order, err error) { // Modified return to named
-	err = o.uow.Execute(func(store repository.UnitOfWorkStore) error {
-		// 1. Get repositories from the store
-		userRepo := store.Users()
-		productRepo := store.Products()
-		orderRepo := store.Orders()
+// placeOrder validates the lines, reserves their stock until expiresAt and
+// creates the order within the caller's transaction. Lines for the same
+// product are merged.
+func placeOrder(ctx context.Context, store repository.UnitOfWorkStore, customerID int, lines []entity.OrderItem, expiresAt time.Time) (entity.Order, error) {
+	// 1. Get repositories from the store
+	userRepo := store.Users()
+	productRepo := store.Products()
+	orderRepo := store.Orders()
+
+	// 2. Check if user exists
+	if _, err := userRepo.GetUserByID(ctx, customerID); err != nil {
+		return entity.Order{}, err
+	}
+
+	if len(lines) == 0 {
+		return entity.Order{}, ErrOrderEmpty
+	}
 
-		// 2. Check if user exists
-		user, err := userRepo.GetUserByID(order.CustomerID)
-		if err != nil || user.ID == 0 {
-			return errors.New("user not found")
+	var invalid []errs.FieldError
+	for i, line := range lines {
+		switch {
+		case line.Quantity <= 0:
+			invalid = append(invalid, errs.FieldError{Field: fmt.Sprintf("items[%d].quantity", i), Reason: "must be greater than zero"})
+		case line.Quantity > entity.MaxQuantity:
+			invalid = append(invalid, errs.FieldError{Field: fmt.Sprintf("items[%d].quantity", i), Reason: fmt.Sprintf("must be at most %d", entity.MaxQuantity)})
 		}
+	}
+	if err := errs.Invalid(invalid...); err != nil {
+		return entity.Order{}, err
+	}
+
+	order := entity.Order{CustomerID: customerID, Status: entity.OrderStatusPending}
+	lineByProduct := make(map[int]int)
+	for n, line := range lines {
+		i, ok := lineByProduct[line.ProductID]
+		if !ok {
+			lineByProduct[line.ProductID] = len(order.Items)
+			order.Items = append(order.Items, entity.OrderItem{ProductID: line.ProductID, Quantity: line.Quantity})
+			continue
+		}
+		// Merged lines are bounded like single ones; compared before
+		// adding, so that the sum cannot overflow
+		if line.Quantity > entity.MaxQuantity-order.Items[i].Quantity {
+			invalid = append(invalid, errs.FieldError{Field: fmt.Sprintf("items[%d].quantity", n), Reason: fmt.Sprintf("must be at most %d together with the other lines for the product", entity.MaxQuantity)})
+			continue
+		}
+		order.Items[i].Quantity += line.Quantity
+	}
+	if err := errs.Invalid(invalid...); err != nil {
+		return entity.Order{}, err
+	}
 
-		// 3. Check if product exists
-		product, err := productRepo.GetProductByID(order.ProductID)
+	for i, item := range order.Items {
+		// 3. Check if product exists and snapshot its name and price
+		product, err := productRepo.GetProductByID(ctx, item.ProductID)
 		if err != nil {
-			return errors.New("product not found")
+			return entity.Order{}, err
 		}
+		order.Items[i].ProductName = product.Name
+		order.Items[i].UnitPrice = product.Price
+
+		// 4. Reserve the stock (within transaction); fails if there is not enough
+		if err := productRepo.AdjustStock(ctx, item.ProductID, -item.Quantity); err != nil {
+			return entity.Order{}, err
+		}
+	}
+	// Totals are always computed here, never taken from the client
+	if err := order.Recalculate(); err != nil {
+		return entity.Order{}, err
+	}
+
+	// 5. Create order (within transaction)
+	order, err := orderRepo.CreateOrder(ctx, order)
+	if err != nil {
+		return entity.Order{}, err
+	}
+
+	// 6. Record the reservations holding its stock until it is paid
+	if err := holdStock(ctx, store, order, expiresAt); err != nil {
+		return entity.Order{}, err
+	}
+	return order, nil
+}
 
-		// 4. Check if product is in stock
-		if product.Stock < order.Quantity {
-			return errors.New("not enough stock")
+// GetOrderByID returns the order if the principal placed it or may read every order.
+func (o *OrderUseCaseImpl) GetOrderByID(ctx context.Context, principal entity.Principal, id int) (order entity.Order, err error) { // Modified return to named
+	ctx, span := startSpan(ctx, "OrderUseCase.GetOrderByID")
+	defer func() { endSpan(span, err) }()
+
+	err = o.uow.Execute(ctx, func(ctx context.Context, store repository.UnitOfWorkStore) error {
+		var err error
+		order, err = store.Orders().GetOrderByID(ctx, id)
+		if err != nil {
+			return err
 		}
+		return policy.AuthorizeOwner(principal, order.CustomerID, policy.ReadOrders)
+	})
+	if err != nil {
+		return entity.Order{}, err
+	}
+	return order, nil
+}
+
+// GetAllOrders returns one page of the orders matching the filter. Staff and
+// admins see every order, customers only their own whatever the filter says.
+func (o *OrderUseCaseImpl) GetAllOrders(ctx context.Context, principal entity.Principal, filter repository.OrderFilter, opts repository.ListOptions) (orders repository.Page[entity.Order], err error) { // Modified return to named
+	ctx, span := startSpan(ctx, "OrderUseCase.GetAllOrders")
+	defer func() { endSpan(span, err) }()
+
+	if !policy.Can(principal, policy.ReadOrders) {
+		filter.CustomerID = principal.UserID
+	}
+	err = o.uow.Execute(ctx, func(ctx context.Context, store repository.UnitOfWorkStore) error {
+		var err error
+		orders, err = store.Orders().GetAllOrders(ctx, filter, opts)
+		return err
+	})
+	return orders, err
+}
+
+// TransitionOrder moves an order to the next status of its lifecycle.
+// Customers may only cancel their own orders; staff mark them paid once
+// the payment is verified. Paying commits the stock reservations of the
+// order, and fails with ErrReservationExpired once they have expired.
+// Cancelling or refunding an order whose goods have not shipped puts its
+// stock back.
+func (o *OrderUseCaseImpl) TransitionOrder(ctx context.Context, principal entity.Principal, id int, next entity.OrderStatus) (order entity.Order, err error) {
+	ctx, span := startSpan(ctx, "OrderUseCase.TransitionOrder")
+	defer func() { endSpan(span, err) }()
 
-		// 5. Update product stock (within transaction)
-		product.Stock -= order.Quantity
-		_, err = productRepo.UpdateProduct(product)
+	err = o.uow.Execute(ctx, func(ctx context.Context, store repository.UnitOfWorkStore) error {
+		var err error
+		order, err = store.Orders().GetOrderByID(ctx, id)
 		if err != nil {
-			return errors.New("failed to update product stock")
+			return err
 		}
 
-		// 6. Create order (within transaction)
-		createdOrder, err = orderRepo.CreateOrder(order)
+		if customerTransitions[next] {
+			err = policy.AuthorizeOwner(principal, order.CustomerID, policy.ManageOrders)
+		} else {
+			err = policy.Authorize(principal, policy.ManageOrders)
+		}
 		if err != nil {
 			return err
 		}
 
-		return nil // No error, transaction will be committed
+		from := order.Status
+		if err := order.TransitionTo(next); err != nil {
+			return err
+		}
+		// The status is moved first, so that of two concurrent transitions
+		// of the order only one gets to commit reservations or put stock back
+		if err := store.Orders().UpdateOrderStatus(ctx, order.ID, from, next); err != nil {
+			return err
+		}
+		if next == entity.OrderStatusPaid {
+			if err := commitReservations(ctx, store, order.ID, time.Now()); err != nil {
+				return err
+			}
+		}
+		if from.HoldsStock() && !next.HoldsStock() {
+			if err := restoreStock(ctx, store, order); err != nil {
+				return err
+			}
+		}
+
+		order, err = store.Orders().GetOrderByID(ctx, id)
+		return err
 	})
+	if err != nil {
+		return entity.Order{}, err
+	}
+	if next == entity.OrderStatusPaid {
+		o.metrics.OrderPaid(order)
+	}
+	return order, nil
+}
 
-	return createdOrder, err
+// DeleteOrder deletes an order, putting back the stock it still holds. The
+// delete is conditional on the status read, so an order cancelled or
+// refunded concurrently does not put its stock back twice.
+func (o *OrderUseCaseImpl) DeleteOrder(ctx context.Context, principal entity.Principal, id int) (err error) {
+	ctx, span := startSpan(ctx, "OrderUseCase.DeleteOrder")
+	defer func() { endSpan(span, err) }()
+
+	if err := policy.Authorize(principal, policy.ManageOrders); err != nil {
+		return err
+	}
+	return o.uow.Execute(ctx, func(ctx context.Context, store repository.UnitOfWorkStore) error {
+		order, err := store.Orders().GetOrderByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := store.Orders().DeleteOrder(ctx, id, order.Status); err != nil {
+			return err
+		}
+		if order.Status.HoldsStock() {
+			return restoreStock(ctx, store, order)
+		}
+		return nil
+	})
 }
 
-// ----- (Optional but recommended) Update other methods to use UoW as well -----
+// RestoreOrder undeletes a soft-deleted order. If the order still holds
+// stock, the stock is reserved again, so restoring fails if there is not
+// enough of it left. A pending order gets new reservations, which expire
+// after the reservation TTL like those of a new order.
+func (o *OrderUseCaseImpl) RestoreOrder(ctx context.Context, principal entity.Principal, id int) (order entity.Order, err error) {
+	ctx, span := startSpan(ctx, "OrderUseCase.RestoreOrder")
+	defer func() { endSpan(span, err) }()
 
-func (o *OrderUseCaseImpl) GetOrderByID(id int) (order entity.Order, err error) { // Modified return to named
-	err = o.uow.Execute(func(store repository.UnitOfWorkStore) error {
+	if err := policy.Authorize(principal, policy.ManageDeleted); err != nil {
+		return entity.Order{}, err
+	}
+	err = o.uow.Execute(ctx, func(ctx context.Context, store repository.UnitOfWorkStore) error {
 		var err error
-		order, err = store.Orders().GetOrderByID(id)
+		order, err = store.Orders().RestoreOrder(ctx, id)
 		if err != nil {
-			return errors.New("order not found")
+			return err
+		}
+		if !order.Status.HoldsStock() {
+			return nil
+		}
+		if err := reserveStock(ctx, store, order); err != nil {
+			return err
+		}
+		if order.Status == entity.OrderStatusPending {
+			return holdStock(ctx, store, order, time.Now().Add(o.reservationTTL))
 		}
 		return nil
 	})
-	return order, err
+	if err != nil {
+		return entity.Order{}, err
+	}
+	return order, nil
+}
+
+// reserveStock takes the quantities of the order's items out of stock
+// again. Like restoreStock it skips products that no longer exist.
+func reserveStock(ctx context.Context, store repository.UnitOfWorkStore, order entity.Order) error {
+	for _, item := range order.Items {
+		err := store.Products().AdjustStock(ctx, item.ProductID, -item.Quantity)
+		if errors.Is(err, errs.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// restoreStock puts the quantities of the order's items back into stock and
+// releases the reservations still holding them. Soft-deleted products get
+// their stock back too, for when they are restored; only items whose
+// product no longer exists at all are skipped.
+func restoreStock(ctx context.Context, store repository.UnitOfWorkStore, order entity.Order) error {
+	if err := releaseReservations(ctx, store, order.ID); err != nil {
+		return err
+	}
+	for _, item := range order.Items {
+		err := store.Products().AdjustStock(ctx, item.ProductID, item.Quantity)
+		if errors.Is(err, errs.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-func (o *OrderUseCaseImpl) GetAllOrders() (orders []entity.Order, err error) { // Modified return to named
-	err = o.uow.Execute(func(store repository.UnitOfWorkStore) error {
+// ExpireReservations cancels the pending orders whose stock reservations
+// have expired, putting their stock back, and returns how many it
+// cancelled. It handles a bounded batch per call and is meant to be called
+// periodically. Each order is expired in its own transaction, so an order
+// that fails does not keep the others reserved.
+func (o *OrderUseCaseImpl) ExpireReservations(ctx context.Context) (cancelled int, err error) {
+	ctx, span := startSpan(ctx, "OrderUseCase.ExpireReservations")
+	defer func() { endSpan(span, err) }()
+
+	now := time.Now()
+	var expired []entity.InventoryReservation
+	err = o.uow.Execute(ctx, func(ctx context.Context, store repository.UnitOfWorkStore) error {
 		var err error
-		orders, err = store.Orders().GetAllOrders()
+		expired, err = store.Reservations().GetExpiredReservations(ctx, now, expiredReservationBatch)
 		return err
 	})
-	return orders, err
+	if err != nil {
+		return 0, err
+	}
+
+	var failed []error
+	seen := make(map[int]bool)
+	for _, reservation := range expired {
+		if seen[reservation.OrderID] {
+			continue
+		}
+		seen[reservation.OrderID] = true
+
+		var wasCancelled bool
+		err := o.uow.Execute(ctx, func(ctx context.Context, store repository.UnitOfWorkStore) error {
+			var err error
+			wasCancelled, err = expireOrder(ctx, store, reservation.OrderID, now)
+			return err
+		})
+		if err != nil {
+			failed = append(failed, fmt.Errorf("expire order %d: %w", reservation.OrderID, err))
+			continue
+		}
+		if wasCancelled {
+			slog.InfoContext(ctx, "order expired", "order_id", reservation.OrderID)
+			cancelled++
+		}
+	}
+	return cancelled, errors.Join(failed...)
+}
+
+// expireOrder cancels the order if it is still pending and its reservations
+// have expired at now, which puts its stock back. Reservations left held by
+// an order that is no longer pending are only released, since the order
+// already put its stock back or kept it when it left pending.
+func expireOrder(ctx context.Context, store repository.UnitOfWorkStore, orderID int, now time.Time) (bool, error) {
+	reservations, err := store.Reservations().GetOrderReservations(ctx, orderID)
+	if err != nil {
+		return false, err
+	}
+	// The order may have been paid since the expired reservations were listed
+	if !slices.ContainsFunc(reservations, func(r entity.InventoryReservation) bool { return r.IsExpired(now) }) {
+		return false, nil
+	}
+
+	order, err := store.Orders().GetOrderByID(ctx, orderID)
+	switch {
+	case errors.Is(err, errs.ErrNotFound):
+		// Deleting the order put its stock back
+		return false, releaseReservations(ctx, store, orderID)
+	case err != nil:
+		return false, err
+	case order.Status != entity.OrderStatusPending:
+		return false, releaseReservations(ctx, store, orderID)
+	}
+
+	if err := order.TransitionTo(entity.OrderStatusCancelled); err != nil {
+		return false, err
+	}
+	if err := store.Orders().UpdateOrderStatus(ctx, order.ID, entity.OrderStatusPending, entity.OrderStatusCancelled); err != nil {
+		return false, err
+	}
+	if err := restoreStock(ctx, store, order); err != nil {
+		return false, err
+	}
+	return true, nil
 }
 
-func (o *OrderUseCaseImpl) DeleteOrder(id int) error {
-	return o.uow.Execute(func(store repository.UnitOfWorkStore) error {
-		err := store.Orders().DeleteOrder(id)
+// holdStock records a reservation until expiresAt for each item of the
+// order, whose stock has already been taken out.
+func holdStock(ctx context.Context, store repository.UnitOfWorkStore, order entity.Order, expiresAt time.Time) error {
+	for _, item := range order.Items {
+		_, err := store.Reservations().CreateReservation(ctx, entity.InventoryReservation{
+			OrderID:   order.ID,
+			ProductID: item.ProductID,
+			Quantity:  item.Quantity,
+			Status:    entity.ReservationHeld,
+			ExpiresAt: expiresAt,
+		})
 		if err != nil {
-			return errors.New("failed to delete order")
+			return err
 		}
-		return nil
-	})
+	}
+	return nil
+}
+
+// commitReservations makes the stock held by the order's reservations
+// permanent. It fails with ErrReservationExpired if any of them has expired
+// at now. Orders placed before reservations existed have none to commit.
+func commitReservations(ctx context.Context, store repository.UnitOfWorkStore, orderID int, now time.Time) error {
+	reservations, err := store.Reservations().GetOrderReservations(ctx, orderID)
+	if err != nil {
+		return err
+	}
+	for _, reservation := range reservations {
+		if reservation.IsExpired(now) {
+			return ErrReservationExpired
+		}
+	}
+	for _, reservation := range reservations {
+		if reservation.Status != entity.ReservationHeld {
+			continue
+		}
+		if err := store.Reservations().CommitReservation(ctx, reservation.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// releaseReservations marks the reservations the order still holds as
+// released. The stock itself is put back by the caller.
+func releaseReservations(ctx context.Context, store repository.UnitOfWorkStore, orderID int) error {
+	reservations, err := store.Reservations().GetOrderReservations(ctx, orderID)
+	if err != nil {
+		return err
+	}
+	for _, reservation := range reservations {
+		if reservation.Status != entity.ReservationHeld {
+			continue
+		}
+		if err := store.Reservations().ReleaseReservation(ctx, reservation.ID); err != nil {
+			return err
+		}
+	}
+	return nil
 }
@@ -0,0 +1,347 @@
+package usecase
+
+import (
+	"errors"
+	"math"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/witchakornb/basic-ecommerce/domain/entity"
+	"github.com/witchakornb/basic-ecommerce/domain/errs"
+	memory "github.com/witchakornb/basic-ecommerce/infrastructure/memory"
+)
+
+// discardMetrics is a SalesMetrics that records nothing.
+type discardMetrics struct{}
+
+func (discardMetrics) OrderCreated(entity.Order) {}
+func (discardMetrics) OrderOutOfStock()          {}
+func (discardMetrics) OrderPaid(entity.Order)    {}
+
+// shop is an in-memory store with a customer and a product to order.
+type shop struct {
+	store    *memory.Store
+	orders   OrderUseCase
+	customer entity.User
+	product  entity.Product
+}
+
+// newShop creates a shop whose product has the stock, and whose orders
+// reserve it for reservationTTL.
+func newShop(t *testing.T, stock int, reservationTTL time.Duration) *shop {
+	t.Helper()
+	store := memory.NewStore()
+	customer, err := memory.NewMemoryUserRepository(store).CreateUser(t.Context(), entity.User{Username: "alice", Email: "alice@example.com", Role: entity.RoleCustomer})
+	if err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	product, err := memory.NewMemoryProductRepository(store).CreateProduct(t.Context(), entity.Product{Name: "Mug", Price: entity.Money{Amount: 1000, Currency: "USD"}, Stock: stock})
+	if err != nil {
+		t.Fatalf("CreateProduct: %v", err)
+	}
+	return &shop{
+		store:    store,
+		orders:   NewOrderUseCase(memory.NewMemoryUnitOfWork(store), discardMetrics{}, reservationTTL),
+		customer: customer,
+		product:  product,
+	}
+}
+
+// stock returns the current stock of the shop's product.
+func (s *shop) stock(t *testing.T) int {
+	t.Helper()
+	product, err := memory.NewMemoryProductRepository(s.store).GetProductByID(t.Context(), s.product.ID)
+	if err != nil {
+		t.Fatalf("GetProductByID: %v", err)
+	}
+	return product.Stock
+}
+
+// order places an order for quantity units of the shop's product.
+func (s *shop) order(t *testing.T, quantity int) entity.Order {
+	t.Helper()
+	order, err := s.orders.CreateOrder(t.Context(), entity.Order{
+		CustomerID: s.customer.ID,
+		Items:      []entity.OrderItem{{ProductID: s.product.ID, Quantity: quantity}},
+	})
+	if err != nil {
+		t.Fatalf("CreateOrder: %v", err)
+	}
+	return order
+}
+
+// reservations returns the stock reservations of the order.
+func (s *shop) reservations(t *testing.T, orderID int) []entity.InventoryReservation {
+	t.Helper()
+	reservations, err := memory.NewMemoryInventoryReservationRepository(s.store).GetOrderReservations(t.Context(), orderID)
+	if err != nil {
+		t.Fatalf("GetOrderReservations: %v", err)
+	}
+	return reservations
+}
+
+// reservationStatuses returns the statuses of the order's reservations.
+func (s *shop) reservationStatuses(t *testing.T, orderID int) []entity.ReservationStatus {
+	t.Helper()
+	var statuses []entity.ReservationStatus
+	for _, reservation := range s.reservations(t, orderID) {
+		statuses = append(statuses, reservation.Status)
+	}
+	return statuses
+}
+
+func TestCreateOrderQuantities(t *testing.T) {
+	tests := []struct {
+		name       string
+		quantities []int
+		want       int // merged quantity, or 0 if the order is rejected
+	}{
+		{name: "merged", quantities: []int{3, 4}, want: 7},
+		{name: "merged up to the maximum", quantities: []int{entity.MaxQuantity - 1, 1}, want: entity.MaxQuantity},
+		{name: "line above the maximum", quantities: []int{entity.MaxQuantity + 1}},
+		{name: "merged above the maximum", quantities: []int{entity.MaxQuantity, 1}},
+		{name: "merge that would overflow", quantities: []int{math.MaxInt, math.MaxInt}},
+		{name: "negative", quantities: []int{2, -1}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := newShop(t, 2*entity.MaxQuantity, time.Hour)
+			order := entity.Order{CustomerID: s.customer.ID}
+			for _, quantity := range test.quantities {
+				order.Items = append(order.Items, entity.OrderItem{ProductID: s.product.ID, Quantity: quantity})
+			}
+
+			created, err := s.orders.CreateOrder(t.Context(), order)
+			if test.want == 0 {
+				if !errors.Is(err, errs.ErrValidation) {
+					t.Fatalf("CreateOrder returned %v, want a validation error", err)
+				}
+				if stock := s.stock(t); stock != 2*entity.MaxQuantity {
+					t.Fatalf("stock after a rejected order = %d, want it unchanged", stock)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CreateOrder: %v", err)
+			}
+			if len(created.Items) != 1 || created.Items[0].Quantity != test.want {
+				t.Fatalf("CreateOrder returned items %+v, want one line of %d", created.Items, test.want)
+			}
+			if stock := s.stock(t); stock != 2*entity.MaxQuantity-test.want {
+				t.Fatalf("stock = %d, want %d", stock, 2*entity.MaxQuantity-test.want)
+			}
+		})
+	}
+}
+
+func TestTransitionOrderAuthorization(t *testing.T) {
+	staff := entity.Principal{UserID: 100, Role: entity.RoleStaff}
+	tests := []struct {
+		name    string
+		by      string // owner, stranger or staff
+		before  []entity.OrderStatus
+		next    entity.OrderStatus
+		wantErr error
+	}{
+		{name: "owner cancels", by: "owner", next: entity.OrderStatusCancelled},
+		{name: "owner pays", by: "owner", next: entity.OrderStatusPaid, wantErr: errs.ErrForbidden},
+		{name: "owner fulfills", by: "owner", before: []entity.OrderStatus{entity.OrderStatusPaid}, next: entity.OrderStatusFulfilled, wantErr: errs.ErrForbidden},
+		{name: "owner refunds", by: "owner", before: []entity.OrderStatus{entity.OrderStatusPaid}, next: entity.OrderStatusRefunded, wantErr: errs.ErrForbidden},
+		{name: "stranger cancels", by: "stranger", next: entity.OrderStatusCancelled, wantErr: errs.ErrForbidden},
+		{name: "staff pays", by: "staff", next: entity.OrderStatusPaid},
+		{name: "staff cancels", by: "staff", next: entity.OrderStatusCancelled},
+		{name: "staff refunds", by: "staff", before: []entity.OrderStatus{entity.OrderStatusPaid}, next: entity.OrderStatusRefunded},
+		{name: "staff skips a step", by: "staff", next: entity.OrderStatusShipped, wantErr: errs.ErrConflict},
+		{name: "owner cancels a cancelled order", by: "owner", before: []entity.OrderStatus{entity.OrderStatusCancelled}, next: entity.OrderStatusCancelled, wantErr: errs.ErrConflict},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := newShop(t, 5, time.Hour)
+			order := s.order(t, 2)
+			for _, status := range test.before {
+				if _, err := s.orders.TransitionOrder(t.Context(), staff, order.ID, status); err != nil {
+					t.Fatalf("TransitionOrder to %s: %v", status, err)
+				}
+			}
+			before := s.stock(t)
+
+			principal := map[string]entity.Principal{
+				"owner":    {UserID: s.customer.ID, Role: entity.RoleCustomer},
+				"stranger": {UserID: s.customer.ID + 1, Role: entity.RoleCustomer},
+				"staff":    staff,
+			}[test.by]
+			got, err := s.orders.TransitionOrder(t.Context(), principal, order.ID, test.next)
+			if test.wantErr != nil {
+				if !errors.Is(err, test.wantErr) {
+					t.Fatalf("TransitionOrder returned %v, want %v", err, test.wantErr)
+				}
+				if stock := s.stock(t); stock != before {
+					t.Fatalf("stock after a rejected transition = %d, want %d", stock, before)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("TransitionOrder: %v", err)
+			}
+			if got.Status != test.next {
+				t.Fatalf("status = %s, want %s", got.Status, test.next)
+			}
+			want := before
+			if !test.next.HoldsStock() {
+				want += 2
+			}
+			if stock := s.stock(t); stock != want {
+				t.Fatalf("stock = %d, want %d", stock, want)
+			}
+		})
+	}
+}
+
+func TestExpireReservations(t *testing.T) {
+	staff := entity.Principal{UserID: 100, Role: entity.RoleStaff}
+
+	t.Run("cancels expired orders and restocks", func(t *testing.T) {
+		s := newShop(t, 5, -time.Minute)
+		order := s.order(t, 2)
+		if stock := s.stock(t); stock != 3 {
+			t.Fatalf("stock after ordering = %d, want 3", stock)
+		}
+
+		cancelled, err := s.orders.ExpireReservations(t.Context())
+		if err != nil || cancelled != 1 {
+			t.Fatalf("ExpireReservations returned %d, %v; want 1 order cancelled", cancelled, err)
+		}
+		got, err := s.orders.GetOrderByID(t.Context(), staff, order.ID)
+		if err != nil {
+			t.Fatalf("GetOrderByID: %v", err)
+		}
+		if got.Status != entity.OrderStatusCancelled {
+			t.Fatalf("status = %s, want %s", got.Status, entity.OrderStatusCancelled)
+		}
+		if stock := s.stock(t); stock != 5 {
+			t.Fatalf("stock after expiry = %d, want 5", stock)
+		}
+		if statuses := s.reservationStatuses(t, order.ID); !slices.Equal(statuses, []entity.ReservationStatus{entity.ReservationReleased}) {
+			t.Fatalf("reservations = %v, want one released", statuses)
+		}
+
+		cancelled, err = s.orders.ExpireReservations(t.Context())
+		if err != nil || cancelled != 0 {
+			t.Fatalf("second ExpireReservations returned %d, %v; want nothing cancelled", cancelled, err)
+		}
+		if stock := s.stock(t); stock != 5 {
+			t.Fatalf("stock after a second sweep = %d, want 5", stock)
+		}
+	})
+
+	t.Run("paying after expiry fails", func(t *testing.T) {
+		s := newShop(t, 5, -time.Minute)
+		order := s.order(t, 2)
+
+		_, err := s.orders.TransitionOrder(t.Context(), staff, order.ID, entity.OrderStatusPaid)
+		if !errors.Is(err, ErrReservationExpired) {
+			t.Fatalf("TransitionOrder to paid returned %v, want ErrReservationExpired", err)
+		}
+		got, err := s.orders.GetOrderByID(t.Context(), staff, order.ID)
+		if err != nil {
+			t.Fatalf("GetOrderByID: %v", err)
+		}
+		if got.Status != entity.OrderStatusPending {
+			t.Fatalf("status after a failed payment = %s, want %s", got.Status, entity.OrderStatusPending)
+		}
+		if stock := s.stock(t); stock != 3 {
+			t.Fatalf("stock after a failed payment = %d, want 3 until the sweeper runs", stock)
+		}
+	})
+
+	t.Run("skips paid orders", func(t *testing.T) {
+		s := newShop(t, 5, 200*time.Millisecond)
+		order := s.order(t, 2)
+		if _, err := s.orders.TransitionOrder(t.Context(), staff, order.ID, entity.OrderStatusPaid); err != nil {
+			t.Fatalf("TransitionOrder to paid: %v", err)
+		}
+		if statuses := s.reservationStatuses(t, order.ID); !slices.Equal(statuses, []entity.ReservationStatus{entity.ReservationCommitted}) {
+			t.Fatalf("reservations after paying = %v, want one committed", statuses)
+		}
+		// Sweep once the reservation would have expired had it not been paid
+		time.Sleep(time.Until(s.reservations(t, order.ID)[0].ExpiresAt))
+
+		cancelled, err := s.orders.ExpireReservations(t.Context())
+		if err != nil || cancelled != 0 {
+			t.Fatalf("ExpireReservations returned %d, %v; want nothing cancelled", cancelled, err)
+		}
+		got, err := s.orders.GetOrderByID(t.Context(), staff, order.ID)
+		if err != nil {
+			t.Fatalf("GetOrderByID: %v", err)
+		}
+		if got.Status != entity.OrderStatusPaid {
+			t.Fatalf("status = %s, want %s", got.Status, entity.OrderStatusPaid)
+		}
+		if stock := s.stock(t); stock != 3 {
+			t.Fatalf("stock = %d, want 3", stock)
+		}
+	})
+}
+
+func TestDeleteAndRestoreOrder(t *testing.T) {
+	admin := entity.Principal{UserID: 100, Role: entity.RoleAdmin}
+	s := newShop(t, 5, time.Hour)
+	order := s.order(t, 2)
+
+	if err := s.orders.DeleteOrder(t.Context(), admin, order.ID); err != nil {
+		t.Fatalf("DeleteOrder: %v", err)
+	}
+	if stock := s.stock(t); stock != 5 {
+		t.Fatalf("stock after deleting = %d, want 5", stock)
+	}
+	if statuses := s.reservationStatuses(t, order.ID); !slices.Equal(statuses, []entity.ReservationStatus{entity.ReservationReleased}) {
+		t.Fatalf("reservations after deleting = %v, want one released", statuses)
+	}
+
+	restored, err := s.orders.RestoreOrder(t.Context(), admin, order.ID)
+	if err != nil {
+		t.Fatalf("RestoreOrder: %v", err)
+	}
+	if restored.Status != entity.OrderStatusPending {
+		t.Fatalf("status after restoring = %s, want %s", restored.Status, entity.OrderStatusPending)
+	}
+	if stock := s.stock(t); stock != 3 {
+		t.Fatalf("stock after restoring = %d, want 3", stock)
+	}
+	var held []entity.InventoryReservation
+	for _, reservation := range s.reservations(t, order.ID) {
+		if reservation.Status == entity.ReservationHeld {
+			held = append(held, reservation)
+		}
+	}
+	if len(held) != 1 || held[0].Quantity != 2 || !held[0].ExpiresAt.After(time.Now()) {
+		t.Fatalf("held reservations after restoring = %+v, want one of 2 that has not expired", held)
+	}
+
+	// The restored order holds its stock again, so paying for it works
+	if _, err := s.orders.TransitionOrder(t.Context(), admin, order.ID, entity.OrderStatusPaid); err != nil {
+		t.Fatalf("TransitionOrder to paid after restoring: %v", err)
+	}
+}
+
+func TestCancelOrderOfDeletedProduct(t *testing.T) {
+	s := newShop(t, 5, time.Hour)
+	order := s.order(t, 2)
+	products := NewProductUseCase(memory.NewMemoryProductRepository(s.store), memory.NewMemoryProductSearch(s.store))
+	if err := products.DeleteProduct(t.Context(), s.product.ID); err != nil {
+		t.Fatalf("DeleteProduct: %v", err)
+	}
+
+	owner := entity.Principal{UserID: s.customer.ID, Role: entity.RoleCustomer}
+	if _, err := s.orders.TransitionOrder(t.Context(), owner, order.ID, entity.OrderStatusCancelled); err != nil {
+		t.Fatalf("TransitionOrder to cancelled: %v", err)
+	}
+	restored, err := products.RestoreProduct(t.Context(), s.product.ID)
+	if err != nil {
+		t.Fatalf("RestoreProduct: %v", err)
+	}
+	if restored.Stock != 5 {
+		t.Fatalf("stock of the restored product = %d, want the cancelled order's 2 back for 5", restored.Stock)
+	}
+}
@@ -0,0 +1,12 @@
+package usecase
+
+// PasswordHasher hashes and verifies user passwords.
+type PasswordHasher interface {
+	// Hash returns a one-way hash of the plain text password.
+	Hash(password string) (string, error)
+	// Compare reports whether the plain text password matches the hash.
+	Compare(hash, password string) bool
+	// NeedsRehash reports whether the hash was produced with parameters
+	// other than the hasher's current ones and should be replaced.
+	NeedsRehash(hash string) bool
+}
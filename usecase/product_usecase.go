@@ -1,64 +1,137 @@
 package usecase
 
 import (
+	"context"
+	"strings"
+
 	"github.com/witchakornb/basic-ecommerce/domain/entity"
+	"github.com/witchakornb/basic-ecommerce/domain/errs"
 	"github.com/witchakornb/basic-ecommerce/domain/repository"
 )
 
+// ErrEmptySearchQuery is returned when searching without any search terms.
+var ErrEmptySearchQuery = errs.New(errs.ErrValidation, "empty_search_query", "search query must not be empty")
+
 type ProductUseCase interface {
-	CreateProduct(product entity.Product) (entity.Product, error)
-	GetProductByID(id int) (entity.Product, error)
-	GetAllProducts() ([]entity.Product, error)
-	UpdateProduct(product entity.Product) (entity.Product, error)
-	DeleteProduct(id int) error
+	CreateProduct(ctx context.Context, product entity.Product) (entity.Product, error)
+	GetProductByID(ctx context.Context, id int) (entity.Product, error)
+	GetAllProducts(ctx context.Context, filter repository.ProductFilter, opts repository.ListOptions) (repository.Page[entity.Product], error)
+	UpdateProduct(ctx context.Context, product entity.Product) (entity.Product, error)
+	PatchProduct(ctx context.Context, product entity.Product, fields []repository.ProductField) (entity.Product, error)
+	DeleteProduct(ctx context.Context, id int) error
+	RestoreProduct(ctx context.Context, id int) (entity.Product, error)
+	SearchProducts(ctx context.Context, query string, limit int) ([]repository.ProductSearchResult, error)
 }
 
 type ProductUseCaseImpl struct {
-	ProductRepo repository.ProductRepository
+	ProductRepo   repository.ProductRepository
+	ProductSearch repository.ProductSearch
 }
 
-func NewProductUseCase(productRepo repository.ProductRepository) ProductUseCase {
+func NewProductUseCase(productRepo repository.ProductRepository, productSearch repository.ProductSearch) ProductUseCase {
 	return &ProductUseCaseImpl{
-		ProductRepo: productRepo,
+		ProductRepo:   productRepo,
+		ProductSearch: productSearch,
 	}
 }
 
-func (p *ProductUseCaseImpl) CreateProduct(product entity.Product) (entity.Product, error) {
-	product, err := p.ProductRepo.CreateProduct(product)
+func (p *ProductUseCaseImpl) CreateProduct(ctx context.Context, product entity.Product) (_ entity.Product, err error) {
+	ctx, span := startSpan(ctx, "ProductUseCase.CreateProduct")
+	defer func() { endSpan(span, err) }()
+
+	if err := product.Validate(); err != nil {
+		return entity.Product{}, err
+	}
+	product, err = p.ProductRepo.CreateProduct(ctx, product)
 	if err != nil {
 		return entity.Product{}, err
 	}
 	return product, nil
 }
 
-func (p *ProductUseCaseImpl) GetProductByID(id int) (entity.Product, error) {
-	product, err := p.ProductRepo.GetProductByID(id)
+func (p *ProductUseCaseImpl) GetProductByID(ctx context.Context, id int) (_ entity.Product, err error) {
+	ctx, span := startSpan(ctx, "ProductUseCase.GetProductByID")
+	defer func() { endSpan(span, err) }()
+
+	product, err := p.ProductRepo.GetProductByID(ctx, id)
 	if err != nil {
 		return entity.Product{}, err
 	}
 	return product, nil
 }
 
-func (p *ProductUseCaseImpl) GetAllProducts() ([]entity.Product, error) {
-	products, err := p.ProductRepo.GetAllProducts()
+func (p *ProductUseCaseImpl) GetAllProducts(ctx context.Context, filter repository.ProductFilter, opts repository.ListOptions) (_ repository.Page[entity.Product], err error) {
+	ctx, span := startSpan(ctx, "ProductUseCase.GetAllProducts")
+	defer func() { endSpan(span, err) }()
+
+	products, err := p.ProductRepo.GetAllProducts(ctx, filter, opts)
 	if err != nil {
-		return nil, err
+		return repository.Page[entity.Product]{}, err
 	}
 	return products, nil
 }
 
-func (p *ProductUseCaseImpl) UpdateProduct(product entity.Product) (entity.Product, error) {
-	product, err := p.ProductRepo.UpdateProduct(product)
-	if err != nil {
+// UpdateProduct replaces the fields of the product with the same ID, or
+// returns a not-found error if there is none. Timestamps are kept.
+func (p *ProductUseCaseImpl) UpdateProduct(ctx context.Context, product entity.Product) (_ entity.Product, err error) {
+	ctx, span := startSpan(ctx, "ProductUseCase.UpdateProduct")
+	defer func() { endSpan(span, err) }()
+
+	return p.updateProduct(ctx, product, repository.AllProductFields)
+}
+
+// PatchProduct writes only the given fields of the product with the same
+// ID, or returns a not-found error if there is none. product must be the
+// whole patched product, which is validated like a replacement. Leaving out
+// the stock keeps any stock taken by orders since the product was read.
+func (p *ProductUseCaseImpl) PatchProduct(ctx context.Context, product entity.Product, fields []repository.ProductField) (_ entity.Product, err error) {
+	ctx, span := startSpan(ctx, "ProductUseCase.PatchProduct")
+	defer func() { endSpan(span, err) }()
+
+	return p.updateProduct(ctx, product, fields)
+}
+
+// updateProduct validates the product and writes its fields.
+func (p *ProductUseCaseImpl) updateProduct(ctx context.Context, product entity.Product, fields []repository.ProductField) (entity.Product, error) {
+	if err := product.Validate(); err != nil {
 		return entity.Product{}, err
 	}
-	return product, nil
+	return p.ProductRepo.UpdateProduct(ctx, product, fields)
 }
 
-func (p *ProductUseCaseImpl) DeleteProduct(id int) error {
-	err := p.ProductRepo.DeleteProduct(id)
+// DeleteProduct deletes a product, or returns a not-found error if there is none with the ID.
+func (p *ProductUseCaseImpl) DeleteProduct(ctx context.Context, id int) (err error) {
+	ctx, span := startSpan(ctx, "ProductUseCase.DeleteProduct")
+	defer func() { endSpan(span, err) }()
+
+	if _, err := p.ProductRepo.GetProductByID(ctx, id); err != nil {
+		return err
+	}
+	err = p.ProductRepo.DeleteProduct(ctx, id)
 	if err != nil {
 		return err
 	}
 	return nil
 }
+
+// RestoreProduct undeletes a soft-deleted product.
+func (p *ProductUseCaseImpl) RestoreProduct(ctx context.Context, id int) (_ entity.Product, err error) {
+	ctx, span := startSpan(ctx, "ProductUseCase.RestoreProduct")
+	defer func() { endSpan(span, err) }()
+
+	return p.ProductRepo.RestoreProduct(ctx, id)
+}
+
+// SearchProducts returns up to limit products matching the query, most relevant first.
+func (p *ProductUseCaseImpl) SearchProducts(ctx context.Context, query string, limit int) (_ []repository.ProductSearchResult, err error) {
+	ctx, span := startSpan(ctx, "ProductUseCase.SearchProducts")
+	defer func() { endSpan(span, err) }()
+
+	if strings.TrimSpace(query) == "" {
+		return nil, ErrEmptySearchQuery
+	}
+	if limit <= 0 || limit > repository.MaxListLimit {
+		limit = repository.DefaultListLimit
+	}
+	return p.ProductSearch.SearchProducts(ctx, query, limit)
+}
@@ -0,0 +1,14 @@
+package usecase
+
+import "github.com/witchakornb/basic-ecommerce/domain/entity"
+
+// SalesMetrics records sales for monitoring. It is only told about orders
+// whose transaction committed.
+type SalesMetrics interface {
+	// OrderCreated records a placed order.
+	OrderCreated(order entity.Order)
+	// OrderOutOfStock records an order rejected because a product did not have enough stock.
+	OrderOutOfStock()
+	// OrderPaid records the revenue of an order that was paid.
+	OrderPaid(order entity.Order)
+}
@@ -0,0 +1,33 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/witchakornb/basic-ecommerce/domain/errs"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer creates the spans of the use-case methods.
+var tracer = otel.Tracer("github.com/witchakornb/basic-ecommerce/usecase")
+
+// startSpan starts the span of a use-case method, named like
+// "OrderUseCase.CreateOrder". The returned context carries it, so the
+// transactions and statements of the method become its children.
+func startSpan(ctx context.Context, name string) (context.Context, trace.Span) {
+	return tracer.Start(ctx, name)
+}
+
+// endSpan ends the span of a method that returned err. Domain errors, such
+// as a missing record, are recorded on the span; only unexpected failures
+// mark it as failed.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		if errs.KindOf(err) == nil {
+			span.SetStatus(codes.Error, err.Error())
+		}
+	}
+	span.End()
+}
@@ -1,55 +1,212 @@
 package usecase
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
 	"github.com/witchakornb/basic-ecommerce/domain/entity"
+	"github.com/witchakornb/basic-ecommerce/domain/errs"
 	"github.com/witchakornb/basic-ecommerce/domain/repository"
 )
 
+// ErrInvalidCredentials is returned when an email/password pair does not match a user.
+var ErrInvalidCredentials = errs.New(errs.ErrUnauthorized, "invalid_credentials", "invalid email or password")
+
+const (
+	// MinPasswordLength is the minimum length of a password.
+	MinPasswordLength = 8
+	// MaxPasswordLength is the maximum length of a password in bytes;
+	// bcrypt does not accept longer passwords.
+	MaxPasswordLength = 72
+)
+
 type UserUseCase interface {
-	CreateUser(user entity.User) (entity.User, error)
-	GetUserByID(id int) (entity.User, error)
-	UpdateUser(user entity.User) (entity.User, error)
-	DeleteUser(id int) error
+	CreateUser(ctx context.Context, user entity.User) (entity.User, error)
+	GetUserByID(ctx context.Context, id int) (entity.User, error)
+	GetAllUsers(ctx context.Context, filter repository.UserFilter, opts repository.ListOptions) (repository.Page[entity.User], error)
+	UpdateUser(ctx context.Context, user entity.User) (entity.User, error)
+	DeleteUser(ctx context.Context, id int) error
+	RestoreUser(ctx context.Context, id int) (entity.User, error)
+	VerifyCredentials(ctx context.Context, email, password string) (entity.User, error)
 }
 
 type UserUseCaseImpl struct {
 	UserRepo repository.UserRepository
+	Hasher   PasswordHasher
+
+	// dummyHash is compared against when no user matches an email so that
+	// VerifyCredentials takes the same time whether or not the user exists.
+	dummyHash     string
+	dummyHashOnce sync.Once
 }
 
-func NewUserUseCase(userRepo repository.UserRepository) UserUseCase {
+func NewUserUseCase(userRepo repository.UserRepository, hasher PasswordHasher) UserUseCase {
 	return &UserUseCaseImpl{
 		UserRepo: userRepo,
+		Hasher:   hasher,
 	}
 }
 
-func (u *UserUseCaseImpl) CreateUser(user entity.User) (entity.User, error) {
-	user, err := u.UserRepo.CreateUser(user)
+// CreateUser hashes the password and stores the user. Users without a role become customers.
+func (u *UserUseCaseImpl) CreateUser(ctx context.Context, user entity.User) (_ entity.User, err error) {
+	ctx, span := startSpan(ctx, "UserUseCase.CreateUser")
+	defer func() { endSpan(span, err) }()
+
+	if user.Role == "" {
+		user.Role = entity.RoleCustomer
+	}
+	if err := validateUser(user, user.Password, true); err != nil {
+		return entity.User{}, err
+	}
+
+	hash, err := u.Hasher.Hash(user.Password)
+	if err != nil {
+		return entity.User{}, err
+	}
+	user.Password = hash
+
+	user, err = u.UserRepo.CreateUser(ctx, user)
 	if err != nil {
 		return entity.User{}, err
 	}
 	return user, nil
 }
 
-func (u *UserUseCaseImpl) GetUserByID(id int) (entity.User, error) {
-	user, err := u.UserRepo.GetUserByID(id)
+func (u *UserUseCaseImpl) GetUserByID(ctx context.Context, id int) (_ entity.User, err error) {
+	ctx, span := startSpan(ctx, "UserUseCase.GetUserByID")
+	defer func() { endSpan(span, err) }()
+
+	user, err := u.UserRepo.GetUserByID(ctx, id)
 	if err != nil {
 		return entity.User{}, err
 	}
 	return user, nil
 }
 
-func (u *UserUseCaseImpl) UpdateUser(user entity.User) (entity.User, error) {
-	user, err := u.UserRepo.UpdateUser(user)
+func (u *UserUseCaseImpl) GetAllUsers(ctx context.Context, filter repository.UserFilter, opts repository.ListOptions) (_ repository.Page[entity.User], err error) {
+	ctx, span := startSpan(ctx, "UserUseCase.GetAllUsers")
+	defer func() { endSpan(span, err) }()
+
+	users, err := u.UserRepo.GetAllUsers(ctx, filter, opts)
+	if err != nil {
+		return repository.Page[entity.User]{}, err
+	}
+	return users, nil
+}
+
+// UpdateUser updates a user. An empty password or role keeps the stored
+// value, a new password is hashed before it is persisted. Timestamps are kept.
+func (u *UserUseCaseImpl) UpdateUser(ctx context.Context, user entity.User) (_ entity.User, err error) {
+	ctx, span := startSpan(ctx, "UserUseCase.UpdateUser")
+	defer func() { endSpan(span, err) }()
+
+	existing, err := u.UserRepo.GetUserByID(ctx, user.ID)
+	if err != nil {
+		return entity.User{}, err
+	}
+
+	if user.Role == "" {
+		user.Role = existing.Role
+	}
+	user.CreatedAt = existing.CreatedAt
+	user.DeletedAt = existing.DeletedAt
+	if err := validateUser(user, user.Password, false); err != nil {
+		return entity.User{}, err
+	}
+
+	if user.Password == "" {
+		user.Password = existing.Password
+	} else {
+		hash, err := u.Hasher.Hash(user.Password)
+		if err != nil {
+			return entity.User{}, err
+		}
+		user.Password = hash
+	}
+
+	user, err = u.UserRepo.UpdateUser(ctx, user)
 	if err != nil {
 		return entity.User{}, err
 	}
 	return user, nil
 }
 
-func (u *UserUseCaseImpl) DeleteUser(id int) error {
-	err := u.UserRepo.DeleteUser(id)
+// DeleteUser deletes a user, or returns a not-found error if there is none with the ID.
+func (u *UserUseCaseImpl) DeleteUser(ctx context.Context, id int) (err error) {
+	ctx, span := startSpan(ctx, "UserUseCase.DeleteUser")
+	defer func() { endSpan(span, err) }()
+
+	if _, err := u.UserRepo.GetUserByID(ctx, id); err != nil {
+		return err
+	}
+	err = u.UserRepo.DeleteUser(ctx, id)
 	if err != nil {
 		return err
 	}
 	return nil
 }
+
+// RestoreUser undeletes a soft-deleted user.
+func (u *UserUseCaseImpl) RestoreUser(ctx context.Context, id int) (_ entity.User, err error) {
+	ctx, span := startSpan(ctx, "UserUseCase.RestoreUser")
+	defer func() { endSpan(span, err) }()
+
+	return u.UserRepo.RestoreUser(ctx, id)
+}
+
+// VerifyCredentials returns the user matching the email and password.
+// If the stored hash was produced with outdated parameters it is replaced
+// with a fresh hash of the same password.
+func (u *UserUseCaseImpl) VerifyCredentials(ctx context.Context, email, password string) (_ entity.User, err error) {
+	ctx, span := startSpan(ctx, "UserUseCase.VerifyCredentials")
+	defer func() { endSpan(span, err) }()
+
+	user, err := u.UserRepo.GetUserByEmail(ctx, email)
+	if errors.Is(err, errs.ErrNotFound) {
+		u.Hasher.Compare(u.getDummyHash(), password)
+		return entity.User{}, ErrInvalidCredentials
+	}
+	if err != nil {
+		return entity.User{}, err
+	}
+	if !u.Hasher.Compare(user.Password, password) {
+		return entity.User{}, ErrInvalidCredentials
+	}
+
+	if u.Hasher.NeedsRehash(user.Password) {
+		hash, err := u.Hasher.Hash(password)
+		if err != nil {
+			return entity.User{}, err
+		}
+		user.Password = hash
+		user, err = u.UserRepo.UpdateUser(ctx, user)
+		if err != nil {
+			return entity.User{}, err
+		}
+	}
+	return user, nil
+}
+
+func (u *UserUseCaseImpl) getDummyHash() string {
+	u.dummyHashOnce.Do(func() {
+		u.dummyHash, _ = u.Hasher.Hash("dummy-password")
+	})
+	return u.dummyHash
+}
+
+// validateUser checks the invariants of the user and the rules for its
+// plain-text password. An empty password is only accepted if not required.
+func validateUser(user entity.User, password string, passwordRequired bool) error {
+	fields := errs.Fields(user.Validate())
+	switch {
+	case password == "" && passwordRequired:
+		fields = append(fields, errs.FieldError{Field: "password", Reason: "is required"})
+	case password != "" && len(password) < MinPasswordLength:
+		fields = append(fields, errs.FieldError{Field: "password", Reason: fmt.Sprintf("must be at least %d characters long", MinPasswordLength)})
+	case len(password) > MaxPasswordLength:
+		fields = append(fields, errs.FieldError{Field: "password", Reason: fmt.Sprintf("must be at most %d bytes long", MaxPasswordLength)})
+	}
+	return errs.Invalid(fields...)
+}